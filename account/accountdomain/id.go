@@ -7,14 +7,17 @@ import (
 type User struct{}
 type Workspace struct{}
 type Integration struct{}
+type Impersonation struct{}
 
-func (User) Type() string        { return "user" }
-func (Workspace) Type() string   { return "workspace" }
-func (Integration) Type() string { return "integration" }
+func (User) Type() string          { return "user" }
+func (Workspace) Type() string     { return "workspace" }
+func (Integration) Type() string   { return "integration" }
+func (Impersonation) Type() string { return "impersonation" }
 
 type UserID = idx.ID[User]
 type WorkspaceID = idx.ID[Workspace]
 type IntegrationID = idx.ID[Integration]
+type ImpersonationID = idx.ID[Impersonation]
 
 type UserIDList = idx.List[User]
 type WorkspaceIDList = idx.List[Workspace]
@@ -34,4 +37,6 @@ var MustIntegrationID = idx.Must[Integration]
 var IntegrationIDFrom = idx.From[Integration]
 var IntegrationIDFromRef = idx.FromRef[Integration]
 
+var NewImpersonationID = idx.New[Impersonation]
+
 var ErrInvalidID = idx.ErrInvalidID
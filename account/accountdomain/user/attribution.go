@@ -0,0 +1,12 @@
+package user
+
+// Attribution is the acquisition channel of a user recorded at signup, e.g. UTM parameters.
+type Attribution struct {
+	Source   string
+	Medium   string
+	Campaign string
+}
+
+func (a Attribution) IsEmpty() bool {
+	return a == Attribution{}
+}
@@ -0,0 +1,12 @@
+package user
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAttribution_IsEmpty(t *testing.T) {
+	assert.True(t, Attribution{}.IsEmpty())
+	assert.False(t, Attribution{Campaign: "x"}.IsEmpty())
+}
@@ -0,0 +1,27 @@
+package user
+
+import (
+	"time"
+
+	"github.com/reearth/reearthx/account/accountdomain"
+	"github.com/reearth/reearthx/util"
+)
+
+// Impersonation is an audit record of an agent acting as the target user.
+type Impersonation struct {
+	ID        accountdomain.ImpersonationID
+	Agent     ID
+	Target    ID
+	Reason    string
+	CreatedAt time.Time
+}
+
+func NewImpersonation(agent, target ID, reason string) *Impersonation {
+	return &Impersonation{
+		ID:        accountdomain.NewImpersonationID(),
+		Agent:     agent,
+		Target:    target,
+		Reason:    reason,
+		CreatedAt: util.Now(),
+	}
+}
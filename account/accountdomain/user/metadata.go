@@ -0,0 +1,50 @@
+package user
+
+import "strings"
+
+// Metadata is arbitrary data attached to a user. Nested objects are represented as map[string]any.
+type Metadata map[string]any
+
+// Get returns the value at the dotted path such as "source.campaign".
+func (m Metadata) Get(path string) (any, bool) {
+	if m == nil || path == "" {
+		return nil, false
+	}
+
+	var cur any = map[string]any(m)
+	for _, k := range strings.Split(path, ".") {
+		obj, ok := cur.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if cur, ok = obj[k]; !ok {
+			return nil, false
+		}
+	}
+	return cur, true
+}
+
+func (m Metadata) Clone() Metadata {
+	if m == nil {
+		return nil
+	}
+	return Metadata(cloneMetadataValue(map[string]any(m)).(map[string]any))
+}
+
+func cloneMetadataValue(v any) any {
+	switch w := v.(type) {
+	case map[string]any:
+		res := make(map[string]any, len(w))
+		for k, e := range w {
+			res[k] = cloneMetadataValue(e)
+		}
+		return res
+	case []any:
+		res := make([]any, 0, len(w))
+		for _, e := range w {
+			res = append(res, cloneMetadataValue(e))
+		}
+		return res
+	}
+	return v
+}
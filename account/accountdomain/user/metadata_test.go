@@ -0,0 +1,66 @@
+package user
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetadata_Get(t *testing.T) {
+	m := Metadata{
+		"source": "campaign-x",
+		"profile": map[string]any{
+			"country": "jp",
+			"tags":    []any{"a"},
+		},
+	}
+
+	got, ok := m.Get("source")
+	assert.True(t, ok)
+	assert.Equal(t, "campaign-x", got)
+
+	got, ok = m.Get("profile.country")
+	assert.True(t, ok)
+	assert.Equal(t, "jp", got)
+
+	got, ok = m.Get("profile.tags")
+	assert.True(t, ok)
+	assert.Equal(t, []any{"a"}, got)
+
+	got, ok = m.Get("profile.city")
+	assert.False(t, ok)
+	assert.Nil(t, got)
+
+	got, ok = m.Get("source.x")
+	assert.False(t, ok)
+	assert.Nil(t, got)
+
+	got, ok = m.Get("")
+	assert.False(t, ok)
+	assert.Nil(t, got)
+
+	got, ok = Metadata(nil).Get("source")
+	assert.False(t, ok)
+	assert.Nil(t, got)
+}
+
+func TestMetadata_Clone(t *testing.T) {
+	m := Metadata{
+		"profile": map[string]any{
+			"country": "jp",
+			"tags":    []any{"a"},
+		},
+	}
+	got := m.Clone()
+	assert.Equal(t, m, got)
+
+	got["profile"].(map[string]any)["country"] = "us"
+	got["profile"].(map[string]any)["tags"].([]any)[0] = "b"
+	assert.Equal(t, Metadata{
+		"profile": map[string]any{
+			"country": "jp",
+			"tags":    []any{"a"},
+		},
+	}, m)
+	assert.Nil(t, Metadata(nil).Clone())
+}
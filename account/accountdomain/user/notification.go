@@ -0,0 +1,64 @@
+package user
+
+import "strings"
+
+type NotificationCategory string
+
+func (c NotificationCategory) Valid() bool {
+	return c != "" && !strings.ContainsAny(string(c), ".$")
+}
+
+type NotificationChannel string
+
+const (
+	NotificationChannelEmail NotificationChannel = "email"
+	NotificationChannelPush  NotificationChannel = "push"
+	NotificationChannelSMS   NotificationChannel = "sms"
+)
+
+func (c NotificationChannel) Valid() bool {
+	switch c {
+	case NotificationChannelEmail, NotificationChannelPush, NotificationChannelSMS:
+		return true
+	}
+	return false
+}
+
+// NotificationPreferences holds whether each channel is enabled per notification category.
+// Channels that have never been set are treated as disabled.
+type NotificationPreferences map[NotificationCategory]map[NotificationChannel]bool
+
+func (p NotificationPreferences) Enabled(category NotificationCategory, channel NotificationChannel) bool {
+	if p == nil {
+		return false
+	}
+	return p[category][channel]
+}
+
+// Set returns new preferences where only the specified channel of the category is updated.
+func (p NotificationPreferences) Set(category NotificationCategory, channel NotificationChannel, enabled bool) NotificationPreferences {
+	res := p.Clone()
+	if res == nil {
+		res = NotificationPreferences{}
+	}
+	if res[category] == nil {
+		res[category] = map[NotificationChannel]bool{}
+	}
+	res[category][channel] = enabled
+	return res
+}
+
+func (p NotificationPreferences) Clone() NotificationPreferences {
+	if p == nil {
+		return nil
+	}
+	res := make(NotificationPreferences, len(p))
+	for category, channels := range p {
+		c := make(map[NotificationChannel]bool, len(channels))
+		for channel, enabled := range channels {
+			c[channel] = enabled
+		}
+		res[category] = c
+	}
+	return res
+}
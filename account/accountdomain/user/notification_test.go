@@ -0,0 +1,64 @@
+package user
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNotificationCategory_Valid(t *testing.T) {
+	assert.True(t, NotificationCategory("news").Valid())
+	assert.False(t, NotificationCategory("").Valid())
+	assert.False(t, NotificationCategory("a.b").Valid())
+	assert.False(t, NotificationCategory("$a").Valid())
+}
+
+func TestNotificationChannel_Valid(t *testing.T) {
+	assert.True(t, NotificationChannelEmail.Valid())
+	assert.True(t, NotificationChannelPush.Valid())
+	assert.True(t, NotificationChannelSMS.Valid())
+	assert.False(t, NotificationChannel("fax").Valid())
+}
+
+func TestNotificationPreferences_Enabled(t *testing.T) {
+	p := NotificationPreferences{
+		"news": {NotificationChannelEmail: true, NotificationChannelSMS: false},
+	}
+	assert.True(t, p.Enabled("news", NotificationChannelEmail))
+	assert.False(t, p.Enabled("news", NotificationChannelSMS))
+	assert.False(t, p.Enabled("news", NotificationChannelPush))
+	assert.False(t, p.Enabled("billing", NotificationChannelEmail))
+	assert.False(t, NotificationPreferences(nil).Enabled("news", NotificationChannelEmail))
+}
+
+func TestNotificationPreferences_Set(t *testing.T) {
+	p := NotificationPreferences{
+		"news":    {NotificationChannelEmail: true},
+		"billing": {NotificationChannelSMS: true},
+	}
+	got := p.Set("news", NotificationChannelPush, true)
+	assert.Equal(t, NotificationPreferences{
+		"news":    {NotificationChannelEmail: true, NotificationChannelPush: true},
+		"billing": {NotificationChannelSMS: true},
+	}, got)
+	// the original is not changed
+	assert.Equal(t, NotificationPreferences{
+		"news":    {NotificationChannelEmail: true},
+		"billing": {NotificationChannelSMS: true},
+	}, p)
+
+	assert.Equal(t, NotificationPreferences{
+		"news": {NotificationChannelEmail: false},
+	}, NotificationPreferences(nil).Set("news", NotificationChannelEmail, false))
+}
+
+func TestNotificationPreferences_Clone(t *testing.T) {
+	p := NotificationPreferences{
+		"news": {NotificationChannelEmail: true},
+	}
+	got := p.Clone()
+	assert.Equal(t, p, got)
+	got["news"][NotificationChannelEmail] = false
+	assert.True(t, p.Enabled("news", NotificationChannelEmail))
+	assert.Nil(t, NotificationPreferences(nil).Clone())
+}
@@ -31,7 +31,12 @@ func generateToken() string {
 }
 
 func (pr *PasswordReset) Validate(token string) bool {
-	return pr != nil && pr.Token == token && pr.CreatedAt.Add(24*time.Hour).After(time.Now())
+	return pr.ValidateAt(token, util.Now())
+}
+
+// ValidateAt is the same as Validate, but it checks the expiration at the given time.
+func (pr *PasswordReset) ValidateAt(token string, now time.Time) bool {
+	return pr != nil && pr.Token == token && pr.CreatedAt.Add(24*time.Hour).After(now)
 }
 
 func (pr *PasswordReset) Clone() *PasswordReset {
@@ -101,3 +101,12 @@ func TestPasswordResetFrom(t *testing.T) {
 		})
 	}
 }
+
+func TestPasswordReset_ValidateAt(t *testing.T) {
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	pr := PasswordResetFrom("xyz", now)
+	assert.True(t, pr.ValidateAt("xyz", now.Add(23*time.Hour)))
+	assert.False(t, pr.ValidateAt("xyz", now.Add(24*time.Hour)))
+	assert.False(t, pr.ValidateAt("xxx", now))
+	assert.False(t, (*PasswordReset)(nil).ValidateAt("xyz", now))
+}
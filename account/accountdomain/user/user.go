@@ -3,6 +3,7 @@ package user
 import (
 	"errors"
 	"net/mail"
+	"time"
 
 	"github.com/reearth/reearthx/util"
 	"golang.org/x/exp/slices"
@@ -10,7 +11,8 @@ import (
 )
 
 var (
-	ErrInvalidEmail = errors.New("invalid email")
+	ErrInvalidEmail    = errors.New("invalid email")
+	ErrInvalidTimezone = errors.New("invalid timezone")
 )
 
 type User struct {
@@ -22,8 +24,14 @@ type User struct {
 	auths         []Auth
 	lang          language.Tag
 	theme         Theme
+	timezone      string
 	verification  *Verification
 	passwordReset *PasswordReset
+	notifications NotificationPreferences
+	metadata      Metadata
+	tags          []string
+	attribution   Attribution
+	deletedAt     *time.Time
 }
 
 func (u *User) ID() ID {
@@ -50,6 +58,19 @@ func (u *User) Theme() Theme {
 	return u.theme
 }
 
+// Timezone returns the IANA time zone name of the user, e.g. "Asia/Tokyo". It is empty if not set.
+func (u *User) Timezone() string {
+	return u.timezone
+}
+
+// Location returns the time zone of the user. It falls back to UTC if the time zone is not set.
+func (u *User) Location() *time.Location {
+	if l, err := time.LoadLocation(u.timezone); err == nil {
+		return l
+	}
+	return time.UTC
+}
+
 func (u *User) Password() []byte {
 	return u.password
 }
@@ -78,6 +99,76 @@ func (u *User) UpdateTheme(t Theme) {
 	u.theme = t
 }
 
+func (u *User) UpdateTimezone(tz string) error {
+	if !ValidTimezone(tz) {
+		return ErrInvalidTimezone
+	}
+	u.timezone = tz
+	return nil
+}
+
+// ValidTimezone reports whether tz is empty or an IANA time zone name.
+func ValidTimezone(tz string) bool {
+	if tz == "" {
+		return true
+	}
+	// "Local" is accepted by time.LoadLocation but depends on the server
+	if tz == "Local" {
+		return false
+	}
+	_, err := time.LoadLocation(tz)
+	return err == nil
+}
+
+func (u *User) NotificationPreferences() NotificationPreferences {
+	return u.notifications.Clone()
+}
+
+func (u *User) NotifyEnabled(category NotificationCategory, channel NotificationChannel) bool {
+	if u == nil {
+		return false
+	}
+	return u.notifications.Enabled(category, channel)
+}
+
+func (u *User) UpdateNotificationPref(category NotificationCategory, channel NotificationChannel, enabled bool) {
+	u.notifications = u.notifications.Set(category, channel, enabled)
+}
+
+func (u *User) Metadata() Metadata {
+	return u.metadata.Clone()
+}
+
+func (u *User) SetMetadata(m Metadata) {
+	u.metadata = m.Clone()
+}
+
+func (u *User) Tags() []string {
+	return slices.Clone(u.tags)
+}
+
+func (u *User) HasTag(tag string) bool {
+	return slices.Contains(u.tags, tag)
+}
+
+// AddTag adds the tag and returns true if the user did not have it yet.
+func (u *User) AddTag(tag string) bool {
+	if tag == "" || u.HasTag(tag) {
+		return false
+	}
+	u.tags = append(u.tags, tag)
+	return true
+}
+
+func (u *User) RemoveTag(tag string) bool {
+	i := slices.Index(u.tags, tag)
+	if i < 0 {
+		return false
+	}
+	u.tags = slices.Delete(u.tags, i, i+1)
+	return true
+}
+
 func (u *User) Verification() *Verification {
 	return u.verification
 }
@@ -194,6 +285,27 @@ func (u *User) SetVerification(v *Verification) {
 	u.verification = v
 }
 
+func (u *User) Attribution() Attribution {
+	return u.attribution
+}
+
+func (u *User) SetAttribution(a Attribution) {
+	u.attribution = a
+}
+
+// DeletedAt returns the time when the user was soft-deleted, or nil if the user is not deleted.
+func (u *User) DeletedAt() *time.Time {
+	return util.CloneRef(u.deletedAt)
+}
+
+func (u *User) IsDeleted() bool {
+	return u.deletedAt != nil
+}
+
+func (u *User) SetDeletedAt(t *time.Time) {
+	u.deletedAt = util.CloneRef(t)
+}
+
 func (u *User) Clone() *User {
 	return &User{
 		id:            u.id,
@@ -204,7 +316,13 @@ func (u *User) Clone() *User {
 		auths:         slices.Clone(u.auths),
 		lang:          u.lang,
 		theme:         u.theme,
+		timezone:      u.timezone,
 		verification:  util.CloneRef(u.verification),
 		passwordReset: util.CloneRef(u.passwordReset),
+		notifications: u.notifications.Clone(),
+		metadata:      u.metadata.Clone(),
+		tags:          slices.Clone(u.tags),
+		attribution:   u.attribution,
+		deletedAt:     util.CloneRef(u.deletedAt),
 	}
 }
@@ -1,6 +1,8 @@
 package user
 
 import (
+	"time"
+
 	"github.com/reearth/reearthx/account/accountdomain"
 	"github.com/reearth/reearthx/i18n"
 	"github.com/reearth/reearthx/rerror"
@@ -102,6 +104,11 @@ func (b *Builder) Theme(t Theme) *Builder {
 	return b
 }
 
+func (b *Builder) Timezone(tz string) *Builder {
+	b.u.timezone = tz
+	return b
+}
+
 func (b *Builder) LangFrom(lang string) *Builder {
 	if lang == "" {
 		b.u.lang = language.Und
@@ -127,3 +134,31 @@ func (b *Builder) Verification(v *Verification) *Builder {
 	b.u.verification = v
 	return b
 }
+
+func (b *Builder) NotificationPreferences(p NotificationPreferences) *Builder {
+	b.u.notifications = p.Clone()
+	return b
+}
+
+func (b *Builder) Metadata(m Metadata) *Builder {
+	b.u.metadata = m.Clone()
+	return b
+}
+
+func (b *Builder) Tags(tags []string) *Builder {
+	b.u.tags = nil
+	for _, t := range tags {
+		b.u.AddTag(t)
+	}
+	return b
+}
+
+func (b *Builder) Attribution(a Attribution) *Builder {
+	b.u.attribution = a
+	return b
+}
+
+func (b *Builder) DeletedAt(t *time.Time) *Builder {
+	b.u.SetDeletedAt(t)
+	return b
+}
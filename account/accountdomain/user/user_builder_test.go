@@ -296,3 +296,25 @@ func TestBuilder_Verification(t *testing.T) {
 		})
 	}
 }
+
+func TestBuilder_NotificationPreferences(t *testing.T) {
+	p := NotificationPreferences{"news": {NotificationChannelEmail: true}}
+	b := New().NewID().Name("aaa").Email("aaa@bbb.com").NotificationPreferences(p).MustBuild()
+	assert.Equal(t, p, b.NotificationPreferences())
+}
+
+func TestBuilder_Metadata(t *testing.T) {
+	m := Metadata{"source": "campaign-x"}
+	b := New().NewID().Name("aaa").Email("aaa@bbb.com").Metadata(m).MustBuild()
+	assert.Equal(t, m, b.Metadata())
+}
+
+func TestBuilder_Tags(t *testing.T) {
+	b := New().NewID().Name("aaa").Email("aaa@bbb.com").Tags([]string{"a", "b", "a", ""}).MustBuild()
+	assert.Equal(t, []string{"a", "b"}, b.Tags())
+}
+
+func TestBuilder_Attribution(t *testing.T) {
+	b := New().NewID().Name("aaa").Email("aaa@bbb.com").Attribution(Attribution{Source: "google"}).MustBuild()
+	assert.Equal(t, Attribution{Source: "google"}, b.Attribution())
+}
@@ -47,6 +47,26 @@ func TestUser(t *testing.T) {
 	u.UpdateWorkspace(wid)
 	assert.Equal(t, wid, u.Workspace())
 
+	assert.False(t, u.NotifyEnabled("news", NotificationChannelEmail))
+	u.UpdateNotificationPref("news", NotificationChannelEmail, true)
+	u.UpdateNotificationPref("billing", NotificationChannelSMS, true)
+	u.UpdateNotificationPref("news", NotificationChannelPush, false)
+	assert.True(t, u.NotifyEnabled("news", NotificationChannelEmail))
+	assert.False(t, u.NotifyEnabled("news", NotificationChannelPush))
+	assert.Equal(t, NotificationPreferences{
+		"news":    {NotificationChannelEmail: true, NotificationChannelPush: false},
+		"billing": {NotificationChannelSMS: true},
+	}, u.NotificationPreferences())
+
+	u.SetMetadata(Metadata{"source": "campaign-x"})
+	assert.Equal(t, Metadata{"source": "campaign-x"}, u.Metadata())
+
+	assert.NoError(t, u.UpdateTimezone("Asia/Tokyo"))
+	assert.Equal(t, "Asia/Tokyo", u.Timezone())
+
+	u.SetAttribution(Attribution{Source: "google", Medium: "cpc", Campaign: "spring"})
+	assert.Equal(t, Attribution{Source: "google", Medium: "cpc", Campaign: "spring"}, u.Attribution())
+
 	u2 := u.Clone()
 	assert.Equal(t, u, u2)
 	assert.NotSame(t, u, u2)
@@ -134,3 +154,52 @@ func TestUser_Verification(t *testing.T) {
 	u.SetVerification(v)
 	assert.Equal(t, v, u.Verification())
 }
+
+func TestUser_Timezone(t *testing.T) {
+	u := &User{}
+	assert.Equal(t, "", u.Timezone())
+	assert.Equal(t, time.UTC, u.Location())
+
+	assert.NoError(t, u.UpdateTimezone("Asia/Tokyo"))
+	assert.Equal(t, "Asia/Tokyo", u.Location().String())
+
+	assert.Same(t, ErrInvalidTimezone, u.UpdateTimezone("Mars/Olympus"))
+	assert.Same(t, ErrInvalidTimezone, u.UpdateTimezone("Local"))
+	assert.Equal(t, "Asia/Tokyo", u.Timezone())
+
+	assert.NoError(t, u.UpdateTimezone(""))
+	assert.Equal(t, time.UTC, u.Location())
+}
+
+func TestUser_Tags(t *testing.T) {
+	u := &User{}
+	assert.True(t, u.AddTag("a"))
+	assert.False(t, u.AddTag("a"))
+	assert.False(t, u.AddTag(""))
+	assert.True(t, u.AddTag("b"))
+	assert.Equal(t, []string{"a", "b"}, u.Tags())
+	assert.True(t, u.HasTag("b"))
+
+	assert.True(t, u.RemoveTag("a"))
+	assert.False(t, u.RemoveTag("a"))
+	assert.Equal(t, []string{"b"}, u.Tags())
+
+	u.Tags()[0] = "c"
+	assert.Equal(t, []string{"b"}, u.Tags())
+}
+
+func TestUser_DeletedAt(t *testing.T) {
+	u := &User{}
+	assert.False(t, u.IsDeleted())
+	assert.Nil(t, u.DeletedAt())
+
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	u.SetDeletedAt(&now)
+	assert.True(t, u.IsDeleted())
+	assert.Equal(t, &now, u.DeletedAt())
+	assert.NotSame(t, &now, u.DeletedAt())
+	assert.Equal(t, u, u.Clone())
+
+	u.SetDeletedAt(nil)
+	assert.False(t, u.IsDeleted())
+}
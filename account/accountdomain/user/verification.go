@@ -62,10 +62,14 @@ func generateCode() string {
 }
 
 func (v *Verification) IsExpired() bool {
+	return v.IsExpiredAt(util.Now())
+}
+
+// IsExpiredAt is the same as IsExpired, but it checks the expiration at the given time.
+func (v *Verification) IsExpiredAt(now time.Time) bool {
 	if v == nil {
 		return true
 	}
-	now := time.Now()
 	return now.After(v.expiration)
 }
 
@@ -222,3 +222,11 @@ func TestVerificationFrom(t *testing.T) {
 		expiration: e,
 	}, VerificationFrom(c, e, b))
 }
+
+func TestVerification_IsExpiredAt(t *testing.T) {
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	v := VerificationFrom("code", now, false)
+	assert.False(t, v.IsExpiredAt(now))
+	assert.True(t, v.IsExpiredAt(now.Add(time.Second)))
+	assert.True(t, (*Verification)(nil).IsExpiredAt(now))
+}
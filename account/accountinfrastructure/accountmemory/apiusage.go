@@ -0,0 +1,63 @@
+package accountmemory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/reearth/reearthx/account/accountusecase/accountrepo"
+	"github.com/reearth/reearthx/rerror"
+	"github.com/reearth/reearthx/util"
+)
+
+type APIUsage struct {
+	data map[string][]time.Time
+	lock sync.Mutex
+	err  error
+}
+
+func NewAPIUsage() *APIUsage {
+	return &APIUsage{
+		data: map[string][]time.Time{},
+	}
+}
+
+func (r *APIUsage) IncrAPIUsage(_ context.Context, keyID string, window time.Duration) (int64, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+	if keyID == "" || window <= 0 {
+		return 0, rerror.ErrInvalidParams
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	// usages are kept in chronological order, so the expired ones are always at the head
+	now := util.Now()
+	since := now.Add(-window)
+	usages := r.data[keyID]
+	i := 0
+	for i < len(usages) && !usages[i].After(since) {
+		i++
+	}
+	usages = append(usages[i:], now)
+	r.data[keyID] = usages
+	return int64(len(usages)), nil
+}
+
+func (r *APIUsage) ResetAPIUsage(_ context.Context, keyID string) error {
+	if r.err != nil {
+		return r.err
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	delete(r.data, keyID)
+	return nil
+}
+
+func SetAPIUsageError(r accountrepo.APIUsage, err error) {
+	r.(*APIUsage).err = err
+}
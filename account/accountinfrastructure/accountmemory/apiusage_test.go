@@ -0,0 +1,67 @@
+package accountmemory
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/reearth/reearthx/rerror"
+	"github.com/reearth/reearthx/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIUsage_IncrAPIUsage(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	defer util.MockNow(now)()
+
+	r := NewAPIUsage()
+	got, err := r.IncrAPIUsage(ctx, "a", time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), got)
+	got, _ = r.IncrAPIUsage(ctx, "a", time.Minute)
+	assert.Equal(t, int64(2), got)
+	got, _ = r.IncrAPIUsage(ctx, "b", time.Minute)
+	assert.Equal(t, int64(1), got)
+
+	// within the window
+	defer util.MockNow(now.Add(30 * time.Second))()
+	got, _ = r.IncrAPIUsage(ctx, "a", time.Minute)
+	assert.Equal(t, int64(3), got)
+
+	// the window slides: the first two usages expired but the third one did not
+	defer util.MockNow(now.Add(time.Minute))()
+	got, _ = r.IncrAPIUsage(ctx, "a", time.Minute)
+	assert.Equal(t, int64(2), got)
+
+	// all usages expired
+	defer util.MockNow(now.Add(2 * time.Minute))()
+	got, _ = r.IncrAPIUsage(ctx, "a", time.Minute)
+	assert.Equal(t, int64(1), got)
+
+	_, err = r.IncrAPIUsage(ctx, "", time.Minute)
+	assert.Same(t, rerror.ErrInvalidParams, err)
+	_, err = r.IncrAPIUsage(ctx, "a", 0)
+	assert.Same(t, rerror.ErrInvalidParams, err)
+
+	wantErr := errors.New("test")
+	SetAPIUsageError(r, wantErr)
+	_, err = r.IncrAPIUsage(ctx, "a", time.Minute)
+	assert.Same(t, wantErr, err)
+}
+
+func TestAPIUsage_ResetAPIUsage(t *testing.T) {
+	ctx := context.Background()
+	r := NewAPIUsage()
+	_, _ = r.IncrAPIUsage(ctx, "a", time.Minute)
+	_, _ = r.IncrAPIUsage(ctx, "a", time.Minute)
+
+	assert.NoError(t, r.ResetAPIUsage(ctx, "a"))
+	got, _ := r.IncrAPIUsage(ctx, "a", time.Minute)
+	assert.Equal(t, int64(1), got)
+
+	wantErr := errors.New("test")
+	SetAPIUsageError(r, wantErr)
+	assert.Same(t, wantErr, r.ResetAPIUsage(ctx, "a"))
+}
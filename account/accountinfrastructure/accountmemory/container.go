@@ -7,8 +7,12 @@ import (
 
 func New() *accountrepo.Container {
 	return &accountrepo.Container{
-		User:        NewUser(),
-		Workspace:   NewWorkspace(),
-		Transaction: &usecasex.NopTransaction{},
+		User:          NewUser(),
+		Workspace:     NewWorkspace(),
+		APIUsage:      NewAPIUsage(),
+		Impersonation: NewImpersonation(),
+		Session:       NewSession(),
+		MagicLink:     NewMagicLink(),
+		Transaction:   &usecasex.NopTransaction{},
 	}
 }
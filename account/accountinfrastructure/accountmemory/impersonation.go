@@ -0,0 +1,77 @@
+package accountmemory
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/reearth/reearthx/account/accountdomain"
+	"github.com/reearth/reearthx/account/accountdomain/user"
+	"github.com/reearth/reearthx/account/accountusecase"
+	"github.com/reearth/reearthx/account/accountusecase/accountrepo"
+	"github.com/reearth/reearthx/rerror"
+	"github.com/reearth/reearthx/usecasex"
+	"github.com/samber/lo"
+)
+
+type Impersonation struct {
+	data []*user.Impersonation
+	lock sync.RWMutex
+	err  error
+}
+
+func NewImpersonation() *Impersonation {
+	return &Impersonation{}
+}
+
+func (r *Impersonation) RecordImpersonation(ctx context.Context, agent, target accountdomain.UserID, reason string) error {
+	if r.err != nil {
+		return r.err
+	}
+	if strings.TrimSpace(reason) == "" {
+		return rerror.ErrInvalidParams
+	}
+	if a := accountusecase.ImpersonatorFromContext(ctx); a == nil || *a != agent {
+		return accountrepo.ErrOperationDenied
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.data = append(r.data, user.NewImpersonation(agent, target, reason))
+	return nil
+}
+
+func (r *Impersonation) FindImpersonations(_ context.Context, target accountdomain.UserID, p *usecasex.Pagination) ([]*user.Impersonation, *usecasex.PageInfo, error) {
+	if r.err != nil {
+		return nil, nil, r.err
+	}
+
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	res := lo.Filter(r.data, func(i *user.Impersonation, _ int) bool {
+		return i.Target == target
+	})
+	res, info := usecasex.PaginateSlice(res, p, func(i *user.Impersonation) string { return i.ID.String() })
+	return res, info, nil
+}
+
+func (r *Impersonation) UserAuditTrail(_ context.Context, id accountdomain.UserID, p *usecasex.Pagination) ([]*user.Impersonation, *usecasex.PageInfo, error) {
+	if r.err != nil {
+		return nil, nil, r.err
+	}
+
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	res := lo.Filter(r.data, func(i *user.Impersonation, _ int) bool {
+		return i.Agent == id || i.Target == id
+	})
+	res, info := usecasex.PaginateSlice(res, p, func(i *user.Impersonation) string { return i.ID.String() })
+	return res, info, nil
+}
+
+func SetImpersonationError(r accountrepo.Impersonation, err error) {
+	r.(*Impersonation).err = err
+}
@@ -0,0 +1,106 @@
+package accountmemory
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/reearth/reearthx/account/accountdomain"
+	"github.com/reearth/reearthx/account/accountdomain/user"
+	"github.com/reearth/reearthx/account/accountusecase"
+	"github.com/reearth/reearthx/account/accountusecase/accountrepo"
+	"github.com/reearth/reearthx/rerror"
+	"github.com/reearth/reearthx/usecasex"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImpersonation_RecordImpersonation(t *testing.T) {
+	agent, target := accountdomain.NewUserID(), accountdomain.NewUserID()
+	ctx := accountusecase.ContextWithImpersonator(context.Background(), agent)
+	r := NewImpersonation()
+
+	assert.NoError(t, r.RecordImpersonation(ctx, agent, target, "support ticket #1"))
+	assert.Len(t, r.data, 1)
+	assert.Equal(t, agent, r.data[0].Agent)
+	assert.Equal(t, target, r.data[0].Target)
+	assert.Equal(t, "support ticket #1", r.data[0].Reason)
+
+	assert.Same(t, rerror.ErrInvalidParams, r.RecordImpersonation(ctx, agent, target, " "))
+	assert.Same(t, accountrepo.ErrOperationDenied, r.RecordImpersonation(context.Background(), agent, target, "a"))
+	assert.Same(t, accountrepo.ErrOperationDenied, r.RecordImpersonation(ctx, target, target, "a"))
+	assert.Len(t, r.data, 1)
+
+	wantErr := errors.New("test")
+	SetImpersonationError(r, wantErr)
+	assert.Same(t, wantErr, r.RecordImpersonation(ctx, agent, target, "a"))
+}
+
+func TestImpersonation_FindImpersonations(t *testing.T) {
+	agent, target, other := accountdomain.NewUserID(), accountdomain.NewUserID(), accountdomain.NewUserID()
+	ctx := accountusecase.ContextWithImpersonator(context.Background(), agent)
+	r := NewImpersonation()
+	assert.NoError(t, r.RecordImpersonation(ctx, agent, target, "1"))
+	assert.NoError(t, r.RecordImpersonation(ctx, agent, other, "2"))
+	assert.NoError(t, r.RecordImpersonation(ctx, agent, target, "3"))
+
+	reasons := func(l []*user.Impersonation) []string {
+		return lo.Map(l, func(i *user.Impersonation, _ int) string { return i.Reason })
+	}
+
+	got, info, err := r.FindImpersonations(ctx, target, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1", "3"}, reasons(got))
+	assert.Equal(t, int64(2), info.TotalCount)
+
+	got, info, err = r.FindImpersonations(ctx, target, usecasex.CursorPagination{First: lo.ToPtr(int64(1))}.Wrap())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1"}, reasons(got))
+	assert.True(t, info.HasNextPage)
+
+	got, _, err = r.FindImpersonations(ctx, target, usecasex.CursorPagination{First: lo.ToPtr(int64(1)), After: info.EndCursor}.Wrap())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"3"}, reasons(got))
+
+	got, _, err = r.FindImpersonations(ctx, agent, nil)
+	assert.NoError(t, err)
+	assert.Empty(t, got)
+
+	wantErr := errors.New("test")
+	SetImpersonationError(r, wantErr)
+	_, _, err = r.FindImpersonations(ctx, target, nil)
+	assert.Same(t, wantErr, err)
+}
+
+func TestImpersonation_UserAuditTrail(t *testing.T) {
+	agent, target, other := accountdomain.NewUserID(), accountdomain.NewUserID(), accountdomain.NewUserID()
+	ctx := accountusecase.ContextWithImpersonator(context.Background(), agent)
+	r := NewImpersonation()
+	assert.NoError(t, r.RecordImpersonation(ctx, agent, target, "1"))
+	assert.NoError(t, r.RecordImpersonation(ctx, agent, other, "2"))
+	ctx2 := accountusecase.ContextWithImpersonator(context.Background(), target)
+	assert.NoError(t, r.RecordImpersonation(ctx2, target, other, "3"))
+
+	reasons := func(l []*user.Impersonation) []string {
+		return lo.Map(l, func(i *user.Impersonation, _ int) string { return i.Reason })
+	}
+
+	got, info, err := r.UserAuditTrail(ctx, target, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1", "3"}, reasons(got))
+	assert.Equal(t, int64(2), info.TotalCount)
+
+	got, info, err = r.UserAuditTrail(ctx, agent, usecasex.CursorPagination{First: lo.ToPtr(int64(1))}.Wrap())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1"}, reasons(got))
+	assert.True(t, info.HasNextPage)
+
+	got, _, err = r.UserAuditTrail(ctx, accountdomain.NewUserID(), nil)
+	assert.NoError(t, err)
+	assert.Empty(t, got)
+
+	wantErr := errors.New("test")
+	SetImpersonationError(r, wantErr)
+	_, _, err = r.UserAuditTrail(ctx, target, nil)
+	assert.Same(t, wantErr, err)
+}
@@ -0,0 +1,63 @@
+package accountmemory
+
+import (
+	"context"
+
+	"github.com/reearth/reearthx/account/accountdomain"
+	"github.com/reearth/reearthx/account/accountdomain/user"
+	"github.com/reearth/reearthx/account/accountdomain/workspace"
+	"github.com/reearth/reearthx/account/accountusecase/accountrepo"
+	"github.com/reearth/reearthx/rerror"
+)
+
+// CheckIntegrity reports dangling references between users and workspaces stored in the memory repos of the container.
+// It does not modify any data.
+func CheckIntegrity(_ context.Context, c *accountrepo.Container) (*accountrepo.IntegrityReport, error) {
+	if c == nil {
+		return nil, rerror.ErrInvalidParams
+	}
+	ur, ok := c.User.(*User)
+	if !ok {
+		return nil, rerror.ErrInvalidParams
+	}
+	wr, ok := c.Workspace.(*Workspace)
+	if !ok {
+		return nil, rerror.ErrInvalidParams
+	}
+	if ur.err != nil {
+		return nil, ur.err
+	}
+	if wr.err != nil {
+		return nil, wr.err
+	}
+
+	report := &accountrepo.IntegrityReport{}
+	ur.data.Range(func(id accountdomain.UserID, u *user.User) bool {
+		ws, ok := wr.data.Load(u.Workspace())
+		if !ok {
+			report.UsersWithoutWorkspace = append(report.UsersWithoutWorkspace, id)
+		} else if !ws.Members().HasUser(id) {
+			report.UsersNotInWorkspace = append(report.UsersNotInWorkspace, id)
+		}
+		return true
+	})
+	wr.data.Range(func(id accountdomain.WorkspaceID, ws *workspace.Workspace) bool {
+		var missing accountdomain.UserIDList
+		for _, uid := range ws.Members().UserIDs() {
+			if _, ok := ur.data.Load(uid); !ok {
+				missing = append(missing, uid)
+			}
+		}
+		if len(missing) > 0 {
+			if report.MissingMembers == nil {
+				report.MissingMembers = map[accountdomain.WorkspaceID]accountdomain.UserIDList{}
+			}
+			report.MissingMembers[id] = missing.Sort()
+		}
+		return true
+	})
+
+	report.UsersWithoutWorkspace = report.UsersWithoutWorkspace.Sort()
+	report.UsersNotInWorkspace = report.UsersNotInWorkspace.Sort()
+	return report, nil
+}
@@ -0,0 +1,67 @@
+package accountmemory
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/reearth/reearthx/account/accountdomain"
+	"github.com/reearth/reearthx/account/accountdomain/user"
+	"github.com/reearth/reearthx/account/accountdomain/workspace"
+	"github.com/reearth/reearthx/account/accountusecase/accountrepo"
+	"github.com/reearth/reearthx/rerror"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckIntegrity(t *testing.T) {
+	ctx := context.Background()
+	ws1, ws2, ws3 := accountdomain.NewWorkspaceID(), accountdomain.NewWorkspaceID(), accountdomain.NewWorkspaceID()
+	u1 := user.New().NewID().Name("a").Email("a@example.com").Workspace(ws1).MustBuild()
+	u2 := user.New().NewID().Name("b").Email("b@example.com").Workspace(ws2).MustBuild()
+	u3 := user.New().NewID().Name("c").Email("c@example.com").Workspace(ws3).MustBuild()
+	missing := accountdomain.NewUserID()
+
+	c := &accountrepo.Container{
+		User: NewUserWith(u1, u2, u3),
+		Workspace: NewWorkspaceWith(
+			workspace.New().ID(ws1).Name("a").Members(map[accountdomain.UserID]workspace.Member{
+				u1.ID(): {Role: workspace.RoleOwner},
+			}).MustBuild(),
+			workspace.New().ID(ws2).Name("b").Members(map[accountdomain.UserID]workspace.Member{
+				u1.ID(): {Role: workspace.RoleOwner},
+				missing: {Role: workspace.RoleReader},
+			}).MustBuild(),
+		),
+	}
+
+	got, err := CheckIntegrity(ctx, c)
+	assert.NoError(t, err)
+	assert.Equal(t, &accountrepo.IntegrityReport{
+		UsersWithoutWorkspace: accountdomain.UserIDList{u3.ID()},
+		UsersNotInWorkspace:   accountdomain.UserIDList{u2.ID()},
+		MissingMembers: map[accountdomain.WorkspaceID]accountdomain.UserIDList{
+			ws2: {missing},
+		},
+	}, got)
+	assert.False(t, got.IsEmpty())
+
+	// consistent data
+	got, err = CheckIntegrity(ctx, &accountrepo.Container{
+		User: NewUserWith(u1),
+		Workspace: NewWorkspaceWith(
+			workspace.New().ID(ws1).Name("a").Members(map[accountdomain.UserID]workspace.Member{
+				u1.ID(): {Role: workspace.RoleOwner},
+			}).MustBuild(),
+		),
+	})
+	assert.NoError(t, err)
+	assert.True(t, got.IsEmpty())
+
+	_, err = CheckIntegrity(ctx, nil)
+	assert.Same(t, rerror.ErrInvalidParams, err)
+
+	wantErr := errors.New("test")
+	SetUserError(c.User, wantErr)
+	_, err = CheckIntegrity(ctx, c)
+	assert.Same(t, wantErr, err)
+}
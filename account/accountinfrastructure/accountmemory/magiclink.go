@@ -0,0 +1,85 @@
+package accountmemory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/reearth/reearthx/account/accountusecase/accountrepo"
+	"github.com/reearth/reearthx/rerror"
+	"github.com/reearth/reearthx/util"
+)
+
+type MagicLink struct {
+	// data is keyed by hashed tokens
+	data map[string]magicLink
+	lock sync.Mutex
+	err  error
+}
+
+type magicLink struct {
+	email  string
+	expiry time.Time
+}
+
+func NewMagicLink() *MagicLink {
+	return &MagicLink{
+		data: map[string]magicLink{},
+	}
+}
+
+func (r *MagicLink) Create(_ context.Context, email, token string, expiry time.Time) error {
+	if r.err != nil {
+		return r.err
+	}
+	if email == "" || token == "" {
+		return rerror.ErrInvalidParams
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.data[accountrepo.HashMagicLinkToken(token)] = magicLink{email: email, expiry: expiry}
+	return nil
+}
+
+func (r *MagicLink) Consume(_ context.Context, token string) (string, error) {
+	if r.err != nil {
+		return "", r.err
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	h := accountrepo.HashMagicLinkToken(token)
+	l, ok := r.data[h]
+	if !ok {
+		return "", rerror.ErrNotFound
+	}
+	delete(r.data, h)
+	if !l.expiry.After(util.Now()) {
+		return "", rerror.ErrNotFound
+	}
+	return l.email, nil
+}
+
+func (r *MagicLink) RemoveExpired(_ context.Context) error {
+	if r.err != nil {
+		return r.err
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	now := util.Now()
+	for h, l := range r.data {
+		if !l.expiry.After(now) {
+			delete(r.data, h)
+		}
+	}
+	return nil
+}
+
+func SetMagicLinkError(r accountrepo.MagicLink, err error) {
+	r.(*MagicLink).err = err
+}
@@ -0,0 +1,54 @@
+package accountmemory
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/reearth/reearthx/account/accountusecase/accountrepo"
+	"github.com/reearth/reearthx/rerror"
+	"github.com/reearth/reearthx/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMagicLink(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	defer util.MockNow(now)()
+	r := NewMagicLink()
+
+	assert.NoError(t, r.Create(ctx, "a@example.com", "token-a", now.Add(time.Hour)))
+	assert.NoError(t, r.Create(ctx, "b@example.com", "token-b", now.Add(-time.Second)))
+	assert.NoError(t, r.Create(ctx, "c@example.com", "token-c", now.Add(-time.Second)))
+	assert.Same(t, rerror.ErrInvalidParams, r.Create(ctx, "", "token", now))
+	assert.Same(t, rerror.ErrInvalidParams, r.Create(ctx, "a@example.com", "", now))
+
+	// tokens are stored hashed
+	_, ok := r.data["token-a"]
+	assert.False(t, ok)
+	_, ok = r.data[accountrepo.HashMagicLinkToken("token-a")]
+	assert.True(t, ok)
+
+	// single use
+	email, err := r.Consume(ctx, "token-a")
+	assert.NoError(t, err)
+	assert.Equal(t, "a@example.com", email)
+	_, err = r.Consume(ctx, "token-a")
+	assert.Same(t, rerror.ErrNotFound, err)
+
+	// expired
+	_, err = r.Consume(ctx, "token-b")
+	assert.Same(t, rerror.ErrNotFound, err)
+	_, err = r.Consume(ctx, "unknown")
+	assert.Same(t, rerror.ErrNotFound, err)
+
+	assert.NoError(t, r.Create(ctx, "d@example.com", "token-d", now.Add(time.Hour)))
+	assert.NoError(t, r.RemoveExpired(ctx))
+	assert.Len(t, r.data, 1)
+
+	wantErr := errors.New("test")
+	SetMagicLinkError(r, wantErr)
+	_, err = r.Consume(ctx, "token-d")
+	assert.Same(t, wantErr, err)
+}
@@ -0,0 +1,64 @@
+package accountmemory
+
+import (
+	"context"
+
+	"github.com/reearth/reearthx/account/accountdomain"
+	"github.com/reearth/reearthx/account/accountdomain/user"
+	"github.com/reearth/reearthx/account/accountusecase/accountrepo"
+	"golang.org/x/text/language"
+)
+
+// UserSpec describes a user to be seeded by SeedUser. Zero fields are filled with defaults.
+type UserSpec struct {
+	ID        accountdomain.UserID
+	Name      string
+	Email     string
+	Password  string
+	Workspace accountdomain.WorkspaceID
+	Auths     []user.Auth
+	Lang      language.Tag
+	Theme     user.Theme
+	Verified  bool
+}
+
+// SeedUser builds a user matching the spec and saves it to the repo. This is intended to be used in tests.
+func SeedUser(r accountrepo.User, spec UserSpec) (*user.User, error) {
+	if spec.ID.IsEmpty() {
+		spec.ID = accountdomain.NewUserID()
+	}
+	if spec.Name == "" {
+		spec.Name = "user_" + spec.ID.String()
+	}
+	if spec.Email == "" {
+		spec.Email = spec.ID.String() + "@example.com"
+	}
+	if spec.Workspace.IsEmpty() {
+		spec.Workspace = accountdomain.NewWorkspaceID()
+	}
+
+	v := user.NewVerification()
+	if spec.Verified {
+		v.SetVerified(true)
+	}
+
+	u, err := user.New().
+		ID(spec.ID).
+		Name(spec.Name).
+		Email(spec.Email).
+		PasswordPlainText(spec.Password).
+		Workspace(spec.Workspace).
+		Auths(spec.Auths).
+		Lang(spec.Lang).
+		Theme(spec.Theme).
+		Verification(v).
+		Build()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.Save(context.Background(), u); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
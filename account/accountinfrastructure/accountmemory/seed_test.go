@@ -0,0 +1,57 @@
+package accountmemory
+
+import (
+	"context"
+	"testing"
+
+	"github.com/reearth/reearthx/account/accountdomain"
+	"github.com/reearth/reearthx/account/accountdomain/user"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSeedUser(t *testing.T) {
+	ctx := context.Background()
+	r := NewUser()
+
+	// defaults
+	u, err := SeedUser(r, UserSpec{})
+	assert.NoError(t, err)
+	assert.False(t, u.ID().IsEmpty())
+	assert.NotEmpty(t, u.Name())
+	assert.NotEmpty(t, u.Email())
+	assert.False(t, u.Workspace().IsEmpty())
+	assert.False(t, u.Verification().IsVerified())
+	got, err := r.FindByID(ctx, u.ID())
+	assert.NoError(t, err)
+	assert.Same(t, u, got)
+
+	// spec
+	uid := accountdomain.NewUserID()
+	wid := accountdomain.NewWorkspaceID()
+	u, err = SeedUser(r, UserSpec{
+		ID:        uid,
+		Name:      "hoge",
+		Email:     "aa@bb.cc",
+		Password:  "Passw0rd",
+		Workspace: wid,
+		Auths:     []user.Auth{{Provider: "auth0", Sub: "auth0|aaa"}},
+		Theme:     user.ThemeDark,
+		Verified:  true,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, uid, u.ID())
+	assert.Equal(t, "hoge", u.Name())
+	assert.Equal(t, "aa@bb.cc", u.Email())
+	assert.Equal(t, wid, u.Workspace())
+	assert.Equal(t, user.ThemeDark, u.Theme())
+	assert.True(t, u.Verification().IsVerified())
+	assert.True(t, u.ContainAuth(user.AuthFrom("auth0|aaa")))
+	ok, err := u.MatchPassword("Passw0rd")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	// invalid spec
+	u, err = SeedUser(r, UserSpec{Email: "invalid"})
+	assert.Equal(t, user.ErrInvalidEmail, err)
+	assert.Nil(t, u)
+}
@@ -0,0 +1,88 @@
+package accountmemory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/reearth/reearthx/account/accountdomain"
+	"github.com/reearth/reearthx/account/accountusecase/accountrepo"
+	"github.com/reearth/reearthx/rerror"
+	"github.com/reearth/reearthx/util"
+)
+
+type Session struct {
+	data map[accountdomain.UserID][]session
+	lock sync.Mutex
+	err  error
+}
+
+type session struct {
+	id        string
+	createdAt time.Time
+}
+
+func NewSession() *Session {
+	return &Session{
+		data: map[accountdomain.UserID][]session{},
+	}
+}
+
+func (r *Session) CreateSession(_ context.Context, id accountdomain.UserID, sessionID string) error {
+	if r.err != nil {
+		return r.err
+	}
+	if sessionID == "" {
+		return rerror.ErrInvalidParams
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.data[id] = append(r.data[id], session{id: sessionID, createdAt: util.Now()})
+	return nil
+}
+
+func (r *Session) ActiveSessionCount(_ context.Context, id accountdomain.UserID) (int64, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return int64(len(r.data[id])), nil
+}
+
+func (r *Session) EvictOldestSession(_ context.Context, id accountdomain.UserID) error {
+	if r.err != nil {
+		return r.err
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	sessions := r.data[id]
+	if len(sessions) == 0 {
+		return rerror.ErrNotFound
+	}
+
+	oldest := 0
+	for i, s := range sessions {
+		if s.createdAt.Before(sessions[oldest].createdAt) {
+			oldest = i
+		}
+	}
+
+	res := append(sessions[:oldest:oldest], sessions[oldest+1:]...)
+	if len(res) == 0 {
+		delete(r.data, id)
+	} else {
+		r.data[id] = res
+	}
+	return nil
+}
+
+func SetSessionError(r accountrepo.Session, err error) {
+	r.(*Session).err = err
+}
@@ -0,0 +1,53 @@
+package accountmemory
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/reearth/reearthx/account/accountdomain"
+	"github.com/reearth/reearthx/rerror"
+	"github.com/reearth/reearthx/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSession(t *testing.T) {
+	ctx := context.Background()
+	uid, uid2 := accountdomain.NewUserID(), accountdomain.NewUserID()
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	r := NewSession()
+
+	defer util.MockNow(now.Add(time.Minute))()
+	assert.NoError(t, r.CreateSession(ctx, uid, "b"))
+	defer util.MockNow(now)()
+	assert.NoError(t, r.CreateSession(ctx, uid, "a"))
+	defer util.MockNow(now.Add(2 * time.Minute))()
+	assert.NoError(t, r.CreateSession(ctx, uid, "c"))
+	assert.NoError(t, r.CreateSession(ctx, uid2, "d"))
+	assert.Same(t, rerror.ErrInvalidParams, r.CreateSession(ctx, uid, ""))
+
+	got, err := r.ActiveSessionCount(ctx, uid)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), got)
+
+	assert.NoError(t, r.EvictOldestSession(ctx, uid))
+	assert.Equal(t, []string{"b", "c"}, []string{r.data[uid][0].id, r.data[uid][1].id})
+	assert.NoError(t, r.EvictOldestSession(ctx, uid))
+	assert.NoError(t, r.EvictOldestSession(ctx, uid))
+	assert.Same(t, rerror.ErrNotFound, r.EvictOldestSession(ctx, uid))
+
+	got, err = r.ActiveSessionCount(ctx, uid)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), got)
+	got, err = r.ActiveSessionCount(ctx, uid2)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), got)
+
+	wantErr := errors.New("test")
+	SetSessionError(r, wantErr)
+	assert.Same(t, wantErr, r.CreateSession(ctx, uid, "e"))
+	_, err = r.ActiveSessionCount(ctx, uid)
+	assert.Same(t, wantErr, err)
+	assert.Same(t, wantErr, r.EvictOldestSession(ctx, uid))
+}
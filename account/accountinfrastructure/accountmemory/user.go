@@ -2,17 +2,30 @@ package accountmemory
 
 import (
 	"context"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/reearth/reearthx/account/accountdomain"
 	"github.com/reearth/reearthx/account/accountdomain/user"
 	"github.com/reearth/reearthx/account/accountusecase/accountrepo"
 	"github.com/reearth/reearthx/rerror"
+	"github.com/reearth/reearthx/usecasex"
 	"github.com/reearth/reearthx/util"
+	"github.com/samber/lo"
+	"golang.org/x/exp/slices"
+	"golang.org/x/text/language"
 )
 
 type User struct {
-	data *util.SyncMap[accountdomain.UserID, *user.User]
-	err  error
+	data       *util.SyncMap[accountdomain.UserID, *user.User]
+	createLock sync.Mutex
+	// updateLock serializes updates that read, modify and store users
+	updateLock sync.Mutex
+	now        util.TimeNow
+	err        error
+	methodErrs util.SyncMap[string, error]
 }
 
 func NewUser() *User {
@@ -30,159 +43,461 @@ func NewUserWith(users ...*user.User) *User {
 }
 
 func (r *User) FindByIDs(ctx context.Context, ids accountdomain.UserIDList) ([]*user.User, error) {
-	if r.err != nil {
-		return nil, r.err
+	if err := r.errorOf("FindByIDs"); err != nil {
+		return nil, err
+	}
+
+	return lo.Compact(r.findByIDsOrdered(lo.Uniq(ids))), nil
+}
+
+func (r *User) FindAll(ctx context.Context, p *usecasex.Pagination) ([]*user.User, *usecasex.PageInfo, error) {
+	if err := r.errorOf("FindAll"); err != nil {
+		return nil, nil, err
+	}
+
+	res := r.findAll(func(_ accountdomain.UserID, _ *user.User) bool { return true })
+	res, info := usecasex.PaginateSlice(res, p, func(u *user.User) string { return u.ID().String() })
+	return res, info, nil
+}
+
+func (r *User) Search(ctx context.Context, keyword string, p *usecasex.Pagination) ([]*user.User, *usecasex.PageInfo, error) {
+	if err := r.errorOf("Search"); err != nil {
+		return nil, nil, err
 	}
 
-	res := r.data.FindAll(func(key accountdomain.UserID, value *user.User) bool {
-		return ids.Has(key)
+	keyword = strings.ToLower(keyword)
+	res := r.findAll(func(_ accountdomain.UserID, u *user.User) bool {
+		return strings.Contains(strings.ToLower(u.Name()), keyword) || strings.Contains(strings.ToLower(u.Email()), keyword)
 	})
+	res, info := usecasex.PaginateSlice(res, p, func(u *user.User) string { return u.ID().String() })
+	return res, info, nil
+}
 
-	return res, nil
+func (r *User) FindByIDsOrdered(ctx context.Context, ids accountdomain.UserIDList) ([]*user.User, error) {
+	if err := r.errorOf("FindByIDsOrdered"); err != nil {
+		return nil, err
+	}
+
+	return r.findByIDsOrdered(ids), nil
+}
+
+func (r *User) findByIDsOrdered(ids accountdomain.UserIDList) []*user.User {
+	res := make([]*user.User, 0, len(ids))
+	for _, id := range ids {
+		u, _ := r.load(id)
+		res = append(res, u)
+	}
+	return res
 }
 
 func (r *User) FindByID(ctx context.Context, v accountdomain.UserID) (*user.User, error) {
-	if r.err != nil {
-		return nil, r.err
+	if err := r.errorOf("FindByID"); err != nil {
+		return nil, err
 	}
 
-	return rerror.ErrIfNil(r.data.Find(func(key accountdomain.UserID, value *user.User) bool {
+	return rerror.ErrIfNil(r.find(func(key accountdomain.UserID, value *user.User) bool {
 		return key == v
 	}), rerror.ErrNotFound)
 }
 
+func (r *User) FindByIDPrefix(ctx context.Context, prefix string, limit int) ([]*user.User, error) {
+	if err := r.errorOf("FindByIDPrefix"); err != nil {
+		return nil, err
+	}
+	if prefix == "" {
+		return nil, rerror.ErrInvalidParams
+	}
+
+	// IDs are lowercase
+	prefix = strings.ToLower(prefix)
+	res := r.findAll(func(key accountdomain.UserID, _ *user.User) bool {
+		return strings.HasPrefix(key.String(), prefix)
+	})
+	slices.SortFunc(res, func(a, b *user.User) bool { return a.ID().Compare(b.ID()) < 0 })
+	if limit > 0 && len(res) > limit {
+		res = res[:limit]
+	}
+	return res, nil
+}
+
+func (r *User) FindByIDIncludingDeleted(ctx context.Context, id accountdomain.UserID) (*user.User, error) {
+	if err := r.errorOf("FindByIDIncludingDeleted"); err != nil {
+		return nil, err
+	}
+
+	u, ok := r.data.Load(id)
+	if !ok {
+		return nil, rerror.ErrNotFound
+	}
+	return u, nil
+}
+
 func (r *User) FindBySub(ctx context.Context, auth0sub string) (*user.User, error) {
-	if r.err != nil {
-		return nil, r.err
+	if err := r.errorOf("FindBySub"); err != nil {
+		return nil, err
 	}
 
 	if auth0sub == "" {
 		return nil, rerror.ErrInvalidParams
 	}
 
-	return rerror.ErrIfNil(r.data.Find(func(key accountdomain.UserID, value *user.User) bool {
+	return rerror.ErrIfNil(r.find(func(key accountdomain.UserID, value *user.User) bool {
 		return value.ContainAuth(user.AuthFrom(auth0sub))
 	}), rerror.ErrNotFound)
 }
 
+func (r *User) FindBySubs(ctx context.Context, subs []string) ([]*user.User, error) {
+	if err := r.errorOf("FindBySubs"); err != nil {
+		return nil, err
+	}
+
+	subs = lo.Compact(subs)
+	if len(subs) == 0 {
+		return nil, nil
+	}
+
+	res := r.findAll(func(key accountdomain.UserID, value *user.User) bool {
+		return lo.ContainsBy(subs, value.Auths().Has)
+	})
+	slices.SortFunc(res, func(a, b *user.User) bool { return a.ID().Compare(b.ID()) < 0 })
+	return res, nil
+}
+
 func (r *User) FindByPasswordResetRequest(ctx context.Context, token string) (*user.User, error) {
-	if r.err != nil {
-		return nil, r.err
+	if err := r.errorOf("FindByPasswordResetRequest"); err != nil {
+		return nil, err
 	}
 
 	if token == "" {
 		return nil, rerror.ErrInvalidParams
 	}
 
-	return rerror.ErrIfNil(r.data.Find(func(key accountdomain.UserID, value *user.User) bool {
-		return value.PasswordReset() != nil && value.PasswordReset().Token == token
+	return rerror.ErrIfNil(r.find(func(key accountdomain.UserID, value *user.User) bool {
+		return value.PasswordReset().ValidateAt(token, r.now.Now())
 	}), rerror.ErrNotFound)
 }
 
 func (r *User) FindByEmail(ctx context.Context, email string) (*user.User, error) {
-	if r.err != nil {
-		return nil, r.err
+	if err := r.errorOf("FindByEmail"); err != nil {
+		return nil, err
 	}
 
 	if email == "" {
 		return nil, rerror.ErrInvalidParams
 	}
 
-	return rerror.ErrIfNil(r.data.Find(func(key accountdomain.UserID, value *user.User) bool {
-		return value.Email() == email
+	return rerror.ErrIfNil(r.find(func(key accountdomain.UserID, value *user.User) bool {
+		return strings.EqualFold(value.Email(), email)
 	}), rerror.ErrNotFound)
 }
 
 func (r *User) FindByName(ctx context.Context, name string) (*user.User, error) {
-	if r.err != nil {
-		return nil, r.err
+	if err := r.errorOf("FindByName"); err != nil {
+		return nil, err
 	}
 
 	if name == "" {
 		return nil, rerror.ErrInvalidParams
 	}
 
-	return rerror.ErrIfNil(r.data.Find(func(key accountdomain.UserID, value *user.User) bool {
+	return rerror.ErrIfNil(r.find(func(key accountdomain.UserID, value *user.User) bool {
 		return value.Name() == name
 	}), rerror.ErrNotFound)
 }
 
 func (r *User) FindByNameOrEmail(ctx context.Context, nameOrEmail string) (*user.User, error) {
-	if r.err != nil {
-		return nil, r.err
+	if err := r.errorOf("FindByNameOrEmail"); err != nil {
+		return nil, err
 	}
 
 	if nameOrEmail == "" {
 		return nil, rerror.ErrInvalidParams
 	}
 
-	return rerror.ErrIfNil(r.data.Find(func(key accountdomain.UserID, value *user.User) bool {
-		return value.Email() == nameOrEmail || value.Name() == nameOrEmail
+	return rerror.ErrIfNil(r.find(func(key accountdomain.UserID, value *user.User) bool {
+		return strings.EqualFold(value.Email(), nameOrEmail) || value.Name() == nameOrEmail
 	}), rerror.ErrNotFound)
 }
 
 func (r *User) FindByVerification(ctx context.Context, code string) (*user.User, error) {
-	if r.err != nil {
-		return nil, r.err
+	if err := r.errorOf("FindByVerification"); err != nil {
+		return nil, err
 	}
 
 	if code == "" {
 		return nil, rerror.ErrInvalidParams
 	}
 
-	return rerror.ErrIfNil(r.data.Find(func(key accountdomain.UserID, value *user.User) bool {
+	return rerror.ErrIfNil(r.find(func(key accountdomain.UserID, value *user.User) bool {
 		return value.Verification() != nil && value.Verification().Code() == code
 
 	}), rerror.ErrNotFound)
 }
 
-func (r *User) FindBySubOrCreate(ctx context.Context, u *user.User, sub string) (*user.User, error) {
-	if r.err != nil {
-		return nil, r.err
+func (r *User) FindBySubOrCreate(ctx context.Context, u *user.User, sub string) (*user.User, bool, error) {
+	if err := r.errorOf("FindBySubOrCreate"); err != nil {
+		return nil, false, err
 	}
 
-	u2 := r.data.Find(func(key accountdomain.UserID, value *user.User) bool {
+	r.createLock.Lock()
+	defer r.createLock.Unlock()
+
+	u2 := r.find(func(key accountdomain.UserID, value *user.User) bool {
 		return value.ContainAuth(user.AuthFrom(sub))
 	})
 	if u2 == nil {
 		r.data.Store(u.ID(), u)
-		return u, nil
+		return u, true, nil
+	}
+	return u2, false, nil
+}
+
+func (r *User) FindByMetadata(ctx context.Context, path string, value any, p *usecasex.Pagination) ([]*user.User, *usecasex.PageInfo, error) {
+	if err := r.errorOf("FindByMetadata"); err != nil {
+		return nil, nil, err
+	}
+	if path == "" {
+		return nil, nil, rerror.ErrInvalidParams
+	}
+
+	res := r.findAll(func(key accountdomain.UserID, value2 *user.User) bool {
+		v, ok := value2.Metadata().Get(path)
+		return ok && reflect.DeepEqual(v, value)
+	})
+	res, info := usecasex.PaginateSlice(res, p, func(u *user.User) string { return u.ID().String() })
+	return res, info, nil
+}
+
+func (r *User) FindBySource(ctx context.Context, source string, p *usecasex.Pagination) ([]*user.User, *usecasex.PageInfo, error) {
+	if err := r.errorOf("FindBySource"); err != nil {
+		return nil, nil, err
+	}
+	if source == "" {
+		return nil, nil, rerror.ErrInvalidParams
 	}
-	return u2, nil
+
+	res := r.findAll(func(_ accountdomain.UserID, u *user.User) bool {
+		return u.Attribution().Source == source
+	})
+	res, info := usecasex.PaginateSlice(res, p, func(u *user.User) string { return u.ID().String() })
+	return res, info, nil
+}
+
+func (r *User) CountBySource(ctx context.Context) (map[string]int64, error) {
+	if err := r.errorOf("CountBySource"); err != nil {
+		return nil, err
+	}
+
+	res := map[string]int64{}
+	for _, u := range r.findAll(func(_ accountdomain.UserID, _ *user.User) bool { return true }) {
+		res[u.Attribution().Source]++
+	}
+	return res, nil
 }
 
 func (r *User) Create(ctx context.Context, u *user.User) error {
-	if r.err != nil {
-		return r.err
+	if err := r.errorOf("Create"); err != nil {
+		return err
 	}
 
-	if _, ok := r.data.Load(u.ID()); !ok {
-		r.data.Store(u.ID(), u)
-	} else {
+	if _, computed := r.data.GetOrCompute(u.ID(), func() *user.User { return u }); !computed {
 		return accountrepo.ErrDuplicatedUser
 	}
-
 	return nil
 }
 
+func (r *User) CreateAll(ctx context.Context, users []*user.User, allOrNothing bool) ([]*user.User, accountdomain.UserIDList, error) {
+	if err := r.errorOf("CreateAll"); err != nil {
+		return nil, nil, err
+	}
+
+	if allOrNothing {
+		seen := map[accountdomain.UserID]struct{}{}
+		var duplicates accountdomain.UserIDList
+		for _, u := range users {
+			_, dup := seen[u.ID()]
+			if _, ok := r.data.Load(u.ID()); ok || dup {
+				duplicates = append(duplicates, u.ID())
+			}
+			seen[u.ID()] = struct{}{}
+		}
+		if len(duplicates) > 0 {
+			return nil, duplicates, accountrepo.ErrDuplicatedUser
+		}
+	}
+
+	created := make([]*user.User, 0, len(users))
+	var duplicates accountdomain.UserIDList
+	for _, u := range users {
+		if _, loaded := r.data.LoadOrStore(u.ID(), u); loaded {
+			duplicates = append(duplicates, u.ID())
+			continue
+		}
+		created = append(created, u)
+	}
+	return created, duplicates, nil
+}
+
 func (r *User) Save(ctx context.Context, u *user.User) error {
-	if r.err != nil {
-		return r.err
+	if err := r.errorOf("Save"); err != nil {
+		return err
 	}
 
 	r.data.Store(u.ID(), u)
 	return nil
 }
 
+func (r *User) UpdateNotificationPref(ctx context.Context, id accountdomain.UserID, category user.NotificationCategory, channel user.NotificationChannel, enabled bool) error {
+	if err := r.errorOf("UpdateNotificationPref"); err != nil {
+		return err
+	}
+	if !category.Valid() || !channel.Valid() {
+		return rerror.ErrInvalidParams
+	}
+
+	r.updateLock.Lock()
+	defer r.updateLock.Unlock()
+
+	u, ok := r.load(id)
+	if !ok {
+		return rerror.ErrNotFound
+	}
+	u2 := u.Clone()
+	u2.UpdateNotificationPref(category, channel, enabled)
+	r.data.Store(id, u2)
+	return nil
+}
+
+func (r *User) UpdateLocale(ctx context.Context, id accountdomain.UserID, lang language.Tag, tz string) error {
+	if err := r.errorOf("UpdateLocale"); err != nil {
+		return err
+	}
+	if !user.ValidTimezone(tz) {
+		return rerror.ErrInvalidParams
+	}
+
+	r.updateLock.Lock()
+	defer r.updateLock.Unlock()
+
+	u, ok := r.load(id)
+	if !ok {
+		return rerror.ErrNotFound
+	}
+	u2 := u.Clone()
+	u2.UpdateLang(lang)
+	_ = u2.UpdateTimezone(tz)
+	r.data.Store(id, u2)
+	return nil
+}
+
+func (r *User) TagByCriteria(ctx context.Context, criteria accountrepo.UserCriteria, tag string) (int64, error) {
+	if err := r.errorOf("TagByCriteria"); err != nil {
+		return 0, err
+	}
+	if tag == "" || criteria.IsEmpty() {
+		return 0, rerror.ErrInvalidParams
+	}
+
+	r.updateLock.Lock()
+	defer r.updateLock.Unlock()
+
+	matched := r.findAll(func(_ accountdomain.UserID, u *user.User) bool {
+		return criteria.Match(u) && !u.HasTag(tag)
+	})
+	for _, u := range matched {
+		u2 := u.Clone()
+		u2.AddTag(tag)
+		r.data.Store(u2.ID(), u2)
+	}
+	return int64(len(matched)), nil
+}
+
+func (r *User) SoftRemove(ctx context.Context, id accountdomain.UserID) error {
+	if err := r.errorOf("SoftRemove"); err != nil {
+		return err
+	}
+
+	r.updateLock.Lock()
+	defer r.updateLock.Unlock()
+
+	u, ok := r.load(id)
+	if !ok {
+		return rerror.ErrNotFound
+	}
+	u2 := u.Clone()
+	u2.SetDeletedAt(lo.ToPtr(r.now.Now()))
+	r.data.Store(id, u2)
+	return nil
+}
+
 func (r *User) Remove(ctx context.Context, user accountdomain.UserID) error {
-	if r.err != nil {
-		return r.err
+	if err := r.errorOf("Remove"); err != nil {
+		return err
 	}
 
 	r.data.Delete(user)
 	return nil
 }
 
+// Snapshot returns copies of all users sorted by ID, so that states of the repo can be compared in tests.
+func (r *User) Snapshot(ctx context.Context) ([]*user.User, error) {
+	if err := r.errorOf("Snapshot"); err != nil {
+		return nil, err
+	}
+
+	res := make([]*user.User, 0, r.data.Len())
+	r.data.Range(func(_ accountdomain.UserID, u *user.User) bool {
+		res = append(res, u.Clone())
+		return true
+	})
+	slices.SortFunc(res, func(a, b *user.User) bool { return a.ID().Compare(b.ID()) < 0 })
+	return res, nil
+}
+
+// load, find and findAll ignore soft-deleted users.
+func (r *User) load(id accountdomain.UserID) (*user.User, bool) {
+	u, ok := r.data.Load(id)
+	if !ok || u.IsDeleted() {
+		return nil, false
+	}
+	return u, true
+}
+
+func (r *User) find(f func(accountdomain.UserID, *user.User) bool) *user.User {
+	return r.data.Find(func(k accountdomain.UserID, u *user.User) bool {
+		return !u.IsDeleted() && f(k, u)
+	})
+}
+
+func (r *User) findAll(f func(accountdomain.UserID, *user.User) bool) []*user.User {
+	return r.data.FindAll(func(k accountdomain.UserID, u *user.User) bool {
+		return !u.IsDeleted() && f(k, u)
+	})
+}
+
+func (r *User) errorOf(method string) error {
+	if r.err != nil {
+		return r.err
+	}
+	err, _ := r.methodErrs.Load(method)
+	return err
+}
+
 func SetUserError(r accountrepo.User, err error) {
 	r.(*User).err = err
 }
+
+// SetUserMethodError makes only the method of the repo, e.g. "FindByEmail", return the error. A nil error clears it.
+// SetUserError takes precedence over it.
+func SetUserMethodError(r accountrepo.User, method string, err error) {
+	if err == nil {
+		r.(*User).methodErrs.Delete(method)
+		return
+	}
+	r.(*User).methodErrs.Store(method, err)
+}
+
+// MockUserNow fixes the time used to check expirations of password reset requests and to soft-delete users, and returns a function to restore it.
+func MockUserNow(r accountrepo.User, now time.Time) func() {
+	return r.(*User).now.Mock(now)
+}
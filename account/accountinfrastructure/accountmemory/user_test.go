@@ -3,6 +3,7 @@ package accountmemory
 import (
 	"context"
 	"errors"
+	"sync"
 	"testing"
 	"time"
 
@@ -10,8 +11,11 @@ import (
 	"github.com/reearth/reearthx/account/accountdomain/user"
 	"github.com/reearth/reearthx/account/accountusecase/accountrepo"
 	"github.com/reearth/reearthx/rerror"
+	"github.com/reearth/reearthx/usecasex"
 	"github.com/reearth/reearthx/util"
+	"github.com/samber/lo"
 	"github.com/stretchr/testify/assert"
+	"golang.org/x/text/language"
 )
 
 func TestNewUser(t *testing.T) {
@@ -100,6 +104,12 @@ func TestUser_FindByEmail(t *testing.T) {
 	assert.Same(t, rerror.ErrNotFound, err)
 	assert.Nil(t, out)
 
+	u2 := user.New().NewID().Name("foo").Email("user@example.com").MustBuild()
+	r.data.Store(u2.ID(), u2)
+	out, err = r.FindByEmail(ctx, "User@Example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, u2, out)
+
 	wantErr := errors.New("test")
 	SetUserError(r, wantErr)
 	_, err = r.FindByEmail(ctx, "")
@@ -124,12 +134,163 @@ func TestUser_FindByIDs(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, 2, len(out))
 
+	// the order of the ids is preserved and missing ids are skipped
+	u3 := user.New().NewID().Name("bar").Email("bar@bb.cc").MustBuild()
+	r.data.Store(u3.ID(), u3)
+	out, err = r.FindByIDs(ctx, accountdomain.UserIDList{u3.ID(), user.NewID(), u1.ID(), u2.ID()})
+	assert.NoError(t, err)
+	assert.Equal(t, []*user.User{u3, u1, u2}, out)
+
+	out, err = r.FindByIDs(ctx, accountdomain.UserIDList{u2.ID(), u1.ID(), u2.ID()})
+	assert.NoError(t, err)
+	assert.Equal(t, []*user.User{u2, u1}, out)
+
+	out, err = r.FindByIDs(ctx, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []*user.User{}, out)
+
+	out, err = r.FindByIDs(ctx, accountdomain.UserIDList{user.NewID(), user.NewID()})
+	assert.NoError(t, err)
+	assert.Equal(t, []*user.User{}, out)
+
 	wantErr := errors.New("test")
 	SetUserError(r, wantErr)
 	_, err = r.FindByIDs(ctx, ids)
 	assert.Same(t, wantErr, err)
 }
 
+func TestUser_FindAll(t *testing.T) {
+	ctx := context.Background()
+	u1 := user.New().NewID().Name("a").Email("a@bb.cc").MustBuild()
+	u2 := user.New().NewID().Name("b").Email("b@bb.cc").MustBuild()
+	u3 := user.New().NewID().Name("c").Email("c@bb.cc").MustBuild()
+	r := NewUserWith(u3, u1, u2)
+
+	got, info, err := r.FindAll(ctx, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []*user.User{u1, u2, u3}, got)
+	assert.Equal(t, int64(3), info.TotalCount)
+	assert.False(t, info.HasNextPage)
+
+	// page through users by cursor
+	got, info, err = r.FindAll(ctx, usecasex.CursorPagination{First: lo.ToPtr(int64(2))}.Wrap())
+	assert.NoError(t, err)
+	assert.Equal(t, []*user.User{u1, u2}, got)
+	assert.True(t, info.HasNextPage)
+
+	got, info, err = r.FindAll(ctx, usecasex.CursorPagination{First: lo.ToPtr(int64(2)), After: info.EndCursor}.Wrap())
+	assert.NoError(t, err)
+	assert.Equal(t, []*user.User{u3}, got)
+	assert.False(t, info.HasNextPage)
+	assert.True(t, info.HasPreviousPage)
+
+	// page through users by offset
+	got, info, err = r.FindAll(ctx, usecasex.OffsetPagination{Offset: 0, Limit: 2}.Wrap())
+	assert.NoError(t, err)
+	assert.Equal(t, []*user.User{u1, u2}, got)
+	assert.True(t, info.HasNextPage)
+
+	got, info, err = r.FindAll(ctx, usecasex.OffsetPagination{Offset: 2, Limit: 2}.Wrap())
+	assert.NoError(t, err)
+	assert.Equal(t, []*user.User{u3}, got)
+	assert.False(t, info.HasNextPage)
+
+	wantErr := errors.New("test")
+	SetUserError(r, wantErr)
+	_, _, err = r.FindAll(ctx, nil)
+	assert.Same(t, wantErr, err)
+}
+
+func TestUser_Search(t *testing.T) {
+	ctx := context.Background()
+	u1 := user.New().NewID().Name("Alice").Email("alice@example.com").MustBuild()
+	u2 := user.New().NewID().Name("bob").Email("bob@Example.org").MustBuild()
+	u3 := user.New().NewID().Name("carol").Email("carol@test.com").MustBuild()
+	r := NewUserWith(u3, u1, u2)
+
+	got, _, err := r.Search(ctx, "EXAMPLE", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []*user.User{u1, u2}, got)
+
+	got, _, err = r.Search(ctx, "ali", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []*user.User{u1}, got)
+
+	got, info, err := r.Search(ctx, "", usecasex.CursorPagination{First: lo.ToPtr(int64(2))}.Wrap())
+	assert.NoError(t, err)
+	assert.Equal(t, []*user.User{u1, u2}, got)
+	assert.True(t, info.HasNextPage)
+
+	got, _, err = r.Search(ctx, "zzz", nil)
+	assert.NoError(t, err)
+	assert.Empty(t, got)
+}
+
+func TestUser_FindByIDsOrdered(t *testing.T) {
+	ctx := context.Background()
+	u1 := user.New().NewID().Name("hoge").Email("abc@bb.cc").MustBuild()
+	u2 := user.New().NewID().Name("foo").Email("cba@bb.cc").MustBuild()
+	r := NewUserWith(u1, u2)
+
+	out, err := r.FindByIDsOrdered(ctx, accountdomain.UserIDList{u2.ID(), user.NewID(), u1.ID()})
+	assert.NoError(t, err)
+	assert.Equal(t, []*user.User{u2, nil, u1}, out)
+
+	wantErr := errors.New("test")
+	SetUserError(r, wantErr)
+	_, err = r.FindByIDsOrdered(ctx, accountdomain.UserIDList{u1.ID()})
+	assert.Same(t, wantErr, err)
+}
+
+func TestUser_FindByIDPrefix(t *testing.T) {
+	ctx := context.Background()
+	u1 := user.New().ID(accountdomain.MustUserID("01gq6gzvh3qhdaqzkh6h4jcpkz")).Name("a").Email("a@bb.cc").MustBuild()
+	u2 := user.New().ID(accountdomain.MustUserID("01gq6gzvh3qhdaqzkh6h4jcpm0")).Name("b").Email("b@bb.cc").MustBuild()
+	u3 := user.New().ID(accountdomain.MustUserID("01gq7000000000000000000000")).Name("c").Email("c@bb.cc").MustBuild()
+	r := NewUserWith(u3, u2, u1)
+
+	got, err := r.FindByIDPrefix(ctx, "01GQ6", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []*user.User{u1, u2}, got)
+
+	got, err = r.FindByIDPrefix(ctx, "01gq", 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []*user.User{u1, u2}, got)
+
+	got, err = r.FindByIDPrefix(ctx, "01gq8", 0)
+	assert.NoError(t, err)
+	assert.Empty(t, got)
+
+	_, err = r.FindByIDPrefix(ctx, "", 0)
+	assert.Same(t, rerror.ErrInvalidParams, err)
+
+	wantErr := errors.New("test")
+	SetUserError(r, wantErr)
+	_, err = r.FindByIDPrefix(ctx, "01gq", 0)
+	assert.Same(t, wantErr, err)
+}
+
+func TestUser_FindBySubs(t *testing.T) {
+	ctx := context.Background()
+	u1 := user.New().NewID().Name("a").Email("a@bb.cc").Auths([]user.Auth{{Provider: "auth0", Sub: "auth0|a"}}).MustBuild()
+	u2 := user.New().NewID().Name("b").Email("b@bb.cc").Auths([]user.Auth{{Provider: "auth0", Sub: "auth0|b"}}).MustBuild()
+	u3 := user.New().NewID().Name("c").Email("c@bb.cc").Auths([]user.Auth{{Provider: "auth0", Sub: "auth0|c"}}).MustBuild()
+	r := NewUserWith(u3, u1, u2)
+
+	out, err := r.FindBySubs(ctx, []string{"auth0|b", "", "auth0|a", "auth0|x"})
+	assert.NoError(t, err)
+	assert.Equal(t, []*user.User{u1, u2}, out)
+
+	out, err = r.FindBySubs(ctx, []string{""})
+	assert.NoError(t, err)
+	assert.Empty(t, out)
+
+	wantErr := errors.New("test")
+	SetUserError(r, wantErr)
+	_, err = r.FindBySubs(ctx, []string{"auth0|a"})
+	assert.Same(t, wantErr, err)
+}
+
 func TestUser_FindByName(t *testing.T) {
 	ctx := context.Background()
 	pr := user.PasswordReset{
@@ -212,6 +373,20 @@ func TestUser_FindByNameOrEmail(t *testing.T) {
 	assert.Nil(t, out3)
 	assert.Same(t, rerror.ErrNotFound, err)
 
+	out4, err := r.FindByNameOrEmail(ctx, "AA@bb.cc")
+	assert.NoError(t, err)
+	assert.Equal(t, u, out4)
+
+	// emails are case-insensitive but names are not
+	u2 := user.New().NewID().Name("Foo").Email("Foo@Example.com").MustBuild()
+	r.data.Store(u2.ID(), u2)
+	out5, err := r.FindByNameOrEmail(ctx, "foo@example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, u2, out5)
+	out6, err := r.FindByNameOrEmail(ctx, "foo")
+	assert.Nil(t, out6)
+	assert.Same(t, rerror.ErrNotFound, err)
+
 	wantErr := errors.New("test")
 	SetUserError(r, wantErr)
 	_, err = r.FindByID(ctx, u.ID())
@@ -277,6 +452,23 @@ func TestUser_FindByPasswordResetRequest(t *testing.T) {
 	}
 }
 
+func TestUser_FindByPasswordResetRequest_Expired(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	u := user.New().NewID().Name("hoge").Email("aa@bb.cc").PasswordReset(user.PasswordResetFrom("123abc", now)).MustBuild()
+	r := NewUserWith(u)
+
+	defer MockUserNow(r, now.Add(time.Hour))()
+	got, err := r.FindByPasswordResetRequest(ctx, "123abc")
+	assert.NoError(t, err)
+	assert.Equal(t, u, got)
+
+	defer MockUserNow(r, now.Add(25*time.Hour))()
+	got, err = r.FindByPasswordResetRequest(ctx, "123abc")
+	assert.Same(t, rerror.ErrNotFound, err)
+	assert.Nil(t, got)
+}
+
 func TestUser_FindByVerification(t *testing.T) {
 	ctx := context.Background()
 	vr := user.VerificationFrom("123abc", time.Now(), false)
@@ -365,14 +557,43 @@ func TestUser_FindBySubOrCreate(t *testing.T) {
 
 	r := &User{data: &util.SyncMap[accountdomain.UserID, *user.User]{}}
 
-	_, err := r.FindBySubOrCreate(ctx, u, "auth0|aaa")
+	_, created, err := r.FindBySubOrCreate(ctx, u, "auth0|aaa")
 	assert.NoError(t, err)
+	assert.True(t, created)
 	assert.Equal(t, 1, r.data.Len())
 
 	// if same sub, it returns existing data in stead of inserting new data
-	_, err = r.FindBySubOrCreate(ctx, u, "auth0|aaa")
+	_, created, err = r.FindBySubOrCreate(ctx, u, "auth0|aaa")
 	assert.NoError(t, err)
+	assert.False(t, created)
+	assert.Equal(t, 1, r.data.Len())
+}
+
+func TestUser_FindBySubOrCreate_Concurrent(t *testing.T) {
+	ctx := context.Background()
+	r := NewUser()
+
+	var wg sync.WaitGroup
+	res := make([]*user.User, 50)
+	created := make([]bool, 50)
+	for i := range res {
+		i := i
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			u := user.New().NewID().Name("hoge").Email("aa@bb.cc").Auths([]user.Auth{{Sub: "auth0|aaa", Provider: "auth0"}}).MustBuild()
+			u2, c, err := r.FindBySubOrCreate(ctx, u, "auth0|aaa")
+			assert.NoError(t, err)
+			res[i], created[i] = u2, c
+		}()
+	}
+	wg.Wait()
+
 	assert.Equal(t, 1, r.data.Len())
+	assert.Equal(t, 1, lo.Count(created, true))
+	for _, u := range res {
+		assert.Same(t, res[0], u)
+	}
 }
 
 func TestUser_Create(t *testing.T) {
@@ -390,6 +611,66 @@ func TestUser_Create(t *testing.T) {
 	assert.Equal(t, accountrepo.ErrDuplicatedUser, err)
 }
 
+func TestUser_Create_Concurrent(t *testing.T) {
+	ctx := context.Background()
+	u := user.New().NewID().Name("hoge").Email("aa@bb.cc").MustBuild()
+	r := NewUser()
+
+	var wg sync.WaitGroup
+	errs := make([]error, 50)
+	for i := range errs {
+		i := i
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = r.Create(ctx, u)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 1, lo.CountBy(errs, func(err error) bool { return err == nil }))
+	assert.Equal(t, len(errs)-1, lo.CountBy(errs, func(err error) bool { return err == accountrepo.ErrDuplicatedUser }))
+}
+
+func TestUser_CreateAll(t *testing.T) {
+	ctx := context.Background()
+	u1 := user.New().NewID().Name("a").Email("a@bb.cc").MustBuild()
+	u2 := user.New().NewID().Name("b").Email("b@bb.cc").MustBuild()
+	u3 := user.New().NewID().Name("c").Email("c@bb.cc").MustBuild()
+	u4 := user.New().NewID().Name("d").Email("d@bb.cc").MustBuild()
+
+	r := NewUserWith(u1)
+	created, duplicates, err := r.CreateAll(ctx, []*user.User{u2, u1, u3}, false)
+	assert.NoError(t, err)
+	assert.Equal(t, []*user.User{u2, u3}, created)
+	assert.Equal(t, accountdomain.UserIDList{u1.ID()}, duplicates)
+	got, _ := r.Snapshot(ctx)
+	assert.Len(t, got, 3)
+
+	// all or nothing
+	created, duplicates, err = r.CreateAll(ctx, []*user.User{u4, u2}, true)
+	assert.Same(t, accountrepo.ErrDuplicatedUser, err)
+	assert.Nil(t, created)
+	assert.Equal(t, accountdomain.UserIDList{u2.ID()}, duplicates)
+	_, err = r.FindByID(ctx, u4.ID())
+	assert.Same(t, rerror.ErrNotFound, err)
+
+	created, duplicates, err = r.CreateAll(ctx, []*user.User{u4, u4}, true)
+	assert.Same(t, accountrepo.ErrDuplicatedUser, err)
+	assert.Nil(t, created)
+	assert.Equal(t, accountdomain.UserIDList{u4.ID()}, duplicates)
+
+	created, duplicates, err = r.CreateAll(ctx, []*user.User{u4}, true)
+	assert.NoError(t, err)
+	assert.Equal(t, []*user.User{u4}, created)
+	assert.Empty(t, duplicates)
+
+	wantErr := errors.New("test")
+	SetUserError(r, wantErr)
+	_, _, err = r.CreateAll(ctx, []*user.User{u4}, false)
+	assert.Same(t, wantErr, err)
+}
+
 func TestUser_Save(t *testing.T) {
 	ctx := context.Background()
 	u := user.New().NewID().Name("hoge").Email("aa@bb.cc").MustBuild()
@@ -423,3 +704,312 @@ func TestUser_Remove(t *testing.T) {
 	SetUserError(r, wantErr)
 	assert.Same(t, wantErr, r.Remove(ctx, u.ID()))
 }
+
+func TestUser_UpdateNotificationPref(t *testing.T) {
+	ctx := context.Background()
+	u := user.New().NewID().Name("hoge").Email("aa@bb.cc").NotificationPreferences(user.NotificationPreferences{
+		"billing": {user.NotificationChannelSMS: true},
+	}).MustBuild()
+	r := NewUserWith(u)
+
+	assert.NoError(t, r.UpdateNotificationPref(ctx, u.ID(), "news", user.NotificationChannelEmail, true))
+	assert.NoError(t, r.UpdateNotificationPref(ctx, u.ID(), "news", user.NotificationChannelPush, false))
+	got, err := r.FindByID(ctx, u.ID())
+	assert.NoError(t, err)
+	assert.Equal(t, user.NotificationPreferences{
+		"billing": {user.NotificationChannelSMS: true},
+		"news":    {user.NotificationChannelEmail: true, user.NotificationChannelPush: false},
+	}, got.NotificationPreferences())
+
+	assert.Same(t, rerror.ErrNotFound, r.UpdateNotificationPref(ctx, accountdomain.NewUserID(), "news", user.NotificationChannelEmail, true))
+	assert.Same(t, rerror.ErrInvalidParams, r.UpdateNotificationPref(ctx, u.ID(), "", user.NotificationChannelEmail, true))
+	assert.Same(t, rerror.ErrInvalidParams, r.UpdateNotificationPref(ctx, u.ID(), "news", "fax", true))
+
+	wantErr := errors.New("test")
+	SetUserError(r, wantErr)
+	assert.Same(t, wantErr, r.UpdateNotificationPref(ctx, u.ID(), "news", user.NotificationChannelEmail, true))
+}
+
+func TestUser_UpdateNotificationPref_Concurrent(t *testing.T) {
+	ctx := context.Background()
+	u := user.New().NewID().Name("hoge").Email("aa@bb.cc").MustBuild()
+	r := NewUserWith(u)
+
+	categories := []user.NotificationCategory{"a", "b", "c", "d", "e", "f", "g", "h"}
+	var wg sync.WaitGroup
+	for _, c := range categories {
+		c := c
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, r.UpdateNotificationPref(ctx, u.ID(), c, user.NotificationChannelEmail, true))
+		}()
+	}
+	wg.Wait()
+
+	got, err := r.FindByID(ctx, u.ID())
+	assert.NoError(t, err)
+	assert.Len(t, got.NotificationPreferences(), len(categories))
+}
+
+func TestUser_UpdateLocale(t *testing.T) {
+	ctx := context.Background()
+	u := user.New().NewID().Name("hoge").Email("aa@bb.cc").MustBuild()
+	r := NewUserWith(u)
+
+	assert.NoError(t, r.UpdateLocale(ctx, u.ID(), language.Japanese, "Asia/Tokyo"))
+	got, err := r.FindByID(ctx, u.ID())
+	assert.NoError(t, err)
+	assert.Equal(t, language.Japanese, got.Lang())
+	assert.Equal(t, "Asia/Tokyo", got.Timezone())
+	assert.Equal(t, "", u.Timezone())
+
+	assert.Same(t, rerror.ErrNotFound, r.UpdateLocale(ctx, accountdomain.NewUserID(), language.Japanese, "Asia/Tokyo"))
+	assert.Same(t, rerror.ErrInvalidParams, r.UpdateLocale(ctx, u.ID(), language.Japanese, "Asia/Nowhere"))
+
+	wantErr := errors.New("test")
+	SetUserError(r, wantErr)
+	assert.Same(t, wantErr, r.UpdateLocale(ctx, u.ID(), language.Japanese, "Asia/Tokyo"))
+}
+
+func TestUser_TagByCriteria(t *testing.T) {
+	ctx := context.Background()
+	wid := accountdomain.NewWorkspaceID()
+	u1 := user.New().NewID().Name("a").Email("a@Example.com").Workspace(wid).MustBuild()
+	u2 := user.New().NewID().Name("b").Email("b@example.com").Metadata(user.Metadata{"plan": "pro"}).MustBuild()
+	u3 := user.New().NewID().Name("c").Email("c@other.com").Metadata(user.Metadata{"plan": "pro"}).MustBuild()
+	r := NewUserWith(u1, u2, u3)
+
+	n, err := r.TagByCriteria(ctx, accountrepo.UserCriteria{EmailDomain: "example.com"}, "news")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+
+	// already tagged users are not counted
+	n, err = r.TagByCriteria(ctx, accountrepo.UserCriteria{Metadata: map[string]any{"plan": "pro"}}, "news")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+
+	n, err = r.TagByCriteria(ctx, accountrepo.UserCriteria{Workspace: &wid, Tag: "news"}, "vip")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+
+	got, _ := r.FindByID(ctx, u1.ID())
+	assert.Equal(t, []string{"news", "vip"}, got.Tags())
+	got, _ = r.FindByID(ctx, u3.ID())
+	assert.Equal(t, []string{"news"}, got.Tags())
+	assert.Empty(t, u1.Tags())
+
+	_, err = r.TagByCriteria(ctx, accountrepo.UserCriteria{}, "news")
+	assert.Same(t, rerror.ErrInvalidParams, err)
+	_, err = r.TagByCriteria(ctx, accountrepo.UserCriteria{Tag: "news"}, "")
+	assert.Same(t, rerror.ErrInvalidParams, err)
+
+	wantErr := errors.New("test")
+	SetUserError(r, wantErr)
+	_, err = r.TagByCriteria(ctx, accountrepo.UserCriteria{Tag: "news"}, "vip")
+	assert.Same(t, wantErr, err)
+}
+
+func TestUser_TagByCriteria_Concurrent(t *testing.T) {
+	ctx := context.Background()
+	u := user.New().NewID().Name("a").Email("a@example.com").MustBuild()
+	r := NewUserWith(u)
+
+	tags := []string{"a", "b", "c", "d", "e", "f", "g", "h"}
+	var wg sync.WaitGroup
+	for _, tag := range tags {
+		tag := tag
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			_, err := r.TagByCriteria(ctx, accountrepo.UserCriteria{EmailDomain: "example.com"}, tag)
+			assert.NoError(t, err)
+		}()
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, r.UpdateNotificationPref(ctx, u.ID(), user.NotificationCategory(tag), user.NotificationChannelEmail, true))
+		}()
+	}
+	wg.Wait()
+
+	got, err := r.FindByID(ctx, u.ID())
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, tags, got.Tags())
+	assert.Len(t, got.NotificationPreferences(), len(tags))
+}
+
+func TestUser_FindByMetadata(t *testing.T) {
+	ctx := context.Background()
+	u1 := user.New().NewID().Name("a").Email("a@bb.cc").Metadata(user.Metadata{
+		"source": "campaign-x",
+	}).MustBuild()
+	u2 := user.New().NewID().Name("b").Email("b@bb.cc").Metadata(user.Metadata{
+		"source":  "campaign-x",
+		"profile": map[string]any{"country": "jp"},
+	}).MustBuild()
+	u3 := user.New().NewID().Name("c").Email("c@bb.cc").Metadata(user.Metadata{
+		"source":  "campaign-y",
+		"profile": map[string]any{"country": "jp"},
+	}).MustBuild()
+	r := NewUserWith(u1, u2, u3)
+
+	got, info, err := r.FindByMetadata(ctx, "source", "campaign-x", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []*user.User{u1, u2}, got)
+	assert.Equal(t, int64(2), info.TotalCount)
+
+	got, info, err = r.FindByMetadata(ctx, "profile.country", "jp", usecasex.CursorPagination{First: lo.ToPtr(int64(1))}.Wrap())
+	assert.NoError(t, err)
+	assert.Equal(t, []*user.User{u2}, got)
+	assert.Equal(t, int64(2), info.TotalCount)
+	assert.True(t, info.HasNextPage)
+
+	got, _, err = r.FindByMetadata(ctx, "profile.country", "us", nil)
+	assert.NoError(t, err)
+	assert.Empty(t, got)
+
+	_, _, err = r.FindByMetadata(ctx, "", "jp", nil)
+	assert.Same(t, rerror.ErrInvalidParams, err)
+
+	wantErr := errors.New("test")
+	SetUserError(r, wantErr)
+	_, _, err = r.FindByMetadata(ctx, "source", "campaign-x", nil)
+	assert.Same(t, wantErr, err)
+}
+
+func TestUser_Snapshot(t *testing.T) {
+	ctx := context.Background()
+	u1 := user.New().NewID().Name("a").Email("a@example.com").MustBuild()
+	u2 := user.New().NewID().Name("b").Email("b@example.com").MustBuild()
+	u3 := user.New().NewID().Name("c").Email("c@example.com").MustBuild()
+	r := NewUserWith(u3, u1, u2)
+
+	got, err := r.Snapshot(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []*user.User{u1, u2, u3}, got)
+	assert.NotSame(t, u1, got[0])
+
+	// later changes do not affect the snapshot
+	u1.UpdateName("x")
+	assert.Equal(t, "a", got[0].Name())
+
+	got, err = NewUser().Snapshot(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, got)
+
+	wantErr := errors.New("test")
+	SetUserError(r, wantErr)
+	_, err = r.Snapshot(ctx)
+	assert.Same(t, wantErr, err)
+}
+
+func TestSetUserMethodError(t *testing.T) {
+	ctx := context.Background()
+	u := user.New().NewID().Name("hoge").Email("aa@bb.cc").MustBuild()
+	r := NewUser()
+
+	wantErr := errors.New("test")
+	SetUserMethodError(r, "FindByEmail", wantErr)
+	assert.NoError(t, r.Create(ctx, u))
+	_, err := r.FindByEmail(ctx, "aa@bb.cc")
+	assert.Same(t, wantErr, err)
+	got, err := r.FindByID(ctx, u.ID())
+	assert.NoError(t, err)
+	assert.Equal(t, u, got)
+
+	// SetUserError takes precedence
+	globalErr := errors.New("global")
+	SetUserError(r, globalErr)
+	_, err = r.FindByEmail(ctx, "aa@bb.cc")
+	assert.Same(t, globalErr, err)
+	SetUserError(r, nil)
+
+	SetUserMethodError(r, "FindByEmail", nil)
+	got, err = r.FindByEmail(ctx, "aa@bb.cc")
+	assert.NoError(t, err)
+	assert.Equal(t, u, got)
+}
+
+func TestUser_SoftRemove(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	u1 := user.New().NewID().Name("a").Email("a@bb.cc").Auths([]user.Auth{user.AuthFrom("auth0|aaa")}).MustBuild()
+	u2 := user.New().NewID().Name("b").Email("b@bb.cc").MustBuild()
+	r := NewUserWith(u1, u2)
+	defer MockUserNow(r, now)()
+
+	assert.NoError(t, r.SoftRemove(ctx, u1.ID()))
+	assert.Same(t, rerror.ErrNotFound, r.SoftRemove(ctx, u1.ID()))
+	assert.Same(t, rerror.ErrNotFound, r.SoftRemove(ctx, user.NewID()))
+	assert.False(t, u1.IsDeleted())
+
+	_, err := r.FindByID(ctx, u1.ID())
+	assert.Same(t, rerror.ErrNotFound, err)
+	_, err = r.FindByEmail(ctx, "a@bb.cc")
+	assert.Same(t, rerror.ErrNotFound, err)
+	_, err = r.FindBySub(ctx, "auth0|aaa")
+	assert.Same(t, rerror.ErrNotFound, err)
+	got, err := r.FindByIDs(ctx, accountdomain.UserIDList{u1.ID(), u2.ID()})
+	assert.NoError(t, err)
+	assert.Equal(t, []*user.User{u2}, got)
+	got, _, err = r.FindAll(ctx, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []*user.User{u2}, got)
+	assert.Same(t, rerror.ErrNotFound, r.UpdateLocale(ctx, u1.ID(), language.Japanese, ""))
+
+	u, err := r.FindByIDIncludingDeleted(ctx, u1.ID())
+	assert.NoError(t, err)
+	assert.Equal(t, &now, u.DeletedAt())
+	_, err = r.FindByIDIncludingDeleted(ctx, user.NewID())
+	assert.Same(t, rerror.ErrNotFound, err)
+
+	// the record is kept
+	assert.Equal(t, 2, r.data.Len())
+	assert.Same(t, accountrepo.ErrDuplicatedUser, r.Create(ctx, u1))
+}
+
+func TestUser_SoftRemove_Concurrent(t *testing.T) {
+	ctx := context.Background()
+	u := user.New().NewID().Name("hoge").Email("aa@bb.cc").MustBuild()
+	r := NewUserWith(u)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = r.UpdateNotificationPref(ctx, u.ID(), "news", user.NotificationChannelEmail, true)
+		}()
+	}
+	assert.NoError(t, r.SoftRemove(ctx, u.ID()))
+	wg.Wait()
+
+	// updates running at the same time do not restore the deleted user
+	_, err := r.FindByID(ctx, u.ID())
+	assert.Same(t, rerror.ErrNotFound, err)
+}
+
+func TestUser_FindBySource(t *testing.T) {
+	ctx := context.Background()
+	u1 := user.New().NewID().Name("a").Email("a@bb.cc").Attribution(user.Attribution{Source: "google", Campaign: "x"}).MustBuild()
+	u2 := user.New().NewID().Name("b").Email("b@bb.cc").Attribution(user.Attribution{Source: "twitter"}).MustBuild()
+	u3 := user.New().NewID().Name("c").Email("c@bb.cc").Attribution(user.Attribution{Source: "google"}).MustBuild()
+	u4 := user.New().NewID().Name("d").Email("d@bb.cc").MustBuild()
+	r := NewUserWith(u1, u2, u3, u4)
+
+	got, info, err := r.FindBySource(ctx, "google", usecasex.CursorPagination{First: lo.ToPtr(int64(1))}.Wrap())
+	assert.NoError(t, err)
+	assert.Equal(t, []*user.User{u1}, got)
+	assert.True(t, info.HasNextPage)
+
+	got, _, err = r.FindBySource(ctx, "google", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []*user.User{u1, u3}, got)
+
+	_, _, err = r.FindBySource(ctx, "", nil)
+	assert.Same(t, rerror.ErrInvalidParams, err)
+
+	counts, err := r.CountBySource(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int64{"google": 2, "twitter": 1, "": 1}, counts)
+}
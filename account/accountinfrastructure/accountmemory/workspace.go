@@ -8,12 +8,14 @@ import (
 	"github.com/reearth/reearthx/account/accountusecase/accountrepo"
 	"github.com/reearth/reearthx/rerror"
 	"github.com/reearth/reearthx/util"
+	"golang.org/x/exp/maps"
 	"golang.org/x/exp/slices"
 )
 
 type Workspace struct {
-	data *util.SyncMap[accountdomain.WorkspaceID, *workspace.Workspace]
-	err  error
+	data         *util.SyncMap[accountdomain.WorkspaceID, *workspace.Workspace]
+	defaultRoles util.SyncMap[accountdomain.WorkspaceID, workspace.Role]
+	err          error
 }
 
 func NewWorkspace() *Workspace {
@@ -98,6 +100,7 @@ func (r *Workspace) Remove(ctx context.Context, wid accountdomain.WorkspaceID) e
 	}
 
 	r.data.Delete(wid)
+	r.defaultRoles.Delete(wid)
 	return nil
 }
 
@@ -108,10 +111,57 @@ func (r *Workspace) RemoveAll(ctx context.Context, ids accountdomain.WorkspaceID
 
 	for _, wid := range ids {
 		r.data.Delete(wid)
+		r.defaultRoles.Delete(wid)
 	}
 	return nil
 }
 
+func (r *Workspace) SetDefaultRole(ctx context.Context, wid accountdomain.WorkspaceID, role workspace.Role) error {
+	if r.err != nil {
+		return r.err
+	}
+	if !role.Valid() {
+		return workspace.ErrInvalidRole
+	}
+	if _, ok := r.data.Load(wid); !ok {
+		return rerror.ErrNotFound
+	}
+
+	r.defaultRoles.Store(wid, role)
+	return nil
+}
+
+func (r *Workspace) DefaultRole(ctx context.Context, wid accountdomain.WorkspaceID) (workspace.Role, error) {
+	if r.err != nil {
+		return "", r.err
+	}
+	if _, ok := r.data.Load(wid); !ok {
+		return "", rerror.ErrNotFound
+	}
+
+	if role, ok := r.defaultRoles.Load(wid); ok {
+		return role, nil
+	}
+	return workspace.RoleReader, nil
+}
+
+func (r *Workspace) DistinctRoles(ctx context.Context) ([]workspace.Role, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	roles := map[workspace.Role]struct{}{}
+	r.data.Range(func(_ accountdomain.WorkspaceID, ws *workspace.Workspace) bool {
+		for _, m := range ws.Members().Users() {
+			roles[m.Role] = struct{}{}
+		}
+		return true
+	})
+	res := maps.Keys(roles)
+	slices.Sort(res)
+	return res, nil
+}
+
 func SetWorkspaceError(r accountrepo.Workspace, err error) {
 	r.(*Workspace).err = err
 }
@@ -92,6 +92,27 @@ func TestWorkspace_FindByUser(t *testing.T) {
 	assert.Same(t, wantErr, r.Save(ctx, ws))
 }
 
+func TestWorkspace_FindByIntegration(t *testing.T) {
+	ctx := context.Background()
+	iid := accountdomain.NewIntegrationID()
+	ws := workspace.New().NewID().Name("hoge").Integrations(map[accountdomain.IntegrationID]workspace.Member{iid: {Role: workspace.RoleReader}}).MustBuild()
+	ws2 := workspace.New().NewID().Name("foo").MustBuild()
+	r := NewWorkspaceWith(ws, ws2)
+
+	out, err := r.FindByIntegration(ctx, iid)
+	assert.NoError(t, err)
+	assert.Equal(t, workspace.WorkspaceList{ws}, out)
+
+	out2, err := r.FindByIntegration(ctx, accountdomain.NewIntegrationID())
+	assert.Same(t, rerror.ErrNotFound, err)
+	assert.Nil(t, out2)
+
+	wantErr := errors.New("test")
+	SetWorkspaceError(r, wantErr)
+	_, err = r.FindByIntegration(ctx, iid)
+	assert.Same(t, wantErr, err)
+}
+
 func TestWorkspace_Save(t *testing.T) {
 	ctx := context.Background()
 	ws := workspace.New().NewID().Name("hoge").MustBuild()
@@ -159,3 +180,65 @@ func TestWorkspace_RemoveAll(t *testing.T) {
 	SetWorkspaceError(r, wantErr)
 	assert.Same(t, wantErr, r.RemoveAll(ctx, ids))
 }
+
+func TestWorkspace_DefaultRole(t *testing.T) {
+	ctx := context.Background()
+	ws := workspace.New().NewID().Name("hoge").MustBuild()
+	r := NewWorkspaceWith(ws)
+
+	got, err := r.DefaultRole(ctx, ws.ID())
+	assert.NoError(t, err)
+	assert.Equal(t, workspace.RoleReader, got)
+
+	assert.NoError(t, r.SetDefaultRole(ctx, ws.ID(), workspace.RoleWriter))
+	got, err = r.DefaultRole(ctx, ws.ID())
+	assert.NoError(t, err)
+	assert.Equal(t, workspace.RoleWriter, got)
+
+	assert.Equal(t, workspace.ErrInvalidRole, r.SetDefaultRole(ctx, ws.ID(), workspace.Role("x")))
+	assert.Equal(t, rerror.ErrNotFound, r.SetDefaultRole(ctx, accountdomain.NewWorkspaceID(), workspace.RoleWriter))
+	_, err = r.DefaultRole(ctx, accountdomain.NewWorkspaceID())
+	assert.Equal(t, rerror.ErrNotFound, err)
+
+	// removing the workspace also removes the default role
+	assert.NoError(t, r.Remove(ctx, ws.ID()))
+	assert.NoError(t, r.Save(ctx, ws))
+	got, err = r.DefaultRole(ctx, ws.ID())
+	assert.NoError(t, err)
+	assert.Equal(t, workspace.RoleReader, got)
+
+	wantErr := errors.New("test")
+	SetWorkspaceError(r, wantErr)
+	assert.Same(t, wantErr, r.SetDefaultRole(ctx, ws.ID(), workspace.RoleWriter))
+	_, err = r.DefaultRole(ctx, ws.ID())
+	assert.Same(t, wantErr, err)
+}
+
+func TestWorkspace_DistinctRoles(t *testing.T) {
+	ctx := context.Background()
+	u1, u2, u3 := accountdomain.NewUserID(), accountdomain.NewUserID(), accountdomain.NewUserID()
+	ws1 := workspace.New().NewID().Members(map[user.ID]workspace.Member{
+		u1: {Role: workspace.RoleOwner},
+		u2: {Role: workspace.RoleWriter},
+	}).MustBuild()
+	ws2 := workspace.New().NewID().Members(map[user.ID]workspace.Member{
+		u1: {Role: workspace.RoleWriter},
+		u3: {Role: workspace.Role("legacy")},
+	}).Integrations(map[workspace.IntegrationID]workspace.Member{
+		workspace.NewIntegrationID(): {Role: workspace.RoleReader},
+	}).MustBuild()
+
+	got, err := NewWorkspace().DistinctRoles(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, got)
+
+	r := NewWorkspaceWith(ws1, ws2)
+	got, err = r.DistinctRoles(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []workspace.Role{"legacy", workspace.RoleOwner, workspace.RoleWriter}, got)
+
+	wantErr := errors.New("test")
+	SetWorkspaceError(r, wantErr)
+	_, err = r.DistinctRoles(ctx)
+	assert.Same(t, wantErr, err)
+}
@@ -56,3 +56,13 @@ func createIndexes(ctx context.Context, c *mongox.Collection, keys, uniqueKeys [
 	}
 	return err
 }
+
+// createIndexes2 is the same as createIndexes, but indexes can have options such as collations.
+// Indexes created by createIndexes are replaced on the first run as their names are different.
+func createIndexes2(ctx context.Context, c *mongox.Collection, indexes ...mongox.Index) error {
+	res, err := c.Indexes2(ctx, indexes...)
+	if len(res.Added) > 0 || len(res.Updated) > 0 || len(res.Deleted) > 0 {
+		log.Infof("mongo: %s: index deleted: %v, updated: %v, created: %v", c.Client().Name(), res.DeletedNames(), res.UpdatedNames(), res.AddedNames())
+	}
+	return err
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/reearth/reearthx/account/accountdomain"
 	"github.com/reearth/reearthx/account/accountdomain/user"
 	"github.com/reearth/reearthx/mongox"
+	"go.mongodb.org/mongo-driver/bson"
 )
 
 type PasswordResetDocument struct {
@@ -13,6 +14,7 @@ type PasswordResetDocument struct {
 	CreatedAt time.Time
 }
 
+// UserDocument omits Notifications and Tags when they are empty, as $set and $addToSet on fields of null fail.
 type UserDocument struct {
 	ID            string
 	Name          string
@@ -21,9 +23,22 @@ type UserDocument struct {
 	Workspace     string
 	Lang          string
 	Theme         string
+	Timezone      string
 	Password      []byte
 	PasswordReset *PasswordResetDocument
 	Verification  *UserVerificationDoc
+	Notifications map[string]map[string]bool `bson:",omitempty"`
+	Metadata      map[string]any
+	Tags          []string `bson:",omitempty"`
+	Attribution   *AttributionDocument
+	Deleted       bool
+	DeletedAt     *time.Time
+}
+
+type AttributionDocument struct {
+	Source   string
+	Medium   string
+	Campaign string
 }
 
 type UserVerificationDoc struct {
@@ -57,6 +72,15 @@ func NewUser(user *user.User) (*UserDocument, string) {
 		}
 	}
 
+	var attribution *AttributionDocument
+	if a := user.Attribution(); !a.IsEmpty() {
+		attribution = &AttributionDocument{
+			Source:   a.Source,
+			Medium:   a.Medium,
+			Campaign: a.Campaign,
+		}
+	}
+
 	return &UserDocument{
 		ID:            id,
 		Name:          user.Name(),
@@ -65,9 +89,16 @@ func NewUser(user *user.User) (*UserDocument, string) {
 		Workspace:     user.Workspace().String(),
 		Lang:          user.Lang().String(),
 		Theme:         string(user.Theme()),
+		Timezone:      user.Timezone(),
 		Verification:  v,
 		Password:      user.Password(),
 		PasswordReset: pwdResetDoc,
+		Notifications: newNotificationPreferences(user.NotificationPreferences()),
+		Metadata:      user.Metadata(),
+		Tags:          user.Tags(),
+		Attribution:   attribution,
+		Deleted:       user.IsDeleted(),
+		DeletedAt:     user.DeletedAt(),
 	}, id
 }
 
@@ -101,6 +132,12 @@ func (d *UserDocument) Model() (*user.User, error) {
 		EncodedPassword(d.Password).
 		PasswordReset(d.PasswordReset.Model()).
 		Theme(user.Theme(d.Theme)).
+		Timezone(d.Timezone).
+		NotificationPreferences(notificationPreferencesFrom(d.Notifications)).
+		Metadata(metadataFrom(d.Metadata)).
+		Tags(d.Tags).
+		Attribution(d.Attribution.Model()).
+		DeletedAt(d.DeletedAt).
 		Build()
 
 	if err != nil {
@@ -119,8 +156,79 @@ func (d *PasswordResetDocument) Model() *user.PasswordReset {
 	}
 }
 
+func newNotificationPreferences(p user.NotificationPreferences) map[string]map[string]bool {
+	if len(p) == 0 {
+		return nil
+	}
+	res := make(map[string]map[string]bool, len(p))
+	for category, channels := range p {
+		c := make(map[string]bool, len(channels))
+		for channel, enabled := range channels {
+			c[string(channel)] = enabled
+		}
+		res[string(category)] = c
+	}
+	return res
+}
+
+func notificationPreferencesFrom(d map[string]map[string]bool) user.NotificationPreferences {
+	if len(d) == 0 {
+		return nil
+	}
+	res := make(user.NotificationPreferences, len(d))
+	for category, channels := range d {
+		c := make(map[user.NotificationChannel]bool, len(channels))
+		for channel, enabled := range channels {
+			c[user.NotificationChannel(channel)] = enabled
+		}
+		res[user.NotificationCategory(category)] = c
+	}
+	return res
+}
+
+func metadataFrom(d map[string]any) user.Metadata {
+	if len(d) == 0 {
+		return nil
+	}
+	return user.Metadata(metadataValueFrom(d).(map[string]any))
+}
+
+// metadataValueFrom converts nested documents decoded by the driver into plain maps and slices.
+func metadataValueFrom(v any) any {
+	switch w := v.(type) {
+	case map[string]any:
+		res := make(map[string]any, len(w))
+		for k, e := range w {
+			res[k] = metadataValueFrom(e)
+		}
+		return res
+	case bson.M:
+		return metadataValueFrom(map[string]any(w))
+	case bson.D:
+		return metadataValueFrom(map[string]any(w.Map()))
+	case bson.A:
+		res := make([]any, 0, len(w))
+		for _, e := range w {
+			res = append(res, metadataValueFrom(e))
+		}
+		return res
+	}
+	return v
+}
+
 type UserConsumer = mongox.SliceFuncConsumer[*UserDocument, *user.User]
 
 func NewUserConsumer() *UserConsumer {
 	return NewComsumer[*UserDocument, *user.User]()
 }
+
+func (d *AttributionDocument) Model() user.Attribution {
+	if d == nil {
+		return user.Attribution{}
+	}
+	return user.Attribution{
+		Source:   d.Source,
+		Medium:   d.Medium,
+		Campaign: d.Campaign,
+	}
+}
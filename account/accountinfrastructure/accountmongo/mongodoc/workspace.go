@@ -20,6 +20,11 @@ type WorkspaceDocument struct {
 	Personal     bool
 }
 
+type WorkspaceDefaultRoleDocument struct {
+	ID   string
+	Role string
+}
+
 func NewWorkspace(ws *workspace.Workspace) (*WorkspaceDocument, string) {
 	membersDoc := map[string]WorkspaceMemberDocument{}
 	for uId, m := range ws.Members().Users() {
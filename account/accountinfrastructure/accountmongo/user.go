@@ -2,6 +2,10 @@ package accountmongo
 
 import (
 	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
 
 	"github.com/reearth/reearthx/account/accountdomain"
 	"github.com/reearth/reearthx/account/accountdomain/user"
@@ -9,29 +13,81 @@ import (
 	"github.com/reearth/reearthx/account/accountusecase/accountrepo"
 	"github.com/reearth/reearthx/mongox"
 	"github.com/reearth/reearthx/rerror"
+	"github.com/reearth/reearthx/usecasex"
+	"github.com/reearth/reearthx/util"
+	"github.com/samber/lo"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"golang.org/x/exp/slices"
+	"golang.org/x/text/language"
 )
 
 var (
 	userIndexes       = []string{"subs", "name"}
-	userUniqueIndexes = []string{"id", "email"}
+	userUniqueIndexes = []string{"id"}
+	// emails are unique case-insensitively. Queries by email have to specify the same collation to use the index.
+	userEmailIndex = mongox.Index{
+		Name:      "email",
+		Key:       bson.D{{Key: "email", Value: 1}},
+		Unique:    true,
+		Collation: &mongox.IndexCollation{Locale: "en", Strength: 2},
+	}
 )
 
+const userDeletedKey = "deleted"
+
 type User struct {
 	client *mongox.Collection
+	now    util.TimeNow
 }
 
 func NewUser(client *mongox.Client) accountrepo.User {
-	return &User{client: client.WithCollection("user")}
+	c := client.WithCollection("user")
+	c.SetSoftDelete(userDeletedKey)
+	c.SetDuplicateKeyError(true)
+	return &User{client: c}
 }
 
 func (r *User) Init() error {
-	return createIndexes(context.Background(), r.client, userIndexes, userUniqueIndexes)
+	return createIndexes2(
+		context.Background(),
+		r.client,
+		append(
+			append(mongox.IndexFromKeys(userUniqueIndexes, true), mongox.IndexFromKeys(userIndexes, false)...),
+			userEmailIndex,
+		)...,
+	)
 }
 
 func (r *User) FindByIDs(ctx context.Context, ids accountdomain.UserIDList) ([]*user.User, error) {
+	res, err := r.FindByIDsOrdered(ctx, lo.Uniq(ids))
+	if err != nil {
+		return nil, err
+	}
+	return lo.Compact(res), nil
+}
+
+func (r *User) FindAll(ctx context.Context, p *usecasex.Pagination) ([]*user.User, *usecasex.PageInfo, error) {
+	return r.paginate(ctx, bson.M{}, p)
+}
+
+func (r *User) Search(ctx context.Context, keyword string, p *usecasex.Pagination) ([]*user.User, *usecasex.PageInfo, error) {
+	if keyword == "" {
+		return r.FindAll(ctx, p)
+	}
+
+	regex := primitive.Regex{Pattern: regexp.QuoteMeta(keyword), Options: "i"}
+	return r.paginate(ctx, bson.M{
+		"$or": []bson.M{
+			{"name": regex},
+			{"email": regex},
+		},
+	}, p)
+}
+
+func (r *User) FindByIDsOrdered(ctx context.Context, ids accountdomain.UserIDList) ([]*user.User, error) {
 	if len(ids) == 0 {
 		return nil, nil
 	}
@@ -49,6 +105,37 @@ func (r *User) FindByID(ctx context.Context, id2 accountdomain.UserID) (*user.Us
 	return r.findOne(ctx, bson.M{"id": id2.String()})
 }
 
+func (r *User) FindByIDPrefix(ctx context.Context, prefix string, limit int) ([]*user.User, error) {
+	if prefix == "" {
+		return nil, rerror.ErrInvalidParams
+	}
+
+	// IDs are lowercase, and the range query can use the index on id unlike a regex
+	prefix = strings.ToLower(prefix)
+	opts := options.Find().SetSort(bson.M{"id": 1})
+	if limit > 0 {
+		opts.SetLimit(int64(limit))
+	}
+	c := mongodoc.NewUserConsumer()
+	if err := r.client.Find(ctx, bson.M{
+		"id": bson.M{"$gte": prefix, "$lt": prefix + "\uffff"},
+	}, c, opts); err != nil {
+		return nil, err
+	}
+	return c.Result, nil
+}
+
+func (r *User) FindByIDIncludingDeleted(ctx context.Context, id accountdomain.UserID) (*user.User, error) {
+	c := mongodoc.NewUserConsumer()
+	if err := r.client.FindIncludingDeleted(ctx, bson.M{"id": id.String()}, c); err != nil {
+		return nil, err
+	}
+	if len(c.Result) == 0 {
+		return nil, rerror.ErrNotFound
+	}
+	return c.Result[0], nil
+}
+
 func (r *User) FindBySub(ctx context.Context, auth0sub string) (*user.User, error) {
 	return r.findOne(ctx, bson.M{
 		"$or": []bson.M{
@@ -63,8 +150,24 @@ func (r *User) FindBySub(ctx context.Context, auth0sub string) (*user.User, erro
 	})
 }
 
+func (r *User) FindBySubs(ctx context.Context, subs []string) ([]*user.User, error) {
+	subs = lo.Uniq(lo.Compact(subs))
+	if len(subs) == 0 {
+		return nil, nil
+	}
+
+	res, err := r.find(ctx, bson.M{
+		"subs": bson.M{"$in": subs},
+	})
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(res, func(a, b *user.User) bool { return a.ID().Compare(b.ID()) < 0 })
+	return res, nil
+}
+
 func (r *User) FindByEmail(ctx context.Context, email string) (*user.User, error) {
-	return r.findOne(ctx, bson.M{"email": email})
+	return r.findOne(ctx, bson.M{"email": email}, emailFindOption())
 }
 
 func (r *User) FindByName(ctx context.Context, name string) (*user.User, error) {
@@ -72,12 +175,12 @@ func (r *User) FindByName(ctx context.Context, name string) (*user.User, error)
 }
 
 func (r *User) FindByNameOrEmail(ctx context.Context, nameOrEmail string) (*user.User, error) {
-	return r.findOne(ctx, bson.M{
-		"$or": []bson.M{
-			{"email": nameOrEmail},
-			{"name": nameOrEmail},
-		},
-	})
+	// the collation of emails must not be applied to names, so they are found separately
+	u, err := r.findOne(ctx, bson.M{"email": nameOrEmail}, emailFindOption())
+	if !errors.Is(err, rerror.ErrNotFound) {
+		return u, err
+	}
+	return r.findOne(ctx, bson.M{"name": nameOrEmail})
 }
 
 func (r *User) FindByVerification(ctx context.Context, code string) (*user.User, error) {
@@ -88,12 +191,15 @@ func (r *User) FindByVerification(ctx context.Context, code string) (*user.User,
 
 func (r *User) FindByPasswordResetRequest(ctx context.Context, pwdResetToken string) (*user.User, error) {
 	return r.findOne(ctx, bson.M{
-		"passwordreset.token": pwdResetToken,
+		"passwordreset.token":     pwdResetToken,
+		"passwordreset.createdat": bson.M{"$gt": r.now.Now().Add(-24 * time.Hour)},
 	})
 }
 
-func (r *User) FindBySubOrCreate(ctx context.Context, u *user.User, sub string) (*user.User, error) {
+func (r *User) FindBySubOrCreate(ctx context.Context, u *user.User, sub string) (*user.User, bool, error) {
 	userDoc, _ := mongodoc.NewUser(u)
+	// decode into a new document so that fields missing in the existing document are not filled with those of u
+	var res mongodoc.UserDocument
 	if err := r.client.Client().FindOneAndUpdate(
 		ctx,
 		bson.M{
@@ -106,16 +212,69 @@ func (r *User) FindBySubOrCreate(ctx context.Context, u *user.User, sub string)
 					},
 				},
 			},
+			userDeletedKey: bson.M{"$ne": true},
 		},
 		bson.M{"$setOnInsert": userDoc},
 		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
-	).Decode(&userDoc); err != nil {
+	).Decode(&res); err != nil {
 		if mongo.IsDuplicateKeyError(err) {
-			return nil, accountrepo.ErrDuplicatedUser
+			// subs has no unique index, so a concurrent call may have inserted the user with the same ID or email first
+			if u2, err2 := r.FindBySub(ctx, sub); err2 == nil {
+				return u2, false, nil
+			}
+			return nil, false, accountrepo.ErrDuplicatedUser
 		}
+		return nil, false, rerror.ErrInternalBy(err)
+	}
+
+	u2, err := res.Model()
+	if err != nil {
+		return nil, false, err
+	}
+	// the returned user has the new ID only if it has been inserted
+	return u2, u2.ID() == u.ID(), nil
+}
+
+// FindByMetadata finds users whose metadata has the value at the dotted path.
+func (r *User) FindByMetadata(ctx context.Context, path string, value any, p *usecasex.Pagination) ([]*user.User, *usecasex.PageInfo, error) {
+	if path == "" {
+		return nil, nil, rerror.ErrInvalidParams
+	}
+	return r.paginate(ctx, bson.M{"metadata." + path: value}, p)
+}
+
+func (r *User) FindBySource(ctx context.Context, source string, p *usecasex.Pagination) ([]*user.User, *usecasex.PageInfo, error) {
+	if source == "" {
+		return nil, nil, rerror.ErrInvalidParams
+	}
+	return r.paginate(ctx, bson.M{"attribution.source": source}, p)
+}
+
+func (r *User) CountBySource(ctx context.Context) (map[string]int64, error) {
+	cur, err := r.client.Client().Aggregate(ctx, []bson.M{
+		{"$match": bson.M{userDeletedKey: bson.M{"$ne": true}}},
+		{"$group": bson.M{
+			"_id":   bson.M{"$ifNull": []any{"$attribution.source", ""}},
+			"count": bson.M{"$sum": 1},
+		}},
+	})
+	if err != nil {
+		return nil, rerror.ErrInternalBy(err)
+	}
+	defer func() { _ = cur.Close(ctx) }()
+
+	var rows []struct {
+		Source string `bson:"_id"`
+		Count  int64  `bson:"count"`
+	}
+	if err := cur.All(ctx, &rows); err != nil {
 		return nil, rerror.ErrInternalBy(err)
 	}
-	return userDoc.Model()
+	res := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		res[row.Source] = row.Count
+	}
+	return res, nil
 }
 
 func (r *User) Create(ctx context.Context, user *user.User) error {
@@ -132,13 +291,175 @@ func (r *User) Create(ctx context.Context, user *user.User) error {
 	return nil
 }
 
+func (r *User) CreateAll(ctx context.Context, users []*user.User, allOrNothing bool) ([]*user.User, accountdomain.UserIDList, error) {
+	if len(users) == 0 {
+		return []*user.User{}, nil, nil
+	}
+
+	docs := make([]any, 0, len(users))
+	for _, u := range users {
+		doc, _ := mongodoc.NewUser(u)
+		docs = append(docs, doc)
+	}
+
+	if allOrNothing {
+		ids := accountdomain.UserIDList(lo.Map(users, func(u *user.User, _ int) accountdomain.UserID { return u.ID() }))
+		// soft-deleted users also occupy their IDs
+		existing := mongodoc.NewUserConsumer()
+		if err := r.client.FindIncludingDeleted(ctx, bson.M{"id": bson.M{"$in": lo.Uniq(ids.Strings())}}, existing); err != nil {
+			return nil, nil, err
+		}
+		duplicates := lo.FindDuplicates(ids)
+		for _, u := range existing.Result {
+			duplicates = append(duplicates, u.ID())
+		}
+		if len(duplicates) > 0 {
+			return nil, duplicates, accountrepo.ErrDuplicatedUser
+		}
+
+		if _, err := r.client.Client().InsertMany(ctx, docs); err != nil {
+			// roll back users inserted before the error, e.g. when an email is duplicated
+			if _, err2 := r.client.Client().DeleteMany(ctx, bson.M{"id": bson.M{"$in": ids.Strings()}}); err2 != nil {
+				return nil, nil, rerror.ErrInternalBy(err2)
+			}
+			if mongo.IsDuplicateKeyError(err) {
+				return nil, nil, accountrepo.ErrDuplicatedUser
+			}
+			return nil, nil, rerror.ErrInternalBy(err)
+		}
+		return users, nil, nil
+	}
+
+	_, err := r.client.Client().InsertMany(ctx, docs, options.InsertMany().SetOrdered(false))
+	if err == nil {
+		return users, nil, nil
+	}
+
+	var bwe mongo.BulkWriteException
+	if !errors.As(err, &bwe) || bwe.WriteConcernError != nil {
+		return nil, nil, rerror.ErrInternalBy(err)
+	}
+	failed := map[int]struct{}{}
+	for _, we := range bwe.WriteErrors {
+		if !mongo.IsDuplicateKeyError(we.WriteError) {
+			return nil, nil, rerror.ErrInternalBy(err)
+		}
+		failed[we.Index] = struct{}{}
+	}
+
+	created := make([]*user.User, 0, len(users))
+	var duplicates accountdomain.UserIDList
+	for i, u := range users {
+		if _, ok := failed[i]; ok {
+			duplicates = append(duplicates, u.ID())
+			continue
+		}
+		created = append(created, u)
+	}
+	return created, duplicates, nil
+}
+
 func (r *User) Save(ctx context.Context, user *user.User) error {
 	doc, id := mongodoc.NewUser(user)
-	return r.client.SaveOne(ctx, id, doc)
+	if err := r.client.SaveOne(ctx, id, doc); err != nil {
+		// e.g. the email is used by another user
+		if errors.Is(err, mongox.ErrDuplicateKey) {
+			return accountrepo.ErrDuplicatedUser
+		}
+		return err
+	}
+	return nil
+}
+
+func (r *User) UpdateNotificationPref(ctx context.Context, id accountdomain.UserID, category user.NotificationCategory, channel user.NotificationChannel, enabled bool) error {
+	if !category.Valid() || !channel.Valid() {
+		return rerror.ErrInvalidParams
+	}
+
+	res, err := r.client.Client().UpdateOne(
+		ctx,
+		bson.M{"id": id.String(), userDeletedKey: bson.M{"$ne": true}},
+		bson.M{"$set": bson.M{
+			"notifications." + string(category) + "." + string(channel): enabled,
+		}},
+	)
+	if err != nil {
+		return rerror.ErrInternalBy(err)
+	}
+	if res.MatchedCount == 0 {
+		return rerror.ErrNotFound
+	}
+	return nil
+}
+
+func (r *User) UpdateLocale(ctx context.Context, id accountdomain.UserID, lang language.Tag, tz string) error {
+	if !user.ValidTimezone(tz) {
+		return rerror.ErrInvalidParams
+	}
+
+	res, err := r.client.Client().UpdateOne(
+		ctx,
+		bson.M{"id": id.String(), userDeletedKey: bson.M{"$ne": true}},
+		bson.M{"$set": bson.M{
+			"lang":     lang.String(),
+			"timezone": tz,
+		}},
+	)
+	if err != nil {
+		return rerror.ErrInternalBy(err)
+	}
+	if res.MatchedCount == 0 {
+		return rerror.ErrNotFound
+	}
+	return nil
+}
+
+func (r *User) TagByCriteria(ctx context.Context, criteria accountrepo.UserCriteria, tag string) (int64, error) {
+	if tag == "" || criteria.IsEmpty() {
+		return 0, rerror.ErrInvalidParams
+	}
+
+	filter := userCriteriaFilter(criteria)
+	filter[userDeletedKey] = bson.M{"$ne": true}
+	res, err := r.client.Client().UpdateMany(
+		ctx,
+		filter,
+		bson.M{"$addToSet": bson.M{"tags": tag}},
+	)
+	if err != nil {
+		return 0, rerror.ErrInternalBy(err)
+	}
+	return res.ModifiedCount, nil
+}
+
+func (r *User) SoftRemove(ctx context.Context, id accountdomain.UserID) error {
+	res, err := r.client.Client().UpdateOne(
+		ctx,
+		bson.M{"id": id.String(), userDeletedKey: bson.M{"$ne": true}},
+		bson.M{"$set": bson.M{
+			userDeletedKey: true,
+			"deletedat":    r.now.Now(),
+		}},
+	)
+	if err != nil {
+		return rerror.ErrInternalBy(err)
+	}
+	if res.MatchedCount == 0 {
+		return rerror.ErrNotFound
+	}
+	return nil
 }
 
 func (r *User) Remove(ctx context.Context, user accountdomain.UserID) error {
-	return r.client.RemoveOne(ctx, bson.M{"id": user.String()})
+	// RemoveOne of the collection only marks the user as deleted
+	res, err := r.client.Client().DeleteOne(ctx, bson.M{"id": user.String()})
+	if err != nil {
+		return rerror.ErrInternalBy(err)
+	}
+	if res.DeletedCount == 0 {
+		return rerror.ErrNotFound
+	}
+	return nil
 }
 
 func (r *User) find(ctx context.Context, filter any) ([]*user.User, error) {
@@ -149,14 +470,57 @@ func (r *User) find(ctx context.Context, filter any) ([]*user.User, error) {
 	return c.Result, nil
 }
 
-func (r *User) findOne(ctx context.Context, filter any) (*user.User, error) {
+func (r *User) paginate(ctx context.Context, filter any, p *usecasex.Pagination) ([]*user.User, *usecasex.PageInfo, error) {
 	c := mongodoc.NewUserConsumer()
-	if err := r.client.FindOne(ctx, filter, c); err != nil {
+	if p == nil || p.Cursor == nil && p.Offset == nil {
+		if err := r.client.Find(ctx, filter, c, options.Find().SetSort(bson.M{"id": 1})); err != nil {
+			return nil, nil, err
+		}
+		var start, end *usecasex.Cursor
+		if len(c.Result) > 0 {
+			start = usecasex.Cursor(c.Result[0].ID().String()).Ref()
+			end = usecasex.Cursor(c.Result[len(c.Result)-1].ID().String()).Ref()
+		}
+		return c.Result, usecasex.NewPageInfo(int64(len(c.Result)), start, end, false, false), nil
+	}
+
+	pageInfo, err := r.client.Paginate(ctx, filter, nil, p, c)
+	if err != nil {
+		return nil, nil, err
+	}
+	return c.Result, pageInfo, nil
+}
+
+func (r *User) findOne(ctx context.Context, filter any, opts ...*options.FindOneOptions) (*user.User, error) {
+	c := mongodoc.NewUserConsumer()
+	if err := r.client.FindOne(ctx, filter, c, opts...); err != nil {
 		return nil, err
 	}
 	return c.Result[0], nil
 }
 
+func userCriteriaFilter(c accountrepo.UserCriteria) bson.M {
+	filter := bson.M{}
+	if c.Workspace != nil {
+		filter["workspace"] = c.Workspace.String()
+	}
+	if c.EmailDomain != "" {
+		filter["email"] = primitive.Regex{Pattern: "@" + regexp.QuoteMeta(c.EmailDomain) + "$", Options: "i"}
+	}
+	if c.Tag != "" {
+		filter["tags"] = c.Tag
+	}
+	for path, value := range c.Metadata {
+		filter["metadata."+path] = value
+	}
+	return filter
+}
+
+// emailFindOption matches emails case-insensitively with the collation of the email index
+func emailFindOption() *options.FindOneOptions {
+	return options.FindOne().SetCollation(userEmailIndex.Collation.Options())
+}
+
 func filterUsers(ids []accountdomain.UserID, rows []*user.User) []*user.User {
 	res := make([]*user.User, 0, len(ids))
 	for _, id := range ids {
@@ -171,3 +535,8 @@ func filterUsers(ids []accountdomain.UserID, rows []*user.User) []*user.User {
 	}
 	return res
 }
+
+// MockUserNow fixes the time used to check expirations of password reset requests and to soft-delete users, and returns a function to restore it.
+func MockUserNow(r accountrepo.User, now time.Time) func() {
+	return r.(*User).now.Mock(now)
+}
@@ -2,15 +2,21 @@ package accountmongo
 
 import (
 	"context"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/reearth/reearthx/account/accountdomain"
 	"github.com/reearth/reearthx/account/accountdomain/user"
+	"github.com/reearth/reearthx/account/accountusecase/accountrepo"
 	"github.com/reearth/reearthx/mongox"
 	"github.com/reearth/reearthx/mongox/mongotest"
 	"github.com/reearth/reearthx/rerror"
+	"github.com/reearth/reearthx/usecasex"
+	"github.com/reearth/reearthx/util"
+	"github.com/samber/lo"
 	"github.com/stretchr/testify/assert"
+	"golang.org/x/text/language"
 )
 
 func TestUserRepo_FindByID(t *testing.T) {
@@ -142,6 +148,92 @@ func TestUserRepo_FindByIDs(t *testing.T) {
 	}
 }
 
+func TestUserRepo_FindAll(t *testing.T) {
+	user1 := user.New().NewID().Email("a@bb.cc").Workspace(user.NewWorkspaceID()).Name("a").MustBuild()
+	user2 := user.New().NewID().Email("b@bb.cc").Workspace(user.NewWorkspaceID()).Name("b").MustBuild()
+	user3 := user.New().NewID().Email("c@bb.cc").Workspace(user.NewWorkspaceID()).Name("c").MustBuild()
+
+	client := mongox.NewClientWithDatabase(mongotest.Connect(t)(t))
+	repo := NewUser(client)
+	ctx := context.Background()
+	for _, u := range []*user.User{user3, user1, user2} {
+		assert.NoError(t, repo.Save(ctx, u))
+	}
+	ids := func(users []*user.User) []accountdomain.UserID {
+		return lo.Map(users, func(u *user.User, _ int) accountdomain.UserID { return u.ID() })
+	}
+
+	got, info, err := repo.FindAll(ctx, usecasex.CursorPagination{First: lo.ToPtr(int64(2))}.Wrap())
+	assert.NoError(t, err)
+	assert.Equal(t, []accountdomain.UserID{user1.ID(), user2.ID()}, ids(got))
+	assert.True(t, info.HasNextPage)
+
+	got, info, err = repo.FindAll(ctx, usecasex.CursorPagination{First: lo.ToPtr(int64(2)), After: info.EndCursor}.Wrap())
+	assert.NoError(t, err)
+	assert.Equal(t, []accountdomain.UserID{user3.ID()}, ids(got))
+	assert.False(t, info.HasNextPage)
+}
+
+func TestUserRepo_Search(t *testing.T) {
+	user1 := user.New().NewID().Email("alice@example.com").Workspace(user.NewWorkspaceID()).Name("Alice").MustBuild()
+	user2 := user.New().NewID().Email("bob@Example.org").Workspace(user.NewWorkspaceID()).Name("bob").MustBuild()
+	user3 := user.New().NewID().Email("carol@test.com").Workspace(user.NewWorkspaceID()).Name("c.rol").MustBuild()
+
+	client := mongox.NewClientWithDatabase(mongotest.Connect(t)(t))
+	repo := NewUser(client)
+	ctx := context.Background()
+	for _, u := range []*user.User{user3, user1, user2} {
+		assert.NoError(t, repo.Save(ctx, u))
+	}
+	ids := func(users []*user.User) []accountdomain.UserID {
+		return lo.Map(users, func(u *user.User, _ int) accountdomain.UserID { return u.ID() })
+	}
+
+	got, _, err := repo.Search(ctx, "EXAMPLE", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []accountdomain.UserID{user1.ID(), user2.ID()}, ids(got))
+
+	// the keyword is not a regular expression
+	got, _, err = repo.Search(ctx, "c.r", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []accountdomain.UserID{user3.ID()}, ids(got))
+
+	got, info, err := repo.Search(ctx, "", usecasex.CursorPagination{First: lo.ToPtr(int64(2))}.Wrap())
+	assert.NoError(t, err)
+	assert.Equal(t, []accountdomain.UserID{user1.ID(), user2.ID()}, ids(got))
+	assert.True(t, info.HasNextPage)
+}
+
+func TestUserRepo_FindByIDsOrdered(t *testing.T) {
+	user1 := user.New().NewID().Email("aa@bb.cc").Workspace(user.NewWorkspaceID()).Name("foo").MustBuild()
+	user2 := user.New().NewID().Email("aa2@bb.cc").Workspace(user.NewWorkspaceID()).Name("hoge").MustBuild()
+
+	client := mongox.NewClientWithDatabase(mongotest.Connect(t)(t))
+	repo := NewUser(client)
+	ctx := context.Background()
+	assert.NoError(t, repo.Save(ctx, user1))
+	assert.NoError(t, repo.Save(ctx, user2))
+
+	got, err := repo.FindByIDsOrdered(ctx, accountdomain.UserIDList{user2.ID(), user.NewID(), user1.ID()})
+	assert.NoError(t, err)
+	assert.Len(t, got, 3)
+	assert.Equal(t, user2.ID(), got[0].ID())
+	assert.Nil(t, got[1])
+	assert.Equal(t, user1.ID(), got[2].ID())
+
+	got, err = repo.FindByIDs(ctx, accountdomain.UserIDList{user2.ID(), user.NewID(), user1.ID()})
+	assert.NoError(t, err)
+	assert.Equal(t, []accountdomain.UserID{user2.ID(), user1.ID()}, lo.Map(got, func(u *user.User, _ int) accountdomain.UserID { return u.ID() }))
+
+	got, err = repo.FindByIDs(ctx, accountdomain.UserIDList{user1.ID(), user1.ID()})
+	assert.NoError(t, err)
+	assert.Len(t, got, 1)
+
+	got, err = repo.FindByIDs(ctx, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []*user.User{}, got)
+}
+
 func TestUserRepo_FindByName(t *testing.T) {
 	wsid := user.NewWorkspaceID()
 	user1 := user.New().
@@ -218,12 +310,24 @@ func TestUserRepo_FindByEmail(t *testing.T) {
 			RepoData: user1,
 			Expected: user1,
 		},
+		{
+			Name:     "must find a user case-insensitively",
+			Input:    "AA@Bb.cc",
+			RepoData: user1,
+			Expected: user1,
+		},
 		{
 			Name:     "must not find any user",
 			Input:    "xx@yy.zz",
 			RepoData: user1,
 			WantErr:  true,
 		},
+		{
+			Name:     "must not match as a pattern",
+			Input:    "a.@bb.cc",
+			RepoData: user1,
+			WantErr:  true,
+		},
 	}
 
 	init := mongotest.Connect(t)
@@ -280,6 +384,18 @@ func TestUserRepo_FindByNameOrEmail(t *testing.T) {
 			RepoData: user1,
 			Expected: user1,
 		},
+		{
+			Name:     "must find a user by email case-insensitively",
+			Input:    "AA@BB.cc",
+			RepoData: user1,
+			Expected: user1,
+		},
+		{
+			Name:     "must not find a user by name case-insensitively",
+			Input:    "Foo",
+			RepoData: user1,
+			WantErr:  true,
+		},
 		{
 			Name:     "must not find any user",
 			Input:    "xx@yy.zz",
@@ -377,6 +493,27 @@ func TestUserRepo_FindByPasswordResetRequest(t *testing.T) {
 	}
 }
 
+func TestUserRepo_FindByPasswordResetRequest_Expired(t *testing.T) {
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	user1 := user.New().NewID().Email("aa@bb.cc").Workspace(user.NewWorkspaceID()).Name("foo").
+		PasswordReset(user.PasswordResetFrom("123abc", now)).MustBuild()
+
+	client := mongox.NewClientWithDatabase(mongotest.Connect(t)(t))
+	repo := NewUser(client)
+	ctx := context.Background()
+	assert.NoError(t, repo.Save(ctx, user1))
+
+	defer MockUserNow(repo, now.Add(time.Hour))()
+	got, err := repo.FindByPasswordResetRequest(ctx, "123abc")
+	assert.NoError(t, err)
+	assert.Equal(t, user1.ID(), got.ID())
+
+	defer MockUserNow(repo, now.Add(25*time.Hour))()
+	got, err = repo.FindByPasswordResetRequest(ctx, "123abc")
+	assert.Equal(t, rerror.ErrNotFound, err)
+	assert.Nil(t, got)
+}
+
 func TestUserRepo_FindByVerification(t *testing.T) {
 	vr := user.VerificationFrom("123abc", time.Now(), false)
 
@@ -497,6 +634,79 @@ func TestUserRepo_FindBySub(t *testing.T) {
 	}
 }
 
+func TestUserRepo_FindByIDPrefix(t *testing.T) {
+	user1 := user.New().ID(accountdomain.MustUserID("01gq6gzvh3qhdaqzkh6h4jcpkz")).Email("a@bb.cc").Workspace(user.NewWorkspaceID()).Name("a").MustBuild()
+	user2 := user.New().ID(accountdomain.MustUserID("01gq6gzvh3qhdaqzkh6h4jcpm0")).Email("b@bb.cc").Workspace(user.NewWorkspaceID()).Name("b").MustBuild()
+	user3 := user.New().ID(accountdomain.MustUserID("01gq7000000000000000000000")).Email("c@bb.cc").Workspace(user.NewWorkspaceID()).Name("c").MustBuild()
+
+	client := mongox.NewClientWithDatabase(mongotest.Connect(t)(t))
+	repo := NewUser(client)
+	ctx := context.Background()
+	for _, u := range []*user.User{user3, user2, user1} {
+		assert.NoError(t, repo.Save(ctx, u))
+	}
+	ids := func(users []*user.User) []accountdomain.UserID {
+		return lo.Map(users, func(u *user.User, _ int) accountdomain.UserID { return u.ID() })
+	}
+
+	got, err := repo.FindByIDPrefix(ctx, "01GQ6", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []accountdomain.UserID{user1.ID(), user2.ID()}, ids(got))
+
+	got, err = repo.FindByIDPrefix(ctx, "01gq", 1)
+	assert.NoError(t, err)
+	assert.Equal(t, []accountdomain.UserID{user1.ID()}, ids(got))
+
+	_, err = repo.FindByIDPrefix(ctx, "", 0)
+	assert.Equal(t, rerror.ErrInvalidParams, err)
+}
+
+func TestUserRepo_FindBySubs(t *testing.T) {
+	user1 := user.New().NewID().Email("aa@bb.cc").Workspace(user.NewWorkspaceID()).Name("foo").Auths([]user.Auth{{Provider: "auth0", Sub: "auth0|a"}}).MustBuild()
+	user2 := user.New().NewID().Email("aa2@bb.cc").Workspace(user.NewWorkspaceID()).Name("hoge").Auths([]user.Auth{{Provider: "auth0", Sub: "auth0|b"}}).MustBuild()
+	user3 := user.New().NewID().Email("aa3@bb.cc").Workspace(user.NewWorkspaceID()).Name("xxx").Auths([]user.Auth{{Provider: "auth0", Sub: "auth0|c"}}).MustBuild()
+
+	client := mongox.NewClientWithDatabase(mongotest.Connect(t)(t))
+	repo := NewUser(client)
+	ctx := context.Background()
+	for _, u := range []*user.User{user3, user1, user2} {
+		assert.NoError(t, repo.Save(ctx, u))
+	}
+
+	got, err := repo.FindBySubs(ctx, []string{"auth0|b", "", "auth0|a", "auth0|x"})
+	assert.NoError(t, err)
+	assert.Equal(t, []accountdomain.UserID{user1.ID(), user2.ID()}, lo.Map(got, func(u *user.User, _ int) accountdomain.UserID { return u.ID() }))
+
+	got, err = repo.FindBySubs(ctx, nil)
+	assert.NoError(t, err)
+	assert.Empty(t, got)
+}
+
+func TestUserRepo_CreateAll(t *testing.T) {
+	user1 := user.New().NewID().Email("a@bb.cc").Workspace(user.NewWorkspaceID()).Name("a").MustBuild()
+	user2 := user.New().NewID().Email("b@bb.cc").Workspace(user.NewWorkspaceID()).Name("b").MustBuild()
+	user3 := user.New().NewID().Email("c@bb.cc").Workspace(user.NewWorkspaceID()).Name("c").MustBuild()
+	user4 := user.New().NewID().Email("d@bb.cc").Workspace(user.NewWorkspaceID()).Name("d").MustBuild()
+
+	client := mongox.NewClientWithDatabase(mongotest.Connect(t)(t))
+	repo := NewUser(client)
+	ctx := context.Background()
+	assert.NoError(t, repo.(*User).Init())
+	assert.NoError(t, repo.Create(ctx, user1))
+
+	created, duplicates, err := repo.CreateAll(ctx, []*user.User{user2, user1, user3}, false)
+	assert.NoError(t, err)
+	assert.Equal(t, []*user.User{user2, user3}, created)
+	assert.Equal(t, accountdomain.UserIDList{user1.ID()}, duplicates)
+
+	created, duplicates, err = repo.CreateAll(ctx, []*user.User{user4, user2}, true)
+	assert.Equal(t, accountrepo.ErrDuplicatedUser, err)
+	assert.Nil(t, created)
+	assert.Equal(t, accountdomain.UserIDList{user2.ID()}, duplicates)
+	_, err = repo.FindByID(ctx, user4.ID())
+	assert.Equal(t, rerror.ErrNotFound, err)
+}
+
 func TestUserRepo_Remove(t *testing.T) {
 	wsid := user.NewWorkspaceID()
 	user1 := user.New().
@@ -517,4 +727,302 @@ func TestUserRepo_Remove(t *testing.T) {
 
 	err = repo.Remove(ctx, user1.ID())
 	assert.NoError(t, err)
+	_, err = repo.FindByIDIncludingDeleted(ctx, user1.ID())
+	assert.Same(t, rerror.ErrNotFound, err)
+}
+
+func TestUserRepo_SoftRemove(t *testing.T) {
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	defer util.MockNow(now)()
+
+	user1 := user.New().NewID().Email("aa@bb.cc").Workspace(user.NewWorkspaceID()).Name("foo").
+		Auths([]user.Auth{user.AuthFrom("auth0|aaa")}).MustBuild()
+	user2 := user.New().NewID().Email("bb@bb.cc").Workspace(user.NewWorkspaceID()).Name("bar").MustBuild()
+
+	client := mongox.NewClientWithDatabase(mongotest.Connect(t)(t))
+	repo := NewUser(client)
+	ctx := context.Background()
+	assert.NoError(t, repo.Save(ctx, user1))
+	assert.NoError(t, repo.Save(ctx, user2))
+
+	assert.NoError(t, repo.SoftRemove(ctx, user1.ID()))
+	assert.Same(t, rerror.ErrNotFound, repo.SoftRemove(ctx, user1.ID()))
+	assert.Same(t, rerror.ErrNotFound, repo.SoftRemove(ctx, user.NewID()))
+
+	_, err := repo.FindByID(ctx, user1.ID())
+	assert.Same(t, rerror.ErrNotFound, err)
+	_, err = repo.FindByEmail(ctx, "aa@bb.cc")
+	assert.Same(t, rerror.ErrNotFound, err)
+	_, err = repo.FindBySub(ctx, "auth0|aaa")
+	assert.Same(t, rerror.ErrNotFound, err)
+	got, _, err := repo.FindAll(ctx, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(got))
+	assert.Equal(t, user2.ID(), got[0].ID())
+
+	u, err := repo.FindByIDIncludingDeleted(ctx, user1.ID())
+	assert.NoError(t, err)
+	assert.True(t, u.IsDeleted())
+	assert.True(t, now.Equal(*u.DeletedAt()))
+
+	// the soft-deleted user still occupies the ID
+	_, _, err = repo.CreateAll(ctx, []*user.User{user1}, true)
+	assert.Same(t, accountrepo.ErrDuplicatedUser, err)
+}
+
+func TestUserRepo_UpdateNotificationPref(t *testing.T) {
+	user1 := user.New().
+		NewID().
+		Email("aa@bb.cc").
+		Workspace(user.NewWorkspaceID()).
+		Name("foo").
+		NotificationPreferences(user.NotificationPreferences{
+			"billing": {user.NotificationChannelSMS: true},
+		}).
+		MustBuild()
+
+	init := mongotest.Connect(t)
+	client := mongox.NewClientWithDatabase(init(t))
+	repo := NewUser(client)
+	ctx := context.Background()
+	assert.NoError(t, repo.Save(ctx, user1))
+
+	assert.NoError(t, repo.UpdateNotificationPref(ctx, user1.ID(), "news", user.NotificationChannelEmail, true))
+	assert.NoError(t, repo.UpdateNotificationPref(ctx, user1.ID(), "billing", user.NotificationChannelPush, false))
+
+	got, err := repo.FindByID(ctx, user1.ID())
+	assert.NoError(t, err)
+	assert.Equal(t, user.NotificationPreferences{
+		"billing": {user.NotificationChannelSMS: true, user.NotificationChannelPush: false},
+		"news":    {user.NotificationChannelEmail: true},
+	}, got.NotificationPreferences())
+
+	assert.Equal(t, rerror.ErrNotFound, repo.UpdateNotificationPref(ctx, user.NewID(), "news", user.NotificationChannelEmail, true))
+	assert.Equal(t, rerror.ErrInvalidParams, repo.UpdateNotificationPref(ctx, user1.ID(), "a.b", user.NotificationChannelEmail, true))
+
+	assert.NoError(t, repo.SoftRemove(ctx, user1.ID()))
+	assert.Equal(t, rerror.ErrNotFound, repo.UpdateNotificationPref(ctx, user1.ID(), "news", user.NotificationChannelEmail, true))
+}
+
+func TestUserRepo_UpdateNotificationPref_NoPrefs(t *testing.T) {
+	user1 := user.New().NewID().Email("aa@bb.cc").Workspace(user.NewWorkspaceID()).Name("foo").MustBuild()
+
+	client := mongox.NewClientWithDatabase(mongotest.Connect(t)(t))
+	repo := NewUser(client)
+	ctx := context.Background()
+	assert.NoError(t, repo.Save(ctx, user1))
+
+	assert.NoError(t, repo.UpdateNotificationPref(ctx, user1.ID(), "news", user.NotificationChannelEmail, true))
+
+	got, err := repo.FindByID(ctx, user1.ID())
+	assert.NoError(t, err)
+	assert.Equal(t, user.NotificationPreferences{
+		"news": {user.NotificationChannelEmail: true},
+	}, got.NotificationPreferences())
+}
+
+func TestUserRepo_UpdateLocale(t *testing.T) {
+	user1 := user.New().NewID().Email("aa@bb.cc").Workspace(user.NewWorkspaceID()).Name("foo").MustBuild()
+
+	client := mongox.NewClientWithDatabase(mongotest.Connect(t)(t))
+	repo := NewUser(client)
+	ctx := context.Background()
+	assert.NoError(t, repo.Save(ctx, user1))
+
+	assert.NoError(t, repo.UpdateLocale(ctx, user1.ID(), language.Japanese, "Asia/Tokyo"))
+
+	got, err := repo.FindByID(ctx, user1.ID())
+	assert.NoError(t, err)
+	assert.Equal(t, language.Japanese, got.Lang())
+	assert.Equal(t, "Asia/Tokyo", got.Timezone())
+
+	assert.Equal(t, rerror.ErrNotFound, repo.UpdateLocale(ctx, user.NewID(), language.Japanese, "Asia/Tokyo"))
+	assert.Equal(t, rerror.ErrInvalidParams, repo.UpdateLocale(ctx, user1.ID(), language.Japanese, "Asia/Nowhere"))
+
+	assert.NoError(t, repo.SoftRemove(ctx, user1.ID()))
+	assert.Equal(t, rerror.ErrNotFound, repo.UpdateLocale(ctx, user1.ID(), language.English, "UTC"))
+}
+
+func TestUserRepo_TagByCriteria(t *testing.T) {
+	wid := user.NewWorkspaceID()
+	user1 := user.New().NewID().Email("a@Example.com").Workspace(wid).Name("a").MustBuild()
+	user2 := user.New().NewID().Email("b@example.com").Workspace(user.NewWorkspaceID()).Name("b").Metadata(user.Metadata{"plan": "pro"}).MustBuild()
+	user3 := user.New().NewID().Email("c@other.com").Workspace(user.NewWorkspaceID()).Name("c").Metadata(user.Metadata{"plan": "pro"}).MustBuild()
+	deleted := user.New().NewID().Email("d@example.com").Workspace(user.NewWorkspaceID()).Name("d").MustBuild()
+
+	client := mongox.NewClientWithDatabase(mongotest.Connect(t)(t))
+	repo := NewUser(client)
+	ctx := context.Background()
+	for _, u := range []*user.User{user1, user2, user3, deleted} {
+		assert.NoError(t, repo.Save(ctx, u))
+	}
+	assert.NoError(t, repo.SoftRemove(ctx, deleted.ID()))
+
+	n, err := repo.TagByCriteria(ctx, accountrepo.UserCriteria{EmailDomain: "example.com"}, "news")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+
+	n, err = repo.TagByCriteria(ctx, accountrepo.UserCriteria{Metadata: map[string]any{"plan": "pro"}}, "news")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+
+	n, err = repo.TagByCriteria(ctx, accountrepo.UserCriteria{Workspace: &wid, Tag: "news"}, "vip")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+
+	got, err := repo.FindByID(ctx, user1.ID())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"news", "vip"}, got.Tags())
+
+	// soft-deleted users are not tagged
+	got, err = repo.FindByIDIncludingDeleted(ctx, deleted.ID())
+	assert.NoError(t, err)
+	assert.Empty(t, got.Tags())
+
+	_, err = repo.TagByCriteria(ctx, accountrepo.UserCriteria{}, "news")
+	assert.Equal(t, rerror.ErrInvalidParams, err)
+}
+
+func TestUserRepo_FindByMetadata(t *testing.T) {
+	wsid := user.NewWorkspaceID()
+	user1 := user.New().NewID().Email("a@bb.cc").Workspace(wsid).Name("a").Metadata(user.Metadata{
+		"source": "campaign-x",
+	}).MustBuild()
+	user2 := user.New().NewID().Email("b@bb.cc").Workspace(wsid).Name("b").Metadata(user.Metadata{
+		"source":  "campaign-x",
+		"profile": map[string]any{"country": "jp"},
+	}).MustBuild()
+	user3 := user.New().NewID().Email("c@bb.cc").Workspace(wsid).Name("c").Metadata(user.Metadata{
+		"source":  "campaign-y",
+		"profile": map[string]any{"country": "jp"},
+	}).MustBuild()
+
+	init := mongotest.Connect(t)
+	client := mongox.NewClientWithDatabase(init(t))
+	repo := NewUser(client)
+	ctx := context.Background()
+	for _, u := range []*user.User{user1, user2, user3} {
+		assert.NoError(t, repo.Save(ctx, u))
+	}
+
+	got, info, err := repo.FindByMetadata(ctx, "source", "campaign-x", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []accountdomain.UserID{user1.ID(), user2.ID()}, lo.Map(got, func(u *user.User, _ int) accountdomain.UserID { return u.ID() }))
+	assert.Equal(t, int64(2), info.TotalCount)
+	assert.Equal(t, user2.Metadata(), got[1].Metadata())
+
+	got, info, err = repo.FindByMetadata(ctx, "profile.country", "jp", usecasex.CursorPagination{First: lo.ToPtr(int64(1))}.Wrap())
+	assert.NoError(t, err)
+	assert.Equal(t, []accountdomain.UserID{user2.ID()}, lo.Map(got, func(u *user.User, _ int) accountdomain.UserID { return u.ID() }))
+	assert.Equal(t, int64(2), info.TotalCount)
+	assert.True(t, info.HasNextPage)
+
+	_, _, err = repo.FindByMetadata(ctx, "", "jp", nil)
+	assert.Equal(t, rerror.ErrInvalidParams, err)
+}
+
+func TestUserRepo_FindBySource(t *testing.T) {
+	user1 := user.New().NewID().Email("a@bb.cc").Workspace(user.NewWorkspaceID()).Name("a").
+		Attribution(user.Attribution{Source: "google", Medium: "cpc", Campaign: "x"}).MustBuild()
+	user2 := user.New().NewID().Email("b@bb.cc").Workspace(user.NewWorkspaceID()).Name("b").
+		Attribution(user.Attribution{Source: "twitter"}).MustBuild()
+	user3 := user.New().NewID().Email("c@bb.cc").Workspace(user.NewWorkspaceID()).Name("c").MustBuild()
+
+	client := mongox.NewClientWithDatabase(mongotest.Connect(t)(t))
+	repo := NewUser(client)
+	ctx := context.Background()
+	for _, u := range []*user.User{user1, user2, user3} {
+		assert.NoError(t, repo.Save(ctx, u))
+	}
+
+	got, _, err := repo.FindBySource(ctx, "google", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(got))
+	assert.Equal(t, user1.Attribution(), got[0].Attribution())
+
+	counts, err := repo.CountBySource(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int64{"google": 1, "twitter": 1, "": 1}, counts)
+}
+
+func TestUserRepo_Save_Duplicated(t *testing.T) {
+	user1 := user.New().NewID().Email("a@bb.cc").Workspace(user.NewWorkspaceID()).Name("a").MustBuild()
+	user2 := user.New().NewID().Email("a@bb.cc").Workspace(user.NewWorkspaceID()).Name("b").MustBuild()
+
+	client := mongox.NewClientWithDatabase(mongotest.Connect(t)(t))
+	repo := NewUser(client)
+	ctx := context.Background()
+	assert.NoError(t, repo.(*User).Init())
+	assert.NoError(t, repo.Save(ctx, user1))
+	assert.Same(t, accountrepo.ErrDuplicatedUser, repo.Save(ctx, user2))
+
+	// emails are unique case-insensitively
+	user3 := user.New().NewID().Email("A@BB.cc").Workspace(user.NewWorkspaceID()).Name("c").MustBuild()
+	assert.Same(t, accountrepo.ErrDuplicatedUser, repo.Save(ctx, user3))
+}
+
+func TestUserRepo_FindBySubOrCreate(t *testing.T) {
+	ctx := context.Background()
+	client := mongox.NewClientWithDatabase(mongotest.Connect(t)(t))
+	repo := NewUser(client)
+	assert.NoError(t, repo.(*User).Init())
+
+	u := user.New().NewID().Name("a").Email("a@bb.cc").Workspace(user.NewWorkspaceID()).Auths([]user.Auth{{Sub: "auth0|a"}}).MustBuild()
+	got, created, err := repo.FindBySubOrCreate(ctx, u, "auth0|a")
+	assert.NoError(t, err)
+	assert.True(t, created)
+	assert.Equal(t, u.ID(), got.ID())
+
+	u2 := user.New().NewID().Name("a").Email("a@bb.cc").Workspace(user.NewWorkspaceID()).Auths([]user.Auth{{Sub: "auth0|a"}}).MustBuild()
+	got, created, err = repo.FindBySubOrCreate(ctx, u2, "auth0|a")
+	assert.NoError(t, err)
+	assert.False(t, created)
+	assert.Equal(t, u.ID(), got.ID())
+
+	// fields missing in a legacy document are not filled with those of the new user
+	legacyID, wid := user.NewID(), user.NewWorkspaceID()
+	_, err = client.WithCollection("user").Client().InsertOne(ctx, map[string]any{
+		"id":        legacyID.String(),
+		"name":      "b",
+		"email":     "b@bb.cc",
+		"subs":      []string{"auth0|b"},
+		"workspace": wid.String(),
+	})
+	assert.NoError(t, err)
+	u3 := user.New().NewID().Name("b").Email("b@bb.cc").Workspace(user.NewWorkspaceID()).Lang(language.Japanese).Auths([]user.Auth{{Sub: "auth0|b"}}).MustBuild()
+	got, created, err = repo.FindBySubOrCreate(ctx, u3, "auth0|b")
+	assert.NoError(t, err)
+	assert.False(t, created)
+	assert.Equal(t, legacyID, got.ID())
+	assert.Equal(t, wid, got.Workspace())
+	assert.Equal(t, language.Und, got.Lang())
+}
+
+func TestUserRepo_FindBySubOrCreate_Concurrent(t *testing.T) {
+	ctx := context.Background()
+	client := mongox.NewClientWithDatabase(mongotest.Connect(t)(t))
+	repo := NewUser(client)
+	assert.NoError(t, repo.(*User).Init())
+
+	const n = 20
+	res := make([]*user.User, n)
+	created := make([]bool, n)
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		i := i
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			u := user.New().NewID().Name("a").Email("a@bb.cc").Workspace(user.NewWorkspaceID()).Auths([]user.Auth{{Sub: "auth0|a"}}).MustBuild()
+			res[i], created[i], errs[i] = repo.FindBySubOrCreate(ctx, u, "auth0|a")
+		}()
+	}
+	wg.Wait()
+
+	for i := 0; i < n; i++ {
+		assert.NoError(t, errs[i])
+		assert.Equal(t, res[0].ID(), res[i].ID())
+	}
+	assert.Equal(t, 1, lo.Count(created, true))
 }
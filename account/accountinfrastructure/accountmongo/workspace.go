@@ -2,6 +2,7 @@ package accountmongo
 
 import (
 	"context"
+	"errors"
 	"strings"
 
 	"github.com/reearth/reearthx/account/accountdomain"
@@ -9,6 +10,8 @@ import (
 	"github.com/reearth/reearthx/account/accountinfrastructure/accountmongo/mongodoc"
 	"github.com/reearth/reearthx/account/accountusecase/accountrepo"
 	"github.com/reearth/reearthx/mongox"
+	"github.com/reearth/reearthx/rerror"
+	"github.com/reearth/reearthx/util"
 	"go.mongodb.org/mongo-driver/bson"
 )
 
@@ -17,15 +20,26 @@ var (
 )
 
 type Workspace struct {
-	client *mongox.Collection
+	client       *mongox.Collection
+	defaultRoles *mongox.Collection
 }
 
 func NewWorkspace(client *mongox.Client) accountrepo.Workspace {
-	return &Workspace{client: client.WithCollection("workspace")}
+	return &Workspace{
+		client:       client.WithCollection("workspace"),
+		defaultRoles: client.WithCollection("workspacedefaultrole"),
+	}
 }
 
 func (r *Workspace) Init() error {
-	return createIndexes(context.Background(), r.client, nil, workspaceUniqueIndexes)
+	return util.Try(
+		func() error {
+			return createIndexes(context.Background(), r.client, nil, workspaceUniqueIndexes)
+		},
+		func() error {
+			return createIndexes(context.Background(), r.defaultRoles, nil, workspaceUniqueIndexes)
+		},
+	)
 }
 
 func (r *Workspace) FindByUser(ctx context.Context, id accountdomain.UserID) (workspace.WorkspaceList, error) {
@@ -79,16 +93,109 @@ func (r *Workspace) SaveAll(ctx context.Context, workspaces []*workspace.Workspa
 }
 
 func (r *Workspace) Remove(ctx context.Context, id accountdomain.WorkspaceID) error {
-	return r.client.RemoveOne(ctx, bson.M{"id": id.String()})
+	// the default role is removed first so that it is not left when the workspace has already been removed
+	filter := bson.M{"id": id.String()}
+	return util.Try(
+		func() error {
+			return r.removeDefaultRoles(ctx, filter)
+		},
+		func() error {
+			return r.client.RemoveOne(ctx, filter)
+		},
+	)
 }
 
 func (r *Workspace) RemoveAll(ctx context.Context, ids accountdomain.WorkspaceIDList) error {
 	if len(ids) == 0 {
 		return nil
 	}
-	return r.client.RemoveAll(ctx, bson.M{
+	filter := bson.M{
 		"id": bson.M{"$in": ids.Strings()},
+	}
+	return util.Try(
+		func() error {
+			return r.removeDefaultRoles(ctx, filter)
+		},
+		func() error {
+			return r.client.RemoveAll(ctx, filter)
+		},
+	)
+}
+
+func (r *Workspace) removeDefaultRoles(ctx context.Context, filter any) error {
+	if err := r.defaultRoles.RemoveAll(ctx, filter); err != nil && !errors.Is(err, rerror.ErrNotFound) {
+		return err
+	}
+	return nil
+}
+
+func (r *Workspace) SetDefaultRole(ctx context.Context, id accountdomain.WorkspaceID, role workspace.Role) error {
+	if !role.Valid() {
+		return workspace.ErrInvalidRole
+	}
+	if err := r.exists(ctx, id); err != nil {
+		return err
+	}
+	return r.defaultRoles.SaveOne(ctx, id.String(), mongodoc.WorkspaceDefaultRoleDocument{
+		ID:   id.String(),
+		Role: string(role),
+	})
+}
+
+func (r *Workspace) DefaultRole(ctx context.Context, id accountdomain.WorkspaceID) (workspace.Role, error) {
+	if err := r.exists(ctx, id); err != nil {
+		return "", err
+	}
+
+	c := &mongox.OneConsumer[mongodoc.WorkspaceDefaultRoleDocument]{}
+	if err := r.defaultRoles.FindOne(ctx, bson.M{"id": id.String()}, c); err != nil {
+		if errors.Is(err, rerror.ErrNotFound) {
+			return workspace.RoleReader, nil
+		}
+		return "", err
+	}
+	d, err := c.Result()
+	if err != nil {
+		return "", err
+	}
+	return workspace.Role(d.Role), nil
+}
+
+func (r *Workspace) DistinctRoles(ctx context.Context) ([]workspace.Role, error) {
+	// members are stored as a map keyed by user IDs, so the roles cannot be listed with a plain distinct
+	cur, err := r.client.Client().Aggregate(ctx, []bson.M{
+		{"$project": bson.M{"members": bson.M{"$objectToArray": "$members"}}},
+		{"$unwind": "$members"},
+		{"$group": bson.M{"_id": "$members.v.role"}},
+		{"$sort": bson.M{"_id": 1}},
 	})
+	if err != nil {
+		return nil, rerror.ErrInternalBy(err)
+	}
+	defer func() { _ = cur.Close(ctx) }()
+
+	var rows []struct {
+		Role string `bson:"_id"`
+	}
+	if err := cur.All(ctx, &rows); err != nil {
+		return nil, rerror.ErrInternalBy(err)
+	}
+	res := make([]workspace.Role, 0, len(rows))
+	for _, row := range rows {
+		res = append(res, workspace.Role(row.Role))
+	}
+	return res, nil
+}
+
+func (r *Workspace) exists(ctx context.Context, id accountdomain.WorkspaceID) error {
+	n, err := r.client.Count(ctx, bson.M{"id": id.String()})
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return rerror.ErrNotFound
+	}
+	return nil
 }
 
 func (r *Workspace) find(ctx context.Context, filter any) (workspace.WorkspaceList, error) {
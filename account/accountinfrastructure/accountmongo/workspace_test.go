@@ -11,6 +11,7 @@ import (
 	"github.com/reearth/reearthx/mongox/mongotest"
 	"github.com/reearth/reearthx/rerror"
 	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
 )
 
 func TestWorkspace_FindByID(t *testing.T) {
@@ -190,3 +191,85 @@ func TestWorkspace_RemoveAll(t *testing.T) {
 	err = repo.RemoveAll(ctx, accountdomain.WorkspaceIDList{ws1.ID(), ws2.ID()})
 	assert.NoError(t, err)
 }
+
+func TestWorkspace_DefaultRole(t *testing.T) {
+	ws := workspace.New().NewID().Name("hoge").MustBuild()
+
+	init := mongotest.Connect(t)
+	client := mongox.NewClientWithDatabase(init(t))
+
+	repo := NewWorkspace(client)
+	ctx := context.Background()
+	assert.NoError(t, repo.(*Workspace).Init())
+	assert.NoError(t, repo.Save(ctx, ws))
+
+	got, err := repo.DefaultRole(ctx, ws.ID())
+	assert.NoError(t, err)
+	assert.Equal(t, workspace.RoleReader, got)
+
+	assert.NoError(t, repo.SetDefaultRole(ctx, ws.ID(), workspace.RoleWriter))
+	got, err = repo.DefaultRole(ctx, ws.ID())
+	assert.NoError(t, err)
+	assert.Equal(t, workspace.RoleWriter, got)
+
+	// saving the workspace keeps the default role
+	assert.NoError(t, repo.Save(ctx, ws))
+	got, err = repo.DefaultRole(ctx, ws.ID())
+	assert.NoError(t, err)
+	assert.Equal(t, workspace.RoleWriter, got)
+
+	// removing the workspace removes the default role
+	assert.NoError(t, repo.Remove(ctx, ws.ID()))
+	assert.NoError(t, repo.Save(ctx, ws))
+	got, err = repo.DefaultRole(ctx, ws.ID())
+	assert.NoError(t, err)
+	assert.Equal(t, workspace.RoleReader, got)
+
+	// the default role is removed even if the workspace has already been removed
+	assert.NoError(t, repo.SetDefaultRole(ctx, ws.ID(), workspace.RoleWriter))
+	_, err = client.WithCollection("workspace").Client().DeleteOne(ctx, bson.M{"id": ws.ID().String()})
+	assert.NoError(t, err)
+	assert.Same(t, rerror.ErrNotFound, repo.Remove(ctx, ws.ID()))
+	assert.NoError(t, repo.Save(ctx, ws))
+	got, err = repo.DefaultRole(ctx, ws.ID())
+	assert.NoError(t, err)
+	assert.Equal(t, workspace.RoleReader, got)
+
+	assert.NoError(t, repo.SetDefaultRole(ctx, ws.ID(), workspace.RoleWriter))
+	assert.NoError(t, repo.RemoveAll(ctx, accountdomain.WorkspaceIDList{ws.ID()}))
+	assert.NoError(t, repo.Save(ctx, ws))
+	got, err = repo.DefaultRole(ctx, ws.ID())
+	assert.NoError(t, err)
+	assert.Equal(t, workspace.RoleReader, got)
+
+	assert.Equal(t, workspace.ErrInvalidRole, repo.SetDefaultRole(ctx, ws.ID(), workspace.Role("x")))
+	assert.Equal(t, rerror.ErrNotFound, repo.SetDefaultRole(ctx, accountdomain.NewWorkspaceID(), workspace.RoleWriter))
+	_, err = repo.DefaultRole(ctx, accountdomain.NewWorkspaceID())
+	assert.Equal(t, rerror.ErrNotFound, err)
+}
+
+func TestWorkspace_DistinctRoles(t *testing.T) {
+	u1, u2, u3 := accountdomain.NewUserID(), accountdomain.NewUserID(), accountdomain.NewUserID()
+	ws1 := workspace.New().NewID().Name("a").Members(map[user.ID]workspace.Member{
+		u1: {Role: workspace.RoleOwner},
+		u2: {Role: workspace.RoleWriter},
+	}).MustBuild()
+	ws2 := workspace.New().NewID().Name("b").Members(map[user.ID]workspace.Member{
+		u1: {Role: workspace.RoleWriter},
+		u3: {Role: workspace.Role("legacy")},
+	}).MustBuild()
+	ws3 := workspace.New().NewID().Name("c").MustBuild()
+
+	client := mongox.NewClientWithDatabase(mongotest.Connect(t)(t))
+	repo := NewWorkspace(client)
+	ctx := context.Background()
+
+	got, err := repo.DistinctRoles(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, got)
+
+	assert.NoError(t, repo.SaveAll(ctx, workspace.WorkspaceList{ws1, ws2, ws3}))
+	got, err = repo.DistinctRoles(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []workspace.Role{"legacy", workspace.RoleOwner, workspace.RoleWriter}, got)
+}
@@ -6,6 +6,7 @@ import (
 	_ "embed"
 	"errors"
 	htmlTmpl "html/template"
+	"time"
 
 	"github.com/reearth/reearthx/account/accountdomain"
 	"github.com/reearth/reearthx/account/accountdomain/user"
@@ -17,6 +18,7 @@ import (
 	"github.com/reearth/reearthx/i18n"
 	"github.com/reearth/reearthx/mailer"
 	"github.com/reearth/reearthx/rerror"
+	"github.com/reearth/reearthx/util"
 	"github.com/samber/lo"
 )
 
@@ -25,6 +27,7 @@ type User struct {
 	gateways        *accountgateway.Container
 	signupSecret    string
 	authSrvUIDomain string
+	now             func() time.Time
 }
 
 var (
@@ -41,6 +44,18 @@ func NewUser(r *accountrepo.Container, g *accountgateway.Container, signupSecret
 		gateways:        g,
 		signupSecret:    signupSecret,
 		authSrvUIDomain: authSrcUIDomain,
+		now:             util.Now,
+	}
+}
+
+// NewUserWithClock is the same as NewUser, but expirations of verifications and password resets are checked with the clock.
+func NewUserWithClock(r *accountrepo.Container, g *accountgateway.Container, signupSecret, authSrcUIDomain string, now func() time.Time) accountinterfaces.User {
+	return &User{
+		repos:           r,
+		gateways:        g,
+		signupSecret:    signupSecret,
+		authSrvUIDomain: authSrcUIDomain,
+		now:             now,
 	}
 }
 
@@ -282,7 +297,7 @@ func (i *User) VerifyUser(ctx context.Context, code string) (*user.User, error)
 		if err != nil {
 			return nil, err
 		}
-		if u.Verification().IsExpired() {
+		if u.Verification().IsExpiredAt(i.now()) {
 			return nil, errors.New("verification expired")
 		}
 		u.Verification().SetVerified(true)
@@ -308,6 +323,7 @@ func (i *User) StartPasswordReset(ctx context.Context, email string) error {
 		}
 
 		pr := user.NewPasswordReset()
+		pr.CreatedAt = i.now()
 		u.SetPasswordReset(pr)
 
 		if err := i.repos.User.Save(ctx, u); err != nil {
@@ -347,7 +363,7 @@ func (i *User) PasswordReset(ctx context.Context, password string, token string)
 		}
 
 		passwordReset := u.PasswordReset()
-		ok := passwordReset.Validate(token)
+		ok := passwordReset.ValidateAt(token, i.now())
 		if !ok {
 			return accountinterfaces.ErrUserInvalidPasswordReset
 		}
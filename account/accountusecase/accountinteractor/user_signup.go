@@ -176,13 +176,16 @@ func (i *User) FindOrCreate(ctx context.Context, param accountinterfaces.UserFin
 			return nil, err
 		}
 
-		u2, err := i.repos.User.FindBySubOrCreate(ctx, u, param.Sub)
+		u2, created, err := i.repos.User.FindBySubOrCreate(ctx, u, param.Sub)
 		if err != nil {
 			return nil, err
 		}
 
-		if err := i.repos.Workspace.Save(ctx, workspace); err != nil {
-			return nil, err
+		// the personal workspace is needed only for the new user
+		if created {
+			if err := i.repos.Workspace.Save(ctx, workspace); err != nil {
+				return nil, err
+			}
 		}
 
 		return u2, nil
@@ -335,6 +335,12 @@ func TestUser_FindOrCreate(t *testing.T) {
 			}).
 			MustBuild(),
 		u)
+
+	// only the personal workspace of the created user is saved
+	ws, err := r.Workspace.FindByUser(context.Background(), u.ID())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(ws))
+	assert.Equal(t, u.Workspace(), ws[0].ID())
 }
 
 func TestIssToURL(t *testing.T) {
@@ -103,6 +103,28 @@ func TestUser_VerifyUser(t *testing.T) {
 	}
 }
 
+func TestUser_VerifyUser_Clock(t *testing.T) {
+	user.DefaultPasswordEncoder = &user.NoopPasswordEncoder{}
+	ctx := context.Background()
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	r := accountmemory.New()
+	u := user.New().
+		NewID().
+		Workspace(accountdomain.NewWorkspaceID()).
+		Name("NAME").
+		Email("aaa@bbb.com").
+		Verification(user.VerificationFrom("code", now.Add(time.Hour), false)).
+		MustBuild()
+	assert.NoError(t, r.User.Save(ctx, u))
+
+	_, err := NewUserWithClock(r, nil, "", "", func() time.Time { return now.Add(2 * time.Hour) }).VerifyUser(ctx, "code")
+	assert.Equal(t, errors.New("verification expired"), err)
+
+	got, err := NewUserWithClock(r, nil, "", "", func() time.Time { return now }).VerifyUser(ctx, "code")
+	assert.NoError(t, err)
+	assert.True(t, got.Verification().IsVerified())
+}
+
 func TestUser_StartPasswordReset(t *testing.T) {
 	user.DefaultPasswordEncoder = &user.NoopPasswordEncoder{}
 	uid := accountdomain.NewUserID()
@@ -125,8 +125,18 @@ func (i *Workspace) AddUserMember(ctx context.Context, workspaceID accountdomain
 			return nil, err
 		}
 
+		var defaultRole workspace.Role
 		for _, m := range ul {
-			err = ws.Members().Join(m.ID(), users[m.ID()], *operator.User)
+			role := users[m.ID()]
+			if role == "" {
+				if defaultRole == "" {
+					if defaultRole, err = i.repos.Workspace.DefaultRole(ctx, workspaceID); err != nil {
+						return nil, err
+					}
+				}
+				role = defaultRole
+			}
+			err = ws.Members().Join(m.ID(), role, *operator.User)
 			if err != nil {
 				return nil, err
 			}
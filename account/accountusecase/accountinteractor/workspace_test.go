@@ -649,6 +649,32 @@ func TestWorkspace_AddMember(t *testing.T) {
 	}
 }
 
+func TestWorkspace_AddMember_DefaultRole(t *testing.T) {
+	ctx := context.Background()
+	userID := accountdomain.NewUserID()
+	ws := workspace.New().NewID().Name("W").Members(map[user.ID]workspace.Member{userID: {Role: workspace.RoleOwner}}).MustBuild()
+	u1 := user.New().NewID().Name("aaa").Email("a@b.c").MustBuild()
+	u2 := user.New().NewID().Name("bbb").Email("b@b.c").MustBuild()
+	op := &accountusecase.Operator{
+		User:             &userID,
+		OwningWorkspaces: []accountdomain.WorkspaceID{ws.ID()},
+	}
+
+	db := accountmemory.New()
+	assert.NoError(t, db.Workspace.Save(ctx, ws))
+	assert.NoError(t, db.User.Save(ctx, u1))
+	assert.NoError(t, db.User.Save(ctx, u2))
+	assert.NoError(t, db.Workspace.SetDefaultRole(ctx, ws.ID(), workspace.RoleWriter))
+
+	got, err := NewWorkspace(db).AddUserMember(ctx, ws.ID(), map[accountdomain.UserID]workspace.Role{
+		u1.ID(): "",
+		u2.ID(): workspace.RoleMaintainer,
+	}, op)
+	assert.NoError(t, err)
+	assert.Equal(t, workspace.RoleWriter, got.Members().UserRole(u1.ID()))
+	assert.Equal(t, workspace.RoleMaintainer, got.Members().UserRole(u2.ID()))
+}
+
 func TestWorkspace_AddIntegrationMember(t *testing.T) {
 	userID := accountdomain.NewUserID()
 	id1 := accountdomain.NewWorkspaceID()
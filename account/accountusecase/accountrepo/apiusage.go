@@ -0,0 +1,14 @@
+package accountrepo
+
+import (
+	"context"
+	"time"
+)
+
+// APIUsage stores rate limit counters per API key.
+type APIUsage interface {
+	// IncrAPIUsage records a usage of the key and returns the number of usages within the sliding window ending now,
+	// including the recorded one.
+	IncrAPIUsage(ctx context.Context, keyID string, window time.Duration) (int64, error)
+	ResetAPIUsage(ctx context.Context, keyID string) error
+}
@@ -9,10 +9,14 @@ import (
 )
 
 type Container struct {
-	User        User
-	Workspace   Workspace
-	Policy      Policy
-	Transaction usecasex.Transaction
+	User          User
+	Workspace     Workspace
+	Policy        Policy
+	APIUsage      APIUsage
+	Impersonation Impersonation
+	Session       Session
+	MagicLink     MagicLink
+	Transaction   usecasex.Transaction
 }
 
 var (
@@ -0,0 +1,20 @@
+package accountrepo
+
+import (
+	"context"
+
+	"github.com/reearth/reearthx/account/accountdomain"
+	"github.com/reearth/reearthx/account/accountdomain/user"
+	"github.com/reearth/reearthx/usecasex"
+)
+
+type Impersonation interface {
+	// RecordImpersonation records that the agent impersonates the target user.
+	// The agent must be the impersonator carried by the context.
+	RecordImpersonation(ctx context.Context, agent, target accountdomain.UserID, reason string) error
+	// FindImpersonations returns impersonations of the target user in the order they were recorded.
+	FindImpersonations(ctx context.Context, target accountdomain.UserID, p *usecasex.Pagination) ([]*user.Impersonation, *usecasex.PageInfo, error)
+	// UserAuditTrail returns impersonations where the user is the agent or the target in the order they were recorded,
+	// so that the activity recorded about the user can be exported.
+	UserAuditTrail(ctx context.Context, id accountdomain.UserID, p *usecasex.Pagination) ([]*user.Impersonation, *usecasex.PageInfo, error)
+}
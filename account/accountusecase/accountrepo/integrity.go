@@ -0,0 +1,19 @@
+package accountrepo
+
+import (
+	"github.com/reearth/reearthx/account/accountdomain"
+)
+
+// IntegrityReport lists dangling references between users and workspaces.
+type IntegrityReport struct {
+	// UsersWithoutWorkspace are users whose personal workspace does not exist.
+	UsersWithoutWorkspace accountdomain.UserIDList
+	// UsersNotInWorkspace are users who are not a member of their personal workspace.
+	UsersNotInWorkspace accountdomain.UserIDList
+	// MissingMembers are users that do not exist but are members of the workspaces.
+	MissingMembers map[accountdomain.WorkspaceID]accountdomain.UserIDList
+}
+
+func (r *IntegrityReport) IsEmpty() bool {
+	return r == nil || len(r.UsersWithoutWorkspace) == 0 && len(r.UsersNotInWorkspace) == 0 && len(r.MissingMembers) == 0
+}
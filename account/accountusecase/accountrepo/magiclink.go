@@ -0,0 +1,21 @@
+package accountrepo
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+)
+
+// MagicLink stores tokens for password-less login. Tokens must be stored hashed with HashMagicLinkToken.
+type MagicLink interface {
+	Create(ctx context.Context, email, token string, expiry time.Time) error
+	// Consume returns the email of the token and invalidates the token. Used or expired tokens return rerror.ErrNotFound.
+	Consume(ctx context.Context, token string) (string, error)
+	RemoveExpired(ctx context.Context) error
+}
+
+func HashMagicLinkToken(token string) string {
+	h := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(h[:])
+}
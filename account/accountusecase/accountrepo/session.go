@@ -0,0 +1,15 @@
+package accountrepo
+
+import (
+	"context"
+
+	"github.com/reearth/reearthx/account/accountdomain"
+)
+
+// Session stores active login sessions of users.
+type Session interface {
+	CreateSession(ctx context.Context, id accountdomain.UserID, sessionID string) error
+	ActiveSessionCount(ctx context.Context, id accountdomain.UserID) (int64, error)
+	// EvictOldestSession removes the session of the user that was created first.
+	EvictOldestSession(ctx context.Context, id accountdomain.UserID) error
+}
@@ -7,21 +7,54 @@ import (
 	"github.com/reearth/reearthx/account/accountdomain/user"
 	"github.com/reearth/reearthx/i18n"
 	"github.com/reearth/reearthx/rerror"
+	"github.com/reearth/reearthx/usecasex"
+	"golang.org/x/text/language"
 )
 
 var ErrDuplicatedUser = rerror.NewE(i18n.T("duplicated user"))
 
+// User is the repository of users. Find methods do not return soft-deleted users unless noted.
 type User interface {
+	// FindByIDs returns users in the same order as the ids. Missing users are skipped and duplicated ids are returned once.
+	// It returns an empty slice rather than nil if no users are found.
 	FindByIDs(context.Context, accountdomain.UserIDList) ([]*user.User, error)
+	// FindAll returns all users sorted by ID.
+	FindAll(context.Context, *usecasex.Pagination) ([]*user.User, *usecasex.PageInfo, error)
+	// Search returns users whose name or email contains the keyword case-insensitively, sorted by ID. An empty keyword matches all users.
+	Search(context.Context, string, *usecasex.Pagination) ([]*user.User, *usecasex.PageInfo, error)
+	// FindByIDsOrdered returns users in the same order as the ids. Missing users are returned as nil.
+	FindByIDsOrdered(context.Context, accountdomain.UserIDList) ([]*user.User, error)
 	FindByID(context.Context, accountdomain.UserID) (*user.User, error)
+	// FindByIDIncludingDeleted is the same as FindByID but also returns the user if it is soft-deleted.
+	FindByIDIncludingDeleted(context.Context, accountdomain.UserID) (*user.User, error)
+	// FindByIDPrefix returns at most limit users whose IDs start with the prefix, sorted by ID. If limit is zero or negative, all matching users are returned.
+	FindByIDPrefix(ctx context.Context, prefix string, limit int) ([]*user.User, error)
 	FindBySub(context.Context, string) (*user.User, error)
+	// FindBySubs returns users that have any of the subs, sorted by ID. Empty subs are ignored.
+	FindBySubs(context.Context, []string) ([]*user.User, error)
 	FindByEmail(context.Context, string) (*user.User, error)
 	FindByName(context.Context, string) (*user.User, error)
 	FindByNameOrEmail(context.Context, string) (*user.User, error)
 	FindByVerification(context.Context, string) (*user.User, error)
+	// FindByPasswordResetRequest returns the user who has the password reset token. Expired tokens are not found.
 	FindByPasswordResetRequest(context.Context, string) (*user.User, error)
-	FindBySubOrCreate(context.Context, *user.User, string) (*user.User, error)
+	// FindBySubOrCreate returns the user who has the sub, or creates the user atomically if not found. The bool reports whether the user was created.
+	FindBySubOrCreate(context.Context, *user.User, string) (*user.User, bool, error)
+	FindByMetadata(context.Context, string, any, *usecasex.Pagination) ([]*user.User, *usecasex.PageInfo, error)
+	// FindBySource returns users whose attribution source is the source, sorted by ID.
+	FindBySource(context.Context, string, *usecasex.Pagination) ([]*user.User, *usecasex.PageInfo, error)
+	// CountBySource returns the number of users for each attribution source. Users without a source are counted under "".
+	CountBySource(context.Context) (map[string]int64, error)
 	Create(context.Context, *user.User) error
+	// CreateAll creates users whose IDs do not exist yet and returns the created users and the IDs of duplicated users.
+	// If allOrNothing is true and any user is duplicated, no users are created and ErrDuplicatedUser is returned.
+	CreateAll(ctx context.Context, users []*user.User, allOrNothing bool) ([]*user.User, accountdomain.UserIDList, error)
 	Save(context.Context, *user.User) error
+	UpdateNotificationPref(context.Context, accountdomain.UserID, user.NotificationCategory, user.NotificationChannel, bool) error
+	UpdateLocale(context.Context, accountdomain.UserID, language.Tag, string) error
+	// TagByCriteria adds the tag to all users matching the criteria and returns the number of newly tagged users.
+	TagByCriteria(context.Context, UserCriteria, string) (int64, error)
+	// SoftRemove marks the user as deleted but keeps the record. It returns rerror.ErrNotFound if the user does not exist or is already deleted.
+	SoftRemove(context.Context, accountdomain.UserID) error
 	Remove(context.Context, accountdomain.UserID) error
 }
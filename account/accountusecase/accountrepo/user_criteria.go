@@ -0,0 +1,43 @@
+package accountrepo
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/reearth/reearthx/account/accountdomain"
+	"github.com/reearth/reearthx/account/accountdomain/user"
+)
+
+// UserCriteria selects users for bulk operations. All specified conditions must match.
+type UserCriteria struct {
+	Workspace   *accountdomain.WorkspaceID
+	EmailDomain string
+	Tag         string
+	// Metadata maps dotted paths of the user metadata to the expected values.
+	Metadata map[string]any
+}
+
+func (c UserCriteria) IsEmpty() bool {
+	return c.Workspace == nil && c.EmailDomain == "" && c.Tag == "" && len(c.Metadata) == 0
+}
+
+func (c UserCriteria) Match(u *user.User) bool {
+	if u == nil {
+		return false
+	}
+	if c.Workspace != nil && u.Workspace() != *c.Workspace {
+		return false
+	}
+	if c.EmailDomain != "" && !strings.HasSuffix(strings.ToLower(u.Email()), "@"+strings.ToLower(c.EmailDomain)) {
+		return false
+	}
+	if c.Tag != "" && !u.HasTag(c.Tag) {
+		return false
+	}
+	for path, value := range c.Metadata {
+		if v, ok := u.Metadata().Get(path); !ok || !reflect.DeepEqual(v, value) {
+			return false
+		}
+	}
+	return true
+}
@@ -16,4 +16,12 @@ type Workspace interface {
 	SaveAll(context.Context, []*workspace.Workspace) error
 	Remove(context.Context, accountdomain.WorkspaceID) error
 	RemoveAll(context.Context, accountdomain.WorkspaceIDList) error
+	// SetDefaultRole sets the role given to users who join the workspace without a role.
+	// It returns workspace.ErrInvalidRole if the role is invalid, or rerror.ErrNotFound if the workspace does not exist.
+	SetDefaultRole(context.Context, accountdomain.WorkspaceID, workspace.Role) error
+	// DefaultRole returns the role given to users who join the workspace without a role, which is workspace.RoleReader unless it has been set.
+	DefaultRole(context.Context, accountdomain.WorkspaceID) (workspace.Role, error)
+	// DistinctRoles returns the roles assigned to at least one user in any workspace in ascending order.
+	// Roles that are no longer valid are also returned.
+	DistinctRoles(context.Context) ([]workspace.Role, error)
 }
@@ -0,0 +1,22 @@
+package accountusecase
+
+import (
+	"context"
+
+	"github.com/reearth/reearthx/account/accountdomain"
+)
+
+type impersonatorKey struct{}
+
+// ContextWithImpersonator returns a context that carries the agent who impersonates a user.
+func ContextWithImpersonator(ctx context.Context, agent accountdomain.UserID) context.Context {
+	return context.WithValue(ctx, impersonatorKey{}, agent)
+}
+
+// ImpersonatorFromContext returns the agent set by ContextWithImpersonator.
+func ImpersonatorFromContext(ctx context.Context) *accountdomain.UserID {
+	if agent, ok := ctx.Value(impersonatorKey{}).(accountdomain.UserID); ok {
+		return &agent
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package mongox
+
+import (
+	"context"
+
+	"github.com/reearth/reearthx/rerror"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// AggregateUnion runs the base pipeline on the collection and each of the union collections, and passes the combined documents to the consumer.
+// Stages that should be applied to the combined documents, such as $sort, cannot be in the base pipeline.
+func (c *Collection) AggregateUnion(ctx context.Context, basePipeline []bson.D, unionCollections []string, consumer Consumer) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	pipeline := make([]bson.D, 0, len(basePipeline)+len(unionCollections))
+	pipeline = append(pipeline, basePipeline...)
+	for _, col := range unionCollections {
+		if col == "" {
+			return rerror.ErrInvalidParams
+		}
+		pipeline = append(pipeline, unionWithStage(col, basePipeline))
+	}
+
+	cursor, err := c.client.Aggregate(ctx, pipeline)
+	if err != nil {
+		return wrapError(err)
+	}
+	defer func() {
+		_ = cursor.Close(ctx)
+	}()
+
+	return consumeCursor(ctx, cursor, consumer)
+}
+
+func unionWithStage(col string, pipeline []bson.D) bson.D {
+	if pipeline == nil {
+		pipeline = []bson.D{}
+	}
+	return bson.D{{Key: "$unionWith", Value: bson.D{
+		{Key: "coll", Value: col},
+		{Key: "pipeline", Value: pipeline},
+	}}}
+}
@@ -0,0 +1,45 @@
+package mongox
+
+import (
+	"context"
+	"testing"
+
+	"github.com/reearth/reearthx/mongox/mongotest"
+	"github.com/reearth/reearthx/rerror"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestCollection_AggregateUnion(t *testing.T) {
+	ctx := context.Background()
+	db := mongotest.Connect(t)(t)
+	c := NewCollection(db.Collection("events1"))
+	_, _ = c.Client().InsertMany(ctx, []any{
+		bson.M{"id": "a", "type": "click"},
+		bson.M{"id": "b", "type": "view"},
+	})
+	_, _ = db.Collection("events2").InsertMany(ctx, []any{
+		bson.M{"id": "c", "type": "click"},
+		bson.M{"id": "d", "type": "view"},
+	})
+
+	type event struct{ ID, Type string }
+	cons := &SliceConsumer[event]{}
+	assert.NoError(t, c.AggregateUnion(ctx, []bson.D{
+		{{Key: "$match", Value: bson.M{"type": "click"}}},
+	}, []string{"events2"}, cons))
+	assert.ElementsMatch(t, []event{{ID: "a", Type: "click"}, {ID: "c", Type: "click"}}, cons.Result)
+
+	cons = &SliceConsumer[event]{}
+	assert.NoError(t, c.AggregateUnion(ctx, nil, []string{"events2"}, cons))
+	assert.Len(t, cons.Result, 4)
+
+	assert.Same(t, rerror.ErrInvalidParams, c.AggregateUnion(ctx, nil, []string{""}, cons))
+}
+
+func TestUnionWithStage(t *testing.T) {
+	assert.Equal(t, bson.D{{Key: "$unionWith", Value: bson.D{
+		{Key: "coll", Value: "a"},
+		{Key: "pipeline", Value: []bson.D{}},
+	}}}, unionWithStage("a", nil))
+}
@@ -0,0 +1,174 @@
+package mongox
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// BulkWriteResult is the result of an unordered bulk write. Writes that failed are reported in Failures.
+type BulkWriteResult struct {
+	MatchedCount  int64
+	ModifiedCount int64
+	UpsertedCount int64
+	Failures      []BulkWriteFailure
+}
+
+type BulkWriteFailure struct {
+	// Index is the index of the write in the slice passed to the method.
+	Index   int
+	Code    int
+	Message string
+}
+
+func (r *BulkWriteResult) HasFailures() bool {
+	return r != nil && len(r.Failures) > 0
+}
+
+// SaveAllUnordered is the same as SaveAll, but the writes are not aborted at the first failure.
+func (c *Collection) SaveAllUnordered(ctx context.Context, ids []string, updates []any) (*BulkWriteResult, error) {
+	if len(ids) == 0 || len(updates) == 0 {
+		return &BulkWriteResult{}, nil
+	}
+	if len(ids) != len(updates) {
+		return nil, wrapError(errors.New("invalid save args"))
+	}
+
+	return c.bulkWriteUnordered(ctx, saveAllFilteredModels(idFilters(ids), updates))
+}
+
+// UpdateManyManyUnordered is the same as UpdateManyMany, but the writes are not aborted at the first failure.
+func (c *Collection) UpdateManyManyUnordered(ctx context.Context, updates []Update) (*BulkWriteResult, error) {
+	if len(updates) == 0 {
+		return &BulkWriteResult{}, nil
+	}
+
+	return c.bulkWriteUnordered(ctx, updateManyManyModels(updates))
+}
+
+// bulkWriteUnordered returns an error only if the bulk write itself fails. Failures of individual writes are reported in the result.
+func (c *Collection) bulkWriteUnordered(ctx context.Context, models []mongo.WriteModel) (*BulkWriteResult, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	result := &BulkWriteResult{}
+	if err := c.writeBatches(ctx, models, 0, func(offset int, models []mongo.WriteModel) error {
+		res, err := c.client.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+
+		var bwe mongo.BulkWriteException
+		if err != nil && (!errors.As(err, &bwe) || bwe.WriteConcernError != nil || len(bwe.WriteErrors) == 0) {
+			return wrapError(err)
+		}
+
+		if res != nil {
+			result.MatchedCount += res.MatchedCount
+			result.ModifiedCount += res.ModifiedCount
+			result.UpsertedCount += res.UpsertedCount
+		}
+		for _, e := range bwe.WriteErrors {
+			result.Failures = append(result.Failures, BulkWriteFailure{
+				Index:   offset + e.Index,
+				Code:    e.Code,
+				Message: e.Message,
+			})
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// FindConflicts returns the indexes of docs that would violate the unique constraints of uniqueFields when they are inserted,
+// because a document that already exists or an earlier doc in docs has the same value in any of the fields.
+// Fields may be dotted paths. Docs that do not have a field are not checked for the field. Soft-deleted documents are also checked.
+func (c *Collection) FindConflicts(ctx context.Context, uniqueFields []string, docs []any) ([]int, error) {
+	if len(uniqueFields) == 0 || len(docs) == 0 {
+		return nil, nil
+	}
+
+	raws := make([]bson.Raw, 0, len(docs))
+	values := map[string][]bson.RawValue{}
+	for _, d := range docs {
+		raw, err := bson.Marshal(d)
+		if err != nil {
+			return nil, wrapError(err)
+		}
+		raws = append(raws, raw)
+		for _, f := range uniqueFields {
+			if v, ok := lookupField(raw, f); ok {
+				values[f] = append(values[f], v)
+			}
+		}
+	}
+
+	or := make([]bson.M, 0, len(values))
+	for f, v := range values {
+		or = append(or, bson.M{f: bson.M{"$in": v}})
+	}
+	if len(or) == 0 {
+		return nil, nil
+	}
+
+	p, err := projection(uniqueFields, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var existing []bson.Raw
+	if err := c.find(ctx, bson.M{"$or": or}, FuncConsumer(func(raw bson.Raw) error {
+		if raw != nil {
+			existing = append(existing, raw)
+		}
+		return nil
+	}), options.Find().SetProjection(p)); err != nil {
+		return nil, err
+	}
+
+	return conflictingDocs(uniqueFields, existing, raws), nil
+}
+
+func conflictingDocs(fields []string, existing, docs []bson.Raw) []int {
+	seen := make(map[string]map[string]struct{}, len(fields))
+	for _, f := range fields {
+		seen[f] = map[string]struct{}{}
+		for _, e := range existing {
+			if v, ok := lookupField(e, f); ok {
+				seen[f][rawValueKey(v)] = struct{}{}
+			}
+		}
+	}
+
+	var res []int
+	for i, d := range docs {
+		conflict := false
+		for _, f := range fields {
+			v, ok := lookupField(d, f)
+			if !ok {
+				continue
+			}
+			k := rawValueKey(v)
+			if _, ok := seen[f][k]; ok {
+				conflict = true
+			}
+			seen[f][k] = struct{}{}
+		}
+		if conflict {
+			res = append(res, i)
+		}
+	}
+	return res
+}
+
+func lookupField(raw bson.Raw, field string) (bson.RawValue, bool) {
+	v, err := raw.LookupErr(strings.Split(field, ".")...)
+	return v, err == nil
+}
+
+func rawValueKey(v bson.RawValue) string {
+	return string(v.Type) + string(v.Value)
+}
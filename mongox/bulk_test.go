@@ -0,0 +1,104 @@
+package mongox
+
+import (
+	"context"
+	"testing"
+
+	"github.com/reearth/reearthx/mongox/mongotest"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestCollection_SaveAllUnordered(t *testing.T) {
+	ctx := context.Background()
+	c := NewCollection(mongotest.Connect(t)(t).Collection("test"))
+	_, err := c.EnsureIndexes(ctx, []string{"id", "name"}, nil)
+	assert.NoError(t, err)
+	_, _ = c.Client().InsertOne(ctx, bson.M{"id": "x", "name": "dup"})
+
+	res, err := c.SaveAllUnordered(ctx, []string{"a", "b", "c"}, []any{
+		bson.M{"id": "a", "name": "a"},
+		bson.M{"id": "b", "name": "dup"}, // violates the unique index
+		bson.M{"id": "c", "name": "c"},
+	})
+	assert.NoError(t, err)
+	assert.True(t, res.HasFailures())
+	assert.Equal(t, int64(2), res.UpsertedCount)
+	assert.Len(t, res.Failures, 1)
+	assert.Equal(t, 1, res.Failures[0].Index)
+	assert.Equal(t, 11000, res.Failures[0].Code)
+
+	got, err := c.Count(ctx, bson.M{"id": bson.M{"$in": []string{"a", "c"}}})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), got)
+
+	res, err = c.SaveAllUnordered(ctx, nil, nil)
+	assert.NoError(t, err)
+	assert.False(t, res.HasFailures())
+}
+
+func TestCollection_UpdateManyManyUnordered(t *testing.T) {
+	ctx := context.Background()
+	c := NewCollection(mongotest.Connect(t)(t).Collection("test"))
+	_, _ = c.Client().InsertMany(ctx, []any{
+		bson.M{"id": "a", "n": 1},
+		bson.M{"id": "b", "n": 1},
+	})
+
+	res, err := c.UpdateManyManyUnordered(ctx, []Update{
+		{Filter: bson.M{"id": "a"}, Update: bson.M{"n": 2}},
+		{Filter: bson.M{"id": "b"}, Update: bson.M{"$invalid": 2}},
+		{Filter: bson.M{"id": "b"}, Update: bson.M{"n": 3}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), res.ModifiedCount)
+	assert.Len(t, res.Failures, 1)
+	assert.Equal(t, 1, res.Failures[0].Index)
+}
+
+func TestBulkWriteResult_HasFailures(t *testing.T) {
+	assert.False(t, (*BulkWriteResult)(nil).HasFailures())
+	assert.False(t, (&BulkWriteResult{}).HasFailures())
+	assert.True(t, (&BulkWriteResult{Failures: []BulkWriteFailure{{}}}).HasFailures())
+}
+
+func TestCollection_FindConflicts(t *testing.T) {
+	ctx := context.Background()
+	c := NewCollection(mongotest.Connect(t)(t).Collection("test"))
+	_, _ = c.Client().InsertMany(ctx, []any{
+		bson.M{"id": "x", "email": "x@example.com", "profile": bson.M{"code": "X"}},
+		bson.M{"id": "y", "email": "y@example.com", "deleted": true},
+	})
+
+	got, err := c.FindConflicts(ctx, []string{"id", "email", "profile.code"}, []any{
+		bson.M{"id": "a", "email": "a@example.com"},
+		bson.M{"id": "b", "email": "y@example.com"},       // conflicts with a soft-deleted document
+		bson.M{"id": "c", "profile": bson.M{"code": "X"}}, // conflicts by the nested field
+		bson.M{"id": "a", "email": "d@example.com"},       // conflicts with the first doc
+		bson.M{"id": "e", "email": "e@example.com"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 3}, got)
+
+	got, err = c.FindConflicts(ctx, nil, []any{bson.M{"id": "x"}})
+	assert.NoError(t, err)
+	assert.Nil(t, got)
+}
+
+func TestConflictingDocs(t *testing.T) {
+	raw := func(d bson.M) bson.Raw {
+		r, _ := bson.Marshal(d)
+		return r
+	}
+
+	existing := []bson.Raw{raw(bson.M{"id": "x", "n": int32(1)})}
+	docs := []bson.Raw{
+		raw(bson.M{"id": "a", "n": int32(2)}),
+		raw(bson.M{"id": "x"}),
+		raw(bson.M{"id": "b", "n": "1"}), // a different type is not the same value
+		raw(bson.M{"id": "c", "n": int32(2)}),
+		raw(bson.M{"n": int32(3)}),
+	}
+	assert.Equal(t, []int{1, 3}, conflictingDocs([]string{"id", "n"}, existing, docs))
+	assert.Nil(t, conflictingDocs([]string{"id"}, nil, nil))
+}
@@ -5,33 +5,104 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"strings"
+	"time"
 
+	"github.com/reearth/reearthx/i18n"
 	"github.com/reearth/reearthx/rerror"
 	"github.com/reearth/reearthx/usecasex"
+	"github.com/samber/lo"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readconcern"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
 )
 
 const idKey = "id"
+const versionKey = "__v"
+
+// DefaultBatchSize is the maximum number of operations in a bulk write issued by SaveAll.
+const DefaultBatchSize = 1000
+
+// ErrVersionConflict is returned when the document has been changed since it was read.
+var ErrVersionConflict = rerror.NewE(i18n.T("version conflict"))
+
+// ErrDuplicateKey is returned by writes of a collection with SetDuplicateKeyError enabled when they violate a unique index.
+var ErrDuplicateKey = rerror.NewE(i18n.T("duplicate key"))
 
 var findOptions = []*options.FindOptions{
 	options.Find().SetAllowDiskUse(true),
 }
 
 type Collection struct {
-	client *mongo.Collection
+	client       *mongo.Collection
+	timeout      time.Duration
+	softDelete   string
+	throttle     *writeThrottle
+	duplicateKey bool
+	decoders     *decoders
 }
 
 func NewCollection(c *mongo.Collection) *Collection {
-	return &Collection{client: c}
+	return &Collection{client: c, decoders: newDecoders()}
+}
+
+// NewCollectionWithTimeout returns a Collection whose operations time out after the duration
+// when the context passed to them has no deadline. Index operations and IterateResumable are not affected.
+func NewCollectionWithTimeout(c *mongo.Collection, timeout time.Duration) *Collection {
+	return &Collection{client: c, timeout: timeout, decoders: newDecoders()}
 }
 
 func (c *Collection) Client() *mongo.Collection {
 	return c.client
 }
 
+// SetSoftDelete enables soft delete with the boolean field. RemoveOne and RemoveAll set the field to true instead of deleting documents,
+// and Find, FindOne, Count and pagination ignore documents whose field is true. An empty field disables soft delete.
+func (c *Collection) SetSoftDelete(field string) {
+	c.softDelete = field
+}
+
+// WithWriteConcern returns a copy of the collection whose writes use the write concern, e.g. writeconcern.New(writeconcern.WMajority()).
+// The original collection keeps using its write concern.
+func (c *Collection) WithWriteConcern(wc *writeconcern.WriteConcern) *Collection {
+	return c.clone(options.Collection().SetWriteConcern(wc))
+}
+
+// WithReadConcern returns a copy of the collection whose reads use the read concern, e.g. readconcern.Majority().
+// The original collection keeps using its read concern.
+func (c *Collection) WithReadConcern(rc *readconcern.ReadConcern) *Collection {
+	return c.clone(options.Collection().SetReadConcern(rc))
+}
+
+func (c *Collection) clone(opts *options.CollectionOptions) *Collection {
+	c2 := *c
+	// Clone never returns an error
+	c2.client, _ = c.client.Clone(opts)
+	c2.decoders = c.decoders.clone()
+	return &c2
+}
+
+// SetDuplicateKeyError makes ReplaceOne, SaveOne, SetOne, RawUpdateOne, UpdateOne, UpdateOneArrayFilters and SaveAll and its variants
+// return ErrDuplicateKey instead of an internal error when they violate a unique index.
+func (c *Collection) SetDuplicateKeyError(enabled bool) {
+	c.duplicateKey = enabled
+}
+
 func (c *Collection) Find(ctx context.Context, filter any, consumer Consumer, options ...*options.FindOptions) error {
+	return c.find(ctx, c.notDeletedFilter(filter), consumer, options...)
+}
+
+// FindIncludingDeleted is the same as Find but also finds soft-deleted documents.
+func (c *Collection) FindIncludingDeleted(ctx context.Context, filter any, consumer Consumer, options ...*options.FindOptions) error {
+	return c.find(ctx, filter, consumer, options...)
+}
+
+func (c *Collection) find(ctx context.Context, filter any, consumer Consumer, options ...*options.FindOptions) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
 	cursor, err := c.client.Find(ctx, filter, append(findOptions, options...)...)
 	if errors.Is(err, mongo.ErrNilDocument) || errors.Is(err, mongo.ErrNoDocuments) {
 		return rerror.ErrNotFound
@@ -43,6 +114,12 @@ func (c *Collection) Find(ctx context.Context, filter any, consumer Consumer, op
 		_ = cursor.Close(ctx)
 	}()
 
+	return consumeCursor(ctx, cursor, consumer)
+}
+
+// consumeCursor passes all documents of the cursor and then the terminal nil to the consumer.
+// It stops when the consumer returns io.EOF.
+func consumeCursor(ctx context.Context, cursor *mongo.Cursor, consumer Consumer) error {
 	for {
 		c := cursor.Next(ctx)
 		if err := cursor.Err(); err != nil && !errors.Is(err, io.EOF) {
@@ -57,6 +134,10 @@ func (c *Collection) Find(ctx context.Context, filter any, consumer Consumer, op
 		}
 
 		if err := consumer.Consume(cursor.Current); err != nil {
+			if errors.Is(err, io.EOF) {
+				// the consumer does not need more documents
+				break
+			}
 			return err
 		}
 	}
@@ -64,7 +145,10 @@ func (c *Collection) Find(ctx context.Context, filter any, consumer Consumer, op
 }
 
 func (c *Collection) FindOne(ctx context.Context, filter any, consumer Consumer, options ...*options.FindOneOptions) error {
-	raw, err := c.client.FindOne(ctx, filter, options...).DecodeBytes()
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	raw, err := c.client.FindOne(ctx, c.notDeletedFilter(filter), options...).DecodeBytes()
 	if err != nil {
 		if errors.Is(err, mongo.ErrNilDocument) || errors.Is(err, mongo.ErrNoDocuments) {
 			return rerror.ErrNotFound
@@ -77,15 +161,160 @@ func (c *Collection) FindOne(ctx context.Context, filter any, consumer Consumer,
 	return nil
 }
 
+// FindWithProjection finds documents returning only the included fields or all fields except the excluded fields.
+// Include and exclude cannot be specified at the same time.
+func (c *Collection) FindWithProjection(ctx context.Context, filter any, include, exclude []string, consumer Consumer) error {
+	p, err := projection(include, exclude)
+	if err != nil {
+		return err
+	}
+	return c.Find(ctx, filter, consumer, options.Find().SetProjection(p))
+}
+
+// FindOneWithProjection is the same as FindWithProjection but finds only one document.
+func (c *Collection) FindOneWithProjection(ctx context.Context, filter any, include, exclude []string, consumer Consumer) error {
+	p, err := projection(include, exclude)
+	if err != nil {
+		return err
+	}
+	return c.FindOne(ctx, filter, consumer, options.FindOne().SetProjection(p))
+}
+
+// FindOneAndUpdate sets fields of a document atomically and passes the updated document to the consumer.
+// The document before the update can be obtained with options.FindOneAndUpdate().SetReturnDocument(options.Before).
+func (c *Collection) FindOneAndUpdate(ctx context.Context, filter, update any, consumer Consumer, opts ...*options.FindOneAndUpdateOptions) error {
+	return c.RawFindOneAndUpdate(ctx, filter, bson.M{"$set": update}, consumer, opts...)
+}
+
+// RawFindOneAndUpdate is the same as FindOneAndUpdate, but the update document is passed to the driver as it is.
+func (c *Collection) RawFindOneAndUpdate(ctx context.Context, filter, update any, consumer Consumer, opts ...*options.FindOneAndUpdateOptions) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	o := options.MergeFindOneAndUpdateOptions(
+		append([]*options.FindOneAndUpdateOptions{options.FindOneAndUpdate().SetReturnDocument(options.After)}, opts...)...,
+	)
+
+	raw, err := c.client.FindOneAndUpdate(ctx, filter, update, o).DecodeBytes()
+	if err != nil {
+		if errors.Is(err, mongo.ErrNilDocument) || errors.Is(err, mongo.ErrNoDocuments) {
+			if o.Upsert != nil && *o.Upsert {
+				// a new document was inserted but the previous one does not exist
+				return nil
+			}
+			return rerror.ErrNotFound
+		}
+		return wrapError(err)
+	}
+	if err := consumer.Consume(raw); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	return nil
+}
+
+// Count counts documents matched by the filter.
+// If the filter is empty, CountEstimated is used instead, so the result may be inaccurate.
+// It is not used with a session, as the estimated count is not allowed in transactions.
 func (c *Collection) Count(ctx context.Context, filter any) (int64, error) {
-	count, err := c.client.CountDocuments(ctx, filter)
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	if c.softDelete == "" && isEmptyFilter(filter) && mongo.SessionFromContext(ctx) == nil {
+		return c.CountEstimated(ctx)
+	}
+
+	count, err := c.client.CountDocuments(ctx, c.notDeletedFilter(filter))
+	if err != nil {
+		return 0, wrapError(err)
+	}
+	return count, nil
+}
+
+// CountEstimated returns the number of all documents in the collection from the collection metadata without scanning documents.
+// It is much faster than Count on large collections, but it may be inaccurate, e.g. after an unclean shutdown or while orphaned documents exist in sharded clusters.
+func (c *Collection) CountEstimated(ctx context.Context) (int64, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	count, err := c.client.EstimatedDocumentCount(ctx)
 	if err != nil {
 		return 0, wrapError(err)
 	}
 	return count, nil
 }
 
+// CountByField counts documents grouped by the value of the field. Documents without the field are counted with the empty key.
+func (c *Collection) CountByField(ctx context.Context, field string, filter any) (map[string]int64, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	if field == "" {
+		return nil, rerror.ErrInvalidParams
+	}
+	if filter == nil {
+		filter = bson.M{}
+	}
+
+	cursor, err := c.client.Aggregate(ctx, []bson.M{
+		{"$match": filter},
+		{"$group": bson.M{"_id": "$" + field, "count": bson.M{"$sum": 1}}},
+	})
+	if err != nil {
+		return nil, wrapError(err)
+	}
+
+	var rows []struct {
+		ID    any   `bson:"_id"`
+		Count int64 `bson:"count"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, wrapError(err)
+	}
+
+	res := make(map[string]int64, len(rows))
+	for _, r := range rows {
+		key := ""
+		if r.ID != nil {
+			key = fmt.Sprint(r.ID)
+		}
+		res[key] += r.Count
+	}
+	return res, nil
+}
+
+func (c *Collection) Distinct(ctx context.Context, field string, filter any) ([]any, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	if field == "" {
+		return nil, rerror.ErrInvalidParams
+	}
+	if filter == nil {
+		filter = bson.M{}
+	}
+
+	res, err := c.client.Distinct(ctx, field, filter)
+	if err != nil {
+		return nil, wrapError(err)
+	}
+	if res == nil {
+		return []any{}, nil
+	}
+	return res, nil
+}
+
 func (c *Collection) RemoveAll(ctx context.Context, f any) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	if c.softDelete != "" {
+		_, err := c.client.UpdateMany(ctx, c.notDeletedFilter(f), c.softDeleteUpdate())
+		if err != nil {
+			return wrapError(err)
+		}
+		return nil
+	}
+
 	_, err := c.client.DeleteMany(ctx, f)
 	if err != nil {
 		return wrapError(err)
@@ -93,7 +322,28 @@ func (c *Collection) RemoveAll(ctx context.Context, f any) error {
 	return nil
 }
 
+func (c *Collection) RemoveAllByIDs(ctx context.Context, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return c.RemoveAll(ctx, bson.M{idKey: bson.M{"$in": ids}})
+}
+
 func (c *Collection) RemoveOne(ctx context.Context, f any) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	if c.softDelete != "" {
+		res, err := c.client.UpdateOne(ctx, c.notDeletedFilter(f), c.softDeleteUpdate())
+		if err != nil {
+			return wrapError(err)
+		}
+		if res != nil && res.MatchedCount == 0 {
+			return rerror.ErrNotFound
+		}
+		return nil
+	}
+
 	res, err := c.client.DeleteOne(ctx, f)
 	if err != nil {
 		return wrapError(err)
@@ -104,11 +354,62 @@ func (c *Collection) RemoveOne(ctx context.Context, f any) error {
 	return nil
 }
 
+// RemoveOneIfMatch removes the document only when it still has the expected field values.
+// It returns ErrVersionConflict if the document exists but does not match, or rerror.ErrNotFound if the document does not exist.
+func (c *Collection) RemoveOneIfMatch(ctx context.Context, id string, expected bson.M) error {
+	filter := bson.M{}
+	for k, v := range expected {
+		filter[k] = v
+	}
+	filter[idKey] = id
+
+	err := c.RemoveOne(ctx, filter)
+	if !errors.Is(err, rerror.ErrNotFound) {
+		return err
+	}
+
+	count, err := c.Count(ctx, bson.M{idKey: id})
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrVersionConflict
+	}
+	return rerror.ErrNotFound
+}
+
+// InsertIdempotent inserts the document whose id is generated by the client. If a document with the same id already exists,
+// e.g. when the insert is retried, it returns false without an error. The collection should have a unique index on the id field.
+func (c *Collection) InsertIdempotent(ctx context.Context, id string, doc any) (bool, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	if _, err := c.client.InsertOne(ctx, doc); err != nil {
+		if !mongo.IsDuplicateKeyError(err) {
+			return false, wrapError(err)
+		}
+
+		// the duplicated key may be other than id
+		count, err2 := c.client.CountDocuments(ctx, bson.M{idKey: id})
+		if err2 != nil {
+			return false, wrapError(err2)
+		}
+		if count == 0 {
+			return false, wrapError(err)
+		}
+		return false, nil
+	}
+	return true, nil
+}
+
 func (c *Collection) SaveOne(ctx context.Context, id string, replacement any) error {
 	return c.ReplaceOne(ctx, bson.M{idKey: id}, replacement)
 }
 
 func (c *Collection) ReplaceOne(ctx context.Context, filter any, replacement any) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
 	_, err := c.client.ReplaceOne(
 		ctx,
 		filter,
@@ -116,12 +417,74 @@ func (c *Collection) ReplaceOne(ctx context.Context, filter any, replacement any
 		options.Replace().SetUpsert(true),
 	)
 	if err != nil {
+		return c.wrapWriteError(err)
+	}
+	return nil
+}
+
+// ReplaceOneNoUpsert is the same as ReplaceOne, but it does not insert the replacement and returns rerror.ErrNotFound if no document matched.
+func (c *Collection) ReplaceOneNoUpsert(ctx context.Context, filter any, replacement any) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	res, err := c.client.ReplaceOne(ctx, filter, replacement)
+	if err != nil {
+		return c.wrapWriteError(err)
+	}
+	if res != nil && res.MatchedCount == 0 {
+		return rerror.ErrNotFound
+	}
+	return nil
+}
+
+// SaveOneWithVersion replaces the document only when its version field "__v" equals expectedVersion, and increments the version.
+// A document without the version field is treated as version 0, and it is inserted if it does not exist and expectedVersion is 0.
+// It returns ErrVersionConflict if the document has been saved with another version, or rerror.ErrNotFound if it does not exist.
+func (c *Collection) SaveOneWithVersion(ctx context.Context, id string, replacement any, expectedVersion int) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	doc, err := versionedDocument(replacement, expectedVersion+1)
+	if err != nil {
+		return rerror.ErrInternalBy(err)
+	}
+
+	var versionFilter any = expectedVersion
+	if expectedVersion == 0 {
+		versionFilter = bson.M{"$in": bson.A{0, nil}}
+	}
+	res, err := c.client.ReplaceOne(ctx, bson.M{idKey: id, versionKey: versionFilter}, doc)
+	if err != nil {
+		return wrapError(err)
+	}
+	if res != nil && res.MatchedCount > 0 {
+		return nil
+	}
+
+	count, err := c.client.CountDocuments(ctx, bson.M{idKey: id})
+	if err != nil {
+		return wrapError(err)
+	}
+	if count > 0 {
+		return ErrVersionConflict
+	}
+	if expectedVersion != 0 {
+		return rerror.ErrNotFound
+	}
+
+	if _, err := c.client.InsertOne(ctx, doc); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrVersionConflict
+		}
 		return wrapError(err)
 	}
 	return nil
 }
 
 func (c *Collection) SetOne(ctx context.Context, id string, replacement any) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
 	_, err := c.client.UpdateOne(
 		ctx,
 		bson.M{idKey: id},
@@ -129,41 +492,128 @@ func (c *Collection) SetOne(ctx context.Context, id string, replacement any) err
 		options.Update().SetUpsert(true),
 	)
 	if err != nil {
-		return wrapError(err)
+		return c.wrapWriteError(err)
 	}
 	return nil
 }
 
 func (c *Collection) SaveAll(ctx context.Context, ids []string, updates []any) error {
+	return c.SaveAllBatched(ctx, ids, updates, DefaultBatchSize)
+}
+
+// SaveAllBatched is the same as SaveAll, but splits writes into bulk writes of at most batchSize operations.
+// If batchSize is not positive, DefaultBatchSize is used.
+func (c *Collection) SaveAllBatched(ctx context.Context, ids []string, updates []any, batchSize int) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
 	if len(ids) == 0 || len(updates) == 0 {
 		return nil
 	}
 	if len(ids) != len(updates) {
 		return wrapError(errors.New("invalid save args"))
 	}
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+
+	return c.writeBatches(ctx, saveAllFilteredModels(idFilters(ids), updates), batchSize, func(_ int, models []mongo.WriteModel) error {
+		if _, err := c.client.BulkWrite(ctx, models); err != nil {
+			return c.wrapWriteError(err)
+		}
+		return nil
+	})
+}
+
+// SaveAllFiltered upserts documents with a single bulk write, replacing the document matched by each filter.
+// The bulk write is split into batches if the write rate limit is set.
+func (c *Collection) SaveAllFiltered(ctx context.Context, filters []any, updates []any) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	if len(filters) == 0 || len(updates) == 0 {
+		return nil
+	}
+	if len(filters) != len(updates) {
+		return wrapError(errors.New("invalid save args"))
+	}
 
-	writeModels := make([]mongo.WriteModel, 0, len(updates))
-	for i, u := range updates {
-		id := ids[i]
-		writeModels = append(
-			writeModels,
-			mongo.NewReplaceOneModel().SetFilter(bson.M{idKey: id}).SetReplacement(u).SetUpsert(true),
-		)
+	return c.writeBatches(ctx, saveAllFilteredModels(filters, updates), 0, func(_ int, models []mongo.WriteModel) error {
+		if _, err := c.client.BulkWrite(ctx, models); err != nil {
+			return c.wrapWriteError(err)
+		}
+		return nil
+	})
+}
+
+func (c *Collection) UpdateMany(ctx context.Context, filter, update any) error {
+	return c.RawUpdateMany(ctx, filter, bson.M{
+		"$set": update,
+	})
+}
+
+// RawUpdateOne updates a document matched by the filter with the update document as is, so any update operators such as $inc and $push can be used.
+// It returns rerror.ErrNotFound if no document matched.
+func (c *Collection) RawUpdateOne(ctx context.Context, filter any, rawUpdate bson.M) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	res, err := c.client.UpdateOne(ctx, filter, rawUpdate)
+	if err != nil {
+		return c.wrapWriteError(err)
 	}
+	if res != nil && res.MatchedCount == 0 {
+		return rerror.ErrNotFound
+	}
+	return nil
+}
 
-	_, err := c.client.BulkWrite(ctx, writeModels)
+// RawUpdateMany is the same as RawUpdateOne, but updates all documents matched by the filter. It does not return an error even if no document matched.
+func (c *Collection) RawUpdateMany(ctx context.Context, filter any, rawUpdate bson.M) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	if err := c.throttle.wait(ctx, 1); err != nil {
+		return err
+	}
+	_, err := c.client.UpdateMany(ctx, filter, rawUpdate)
 	if err != nil {
 		return wrapError(err)
 	}
 	return nil
 }
 
-func (c *Collection) UpdateMany(ctx context.Context, filter, update any) error {
-	_, err := c.client.UpdateMany(ctx, filter, bson.M{
-		"$set": update,
+// UpdateOneArrayFilters sets fields of a document matched by the filter. The update can target array elements by identifiers
+// such as "items.$[elem].status" with array filters such as bson.M{"elem.id": "x"}.
+func (c *Collection) UpdateOneArrayFilters(ctx context.Context, filter, update any, arrayFilters []any) error {
+	return c.UpdateOne(ctx, Update{
+		Filter:       filter,
+		Update:       update,
+		ArrayFilters: arrayFilters,
 	})
+}
+
+// UpdateOne sets fields of a document matched by the filter of the update, using its array filters if any.
+// If Upsert is true, a document is inserted when no document matched, or else rerror.ErrNotFound is returned.
+func (c *Collection) UpdateOne(ctx context.Context, u Update) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	o := options.Update().SetUpsert(u.Upsert)
+	if len(u.ArrayFilters) > 0 {
+		o.SetArrayFilters(options.ArrayFilters{
+			Filters: u.ArrayFilters,
+		})
+	}
+
+	res, err := c.client.UpdateOne(ctx, u.Filter, bson.M{
+		"$set": u.Update,
+	}, o)
 	if err != nil {
-		return wrapError(err)
+		return c.wrapWriteError(err)
+	}
+	if !u.Upsert && res != nil && res.MatchedCount == 0 {
+		return rerror.ErrNotFound
 	}
 	return nil
 }
@@ -172,14 +622,64 @@ type Update struct {
 	Filter       any
 	Update       any
 	ArrayFilters []any
+	// Upsert inserts a document if no document matched the filter.
+	Upsert bool
 }
 
 func (c *Collection) UpdateManyMany(ctx context.Context, updates []Update) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	return c.writeBatches(ctx, updateManyManyModels(updates), 0, func(_ int, models []mongo.WriteModel) error {
+		if _, err := c.client.BulkWrite(ctx, models); err != nil {
+			return wrapError(err)
+		}
+		return nil
+	})
+}
+
+func projection(include, exclude []string) (bson.M, error) {
+	if len(include) > 0 && len(exclude) > 0 {
+		return nil, rerror.ErrInvalidParams
+	}
+	p := bson.M{}
+	for _, f := range include {
+		p[f] = 1
+	}
+	for _, f := range exclude {
+		p[f] = 0
+	}
+	return p, nil
+}
+
+func idFilters(ids []string) []any {
+	filters := make([]any, 0, len(ids))
+	for _, id := range ids {
+		filters = append(filters, bson.M{idKey: id})
+	}
+	return filters
+}
+
+func saveAllFilteredModels(filters []any, updates []any) []mongo.WriteModel {
+	writeModels := make([]mongo.WriteModel, 0, len(updates))
+	for i, u := range updates {
+		writeModels = append(
+			writeModels,
+			mongo.NewReplaceOneModel().SetFilter(filters[i]).SetReplacement(u).SetUpsert(true),
+		)
+	}
+	return writeModels
+}
+
+func updateManyManyModels(updates []Update) []mongo.WriteModel {
 	writeModels := make([]mongo.WriteModel, 0, len(updates))
 	for _, w := range updates {
 		wm := mongo.NewUpdateManyModel().SetFilter(w.Filter).SetUpdate(bson.M{
 			"$set": w.Update,
 		})
+		if w.Upsert {
+			wm.SetUpsert(true)
+		}
 		if len(w.ArrayFilters) > 0 {
 			wm.SetArrayFilters(options.ArrayFilters{
 				Filters: w.ArrayFilters,
@@ -187,15 +687,51 @@ func (c *Collection) UpdateManyMany(ctx context.Context, updates []Update) error
 		}
 		writeModels = append(writeModels, wm)
 	}
+	return writeModels
+}
 
-	_, err := c.client.BulkWrite(ctx, writeModels)
+func versionedDocument(d any, version int) (bson.D, error) {
+	b, err := bson.Marshal(d)
 	if err != nil {
-		return wrapError(err)
+		return nil, err
 	}
-	return nil
+	var doc bson.D
+	if err := bson.Unmarshal(b, &doc); err != nil {
+		return nil, err
+	}
+	doc = lo.Filter(doc, func(e bson.E, _ int) bool { return e.Key != versionKey })
+	return append(doc, bson.E{Key: versionKey, Value: version}), nil
+}
+
+func (c *Collection) notDeletedFilter(filter any) any {
+	if c.softDelete == "" {
+		return filter
+	}
+	f := bson.M{c.softDelete: bson.M{"$ne": true}}
+	if isEmptyFilter(filter) {
+		return f
+	}
+	return bson.M{"$and": []any{filter, f}}
+}
+
+func (c *Collection) softDeleteUpdate() bson.M {
+	return bson.M{"$set": bson.M{c.softDelete: true}}
 }
 
-func getCursor(raw bson.Raw) (*usecasex.Cursor, error) {
+// withTimeout applies the default timeout of the collection only when ctx has no deadline,
+// so it never shortens or extends a deadline set by the caller.
+func (c *Collection) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.timeout <= 0 {
+		return ctx, func() {}
+	}
+	if _, ok := ctx.Deadline(); ok {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, c.timeout)
+}
+
+// getCursor returns the id of the document as a cursor. If sortKey is specified, it returns a composite cursor that also has the value of the sort key.
+func getCursor(raw bson.Raw, sortKey *string) (*usecasex.Cursor, error) {
 	val, err := raw.LookupErr(idKey)
 	if err != nil {
 		return nil, fmt.Errorf("failed to lookup cursor: %v", err.Error())
@@ -204,10 +740,35 @@ func getCursor(raw bson.Raw) (*usecasex.Cursor, error) {
 	if err := val.Unmarshal(&s); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal cursor: %v", err.Error())
 	}
-	c := usecasex.Cursor(s)
+
+	if sortKey == nil || *sortKey == "" {
+		c := usecasex.Cursor(s)
+		return &c, nil
+	}
+
+	v, err := raw.LookupErr(strings.Split(*sortKey, ".")...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lookup sort key: %v", err.Error())
+	}
+	c, err := usecasex.CompositeCursor{Value: v, ID: s}.Encode()
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode cursor: %v", err.Error())
+	}
 	return &c, nil
 }
 
+// IsDuplicateKey returns true if the error is ErrDuplicateKey or an error of the server caused by a violation of a unique index.
+func IsDuplicateKey(err error) bool {
+	return errors.Is(err, ErrDuplicateKey) || mongo.IsDuplicateKeyError(err)
+}
+
+func (c *Collection) wrapWriteError(err error) error {
+	if c.duplicateKey && mongo.IsDuplicateKeyError(err) {
+		return ErrDuplicateKey
+	}
+	return wrapError(err)
+}
+
 func wrapError(err error) error {
 	if IsTransactionError(err) {
 		return usecasex.ErrTransaction
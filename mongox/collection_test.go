@@ -0,0 +1,710 @@
+package mongox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/reearth/reearthx/mongox/mongotest"
+	"github.com/reearth/reearthx/rerror"
+	"github.com/reearth/reearthx/usecasex"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readconcern"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+)
+
+func TestCollection_FindOneAndUpdate(t *testing.T) {
+	ctx := context.Background()
+	c := NewCollection(mongotest.Connect(t)(t).Collection("test"))
+	_, _ = c.Client().InsertOne(ctx, bson.M{"id": "a", "n": 1})
+
+	type d struct {
+		ID string `bson:"id"`
+		N  int    `bson:"n"`
+	}
+
+	// update existing
+	con := &SliceConsumer[d]{}
+	assert.NoError(t, c.FindOneAndUpdate(ctx, bson.M{"id": "a"}, bson.M{"n": 2}, con))
+	assert.Equal(t, []d{{ID: "a", N: 2}}, con.Result)
+
+	// return the document before the update
+	con = &SliceConsumer[d]{}
+	assert.NoError(t, c.FindOneAndUpdate(ctx, bson.M{"id": "a"}, bson.M{"n": 3}, con, options.FindOneAndUpdate().SetReturnDocument(options.Before)))
+	assert.Equal(t, []d{{ID: "a", N: 2}}, con.Result)
+
+	// not found
+	con = &SliceConsumer[d]{}
+	assert.Same(t, rerror.ErrNotFound, c.FindOneAndUpdate(ctx, bson.M{"id": "b"}, bson.M{"n": 1}, con))
+	assert.Empty(t, con.Result)
+
+	// upsert new
+	con = &SliceConsumer[d]{}
+	assert.NoError(t, c.FindOneAndUpdate(ctx, bson.M{"id": "b"}, bson.M{"n": 1}, con, options.FindOneAndUpdate().SetUpsert(true)))
+	assert.Equal(t, []d{{ID: "b", N: 1}}, con.Result)
+
+	// upsert new and return the document before the update
+	con = &SliceConsumer[d]{}
+	assert.NoError(t, c.FindOneAndUpdate(ctx, bson.M{"id": "c"}, bson.M{"n": 1}, con, options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.Before)))
+	assert.Empty(t, con.Result)
+}
+
+func TestCollection_ReadOwnWritesWithSession(t *testing.T) {
+	ctx, db := mongotest.ConnectWithSession(t)(t)
+	c := NewCollection(db.Collection("test"))
+
+	assert.NoError(t, c.SaveOne(ctx, "a", bson.M{"id": "a", "n": 1}))
+	assert.NoError(t, c.SetOne(ctx, "a", bson.M{"n": 2}))
+
+	consumer := &OneConsumer[bson.M]{}
+	assert.NoError(t, c.FindOne(ctx, bson.M{"id": "a"}, consumer))
+	got, err := consumer.Result()
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), got["n"])
+}
+
+func TestCollection_RawFindOneAndUpdate(t *testing.T) {
+	ctx := context.Background()
+	c := NewCollection(mongotest.Connect(t)(t).Collection("test"))
+	_, _ = c.Client().InsertOne(ctx, bson.M{"id": "a", "n": 1})
+
+	type d struct {
+		ID string `bson:"id"`
+		N  int    `bson:"n"`
+	}
+
+	con := &SliceConsumer[d]{}
+	assert.NoError(t, c.RawFindOneAndUpdate(ctx, bson.M{"id": "a"}, bson.M{"$inc": bson.M{"n": 1}}, con))
+	assert.Equal(t, []d{{ID: "a", N: 2}}, con.Result)
+}
+
+func TestCollection_Distinct(t *testing.T) {
+	ctx := context.Background()
+	c := NewCollection(mongotest.Connect(t)(t).Collection("test"))
+	_, _ = c.Client().InsertMany(ctx, []any{
+		bson.M{"id": "a", "profile": bson.M{"country": "jp"}},
+		bson.M{"id": "b", "profile": bson.M{"country": "us"}},
+		bson.M{"id": "c", "profile": bson.M{"country": "jp"}},
+	})
+
+	got, err := c.Distinct(ctx, "profile.country", nil)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []any{"jp", "us"}, got)
+
+	got, err = c.Distinct(ctx, "profile.country", bson.M{"id": "b"})
+	assert.NoError(t, err)
+	assert.Equal(t, []any{"us"}, got)
+
+	got, err = c.Distinct(ctx, "profile.country", bson.M{"id": "x"})
+	assert.NoError(t, err)
+	assert.Equal(t, []any{}, got)
+
+	got, err = c.Distinct(ctx, "", nil)
+	assert.Same(t, rerror.ErrInvalidParams, err)
+	assert.Nil(t, got)
+}
+
+func TestCollection_SaveAllFiltered(t *testing.T) {
+	ctx := context.Background()
+	c := NewCollection(mongotest.Connect(t)(t).Collection("test"))
+	_, _ = c.Client().InsertOne(ctx, bson.M{"a": "1", "b": "1", "v": 1})
+
+	type d struct {
+		A string `bson:"a"`
+		B string `bson:"b"`
+		V int    `bson:"v"`
+	}
+
+	assert.NoError(t, c.SaveAllFiltered(ctx, []any{
+		bson.M{"a": "1", "b": "1"},
+		bson.M{"a": "1", "b": "2"},
+	}, []any{
+		d{A: "1", B: "1", V: 2},
+		d{A: "1", B: "2", V: 3},
+	}))
+
+	con := &SliceConsumer[d]{}
+	assert.NoError(t, c.Find(ctx, bson.M{}, con, options.Find().SetSort(bson.M{"b": 1}).SetProjection(bson.M{"_id": 0})))
+	assert.Equal(t, []d{{A: "1", B: "1", V: 2}, {A: "1", B: "2", V: 3}}, con.Result)
+
+	assert.EqualError(t, rerror.UnwrapErrInternal(c.SaveAllFiltered(ctx, []any{bson.M{"a": "1"}}, []any{d{}, d{}})), "invalid save args")
+	assert.NoError(t, c.SaveAllFiltered(ctx, nil, nil))
+}
+
+func TestCollection_CountByField(t *testing.T) {
+	ctx := context.Background()
+	c := NewCollection(mongotest.Connect(t)(t).Collection("test"))
+	_, _ = c.Client().InsertMany(ctx, []any{
+		bson.M{"id": "a", "role": "owner", "active": true},
+		bson.M{"id": "b", "role": "reader", "active": true},
+		bson.M{"id": "c", "role": "reader", "active": false},
+		bson.M{"id": "d", "active": true},
+	})
+
+	got, err := c.CountByField(ctx, "role", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int64{"owner": 1, "reader": 2, "": 1}, got)
+
+	got, err = c.CountByField(ctx, "role", bson.M{"active": true})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int64{"owner": 1, "reader": 1, "": 1}, got)
+
+	got, err = c.CountByField(ctx, "", nil)
+	assert.Same(t, rerror.ErrInvalidParams, err)
+	assert.Nil(t, got)
+}
+
+func TestCollection_Count(t *testing.T) {
+	ctx := context.Background()
+	c := NewCollection(mongotest.Connect(t)(t).Collection("test"))
+	_, _ = c.Client().InsertMany(ctx, []any{
+		bson.M{"id": "a", "n": 1},
+		bson.M{"id": "b", "n": 2},
+	})
+
+	got, err := c.Count(ctx, bson.M{"n": 1})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), got)
+
+	got, err = c.Count(ctx, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), got)
+
+	got, err = c.CountEstimated(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), got)
+}
+
+func TestCollection_CountEstimated(t *testing.T) {
+	ctx := context.Background()
+	c := NewCollection(mongotest.Connect(t)(t).Collection("test"))
+
+	got, err := c.CountEstimated(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), got)
+
+	n := 100
+	docs := make([]any, 0, n)
+	for i := 0; i < n; i++ {
+		docs = append(docs, bson.M{"id": fmt.Sprint(i)})
+	}
+	_, _ = c.Client().InsertMany(ctx, docs)
+
+	got, err = c.CountEstimated(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(n), got)
+}
+
+func TestCollection_RemoveAllByIDs(t *testing.T) {
+	ctx := context.Background()
+	c := NewCollection(mongotest.Connect(t)(t).Collection("test"))
+	_, _ = c.Client().InsertMany(ctx, []any{
+		bson.M{"id": "a"},
+		bson.M{"id": "b"},
+		bson.M{"id": "c"},
+	})
+
+	assert.NoError(t, c.RemoveAllByIDs(ctx, nil))
+	assert.NoError(t, c.RemoveAllByIDs(ctx, []string{"a", "c", "x"}))
+
+	got, err := c.Distinct(ctx, "id", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []any{"b"}, got)
+
+	// no documents matched
+	assert.NoError(t, c.RemoveAllByIDs(ctx, []string{"x"}))
+}
+
+func TestCollection_SaveAllBatched(t *testing.T) {
+	ctx := context.Background()
+	c := NewCollection(mongotest.Connect(t)(t).Collection("test"))
+
+	ids := make([]string, 0, 2500)
+	docs := make([]any, 0, 2500)
+	for i := 0; i < 2500; i++ {
+		id := fmt.Sprintf("%04d", i)
+		ids = append(ids, id)
+		docs = append(docs, bson.M{"id": id, "i": i})
+	}
+
+	assert.NoError(t, c.SaveAllBatched(ctx, ids, docs, 0))
+	got, err := c.Count(ctx, bson.M{"id": bson.M{"$exists": true}})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2500), got)
+
+	// update with a small batch size
+	for i := range docs {
+		docs[i] = bson.M{"id": ids[i], "i": -i}
+	}
+	assert.NoError(t, c.SaveAllBatched(ctx, ids, docs, 300))
+	got, err = c.Count(ctx, bson.M{"i": bson.M{"$lte": 0}})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2500), got)
+
+	err = c.SaveAllBatched(ctx, ids, docs[:1], 300)
+	assert.EqualError(t, rerror.UnwrapErrInternal(err), "invalid save args")
+}
+
+func TestCollection_InsertIdempotent(t *testing.T) {
+	ctx := context.Background()
+	c := NewCollection(mongotest.Connect(t)(t).Collection("test"))
+	_, err := c.EnsureIndexes(ctx, []string{"id", "email"}, nil)
+	assert.NoError(t, err)
+
+	inserted, err := c.InsertIdempotent(ctx, "a", bson.M{"id": "a", "email": "a"})
+	assert.NoError(t, err)
+	assert.True(t, inserted)
+
+	// retry
+	inserted, err = c.InsertIdempotent(ctx, "a", bson.M{"id": "a", "email": "a"})
+	assert.NoError(t, err)
+	assert.False(t, inserted)
+
+	// duplicated with another key
+	inserted, err = c.InsertIdempotent(ctx, "b", bson.M{"id": "b", "email": "a"})
+	assert.True(t, rerror.IsInternal(err))
+	assert.False(t, inserted)
+
+	got, err := c.Count(ctx, bson.M{"id": bson.M{"$in": []string{"a", "b"}}})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), got)
+}
+
+func TestCollection_withTimeout(t *testing.T) {
+	c := NewCollectionWithTimeout(nil, time.Minute)
+
+	// applied when the context has no deadline
+	ctx, cancel := c.withTimeout(context.Background())
+	deadline, ok := ctx.Deadline()
+	assert.True(t, ok)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), deadline, time.Second)
+	cancel()
+	assert.ErrorIs(t, ctx.Err(), context.Canceled)
+
+	// a tighter deadline of the caller is kept
+	parent, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	want, _ := parent.Deadline()
+	ctx, cancel2 := c.withTimeout(parent)
+	defer cancel2()
+	deadline, ok = ctx.Deadline()
+	assert.True(t, ok)
+	assert.Equal(t, want, deadline)
+
+	// a longer deadline of the caller is kept too
+	parent2, cancel3 := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel3()
+	want, _ = parent2.Deadline()
+	ctx, cancel4 := c.withTimeout(parent2)
+	defer cancel4()
+	deadline, _ = ctx.Deadline()
+	assert.Equal(t, want, deadline)
+
+	// no timeout
+	ctx, cancel5 := NewCollection(nil).withTimeout(context.Background())
+	defer cancel5()
+	_, ok = ctx.Deadline()
+	assert.False(t, ok)
+}
+
+func TestCollection_FindWithProjection(t *testing.T) {
+	ctx := context.Background()
+	c := NewCollection(mongotest.Connect(t)(t).Collection("test"))
+	_, _ = c.Client().InsertMany(ctx, []any{
+		bson.M{"id": "a", "name": "a", "age": 1},
+		bson.M{"id": "b", "name": "b", "age": 2},
+	})
+
+	cons := &SliceConsumer[bson.M]{}
+	assert.NoError(t, c.FindWithProjection(ctx, bson.M{}, []string{"id", "name"}, nil, cons))
+	assert.Equal(t, []bson.M{{"id": "a", "name": "a"}, {"id": "b", "name": "b"}}, lo.Map(cons.Result, func(m bson.M, _ int) bson.M {
+		delete(m, "_id")
+		return m
+	}))
+
+	cons2 := &SliceConsumer[bson.M]{}
+	assert.NoError(t, c.FindOneWithProjection(ctx, bson.M{"id": "b"}, nil, []string{"_id", "age"}, cons2))
+	assert.Equal(t, []bson.M{{"id": "b", "name": "b"}}, cons2.Result)
+
+	assert.Same(t, rerror.ErrInvalidParams, c.FindWithProjection(ctx, bson.M{}, []string{"id"}, []string{"age"}, cons))
+	assert.Same(t, rerror.ErrInvalidParams, c.FindOneWithProjection(ctx, bson.M{}, []string{"id"}, []string{"age"}, cons))
+}
+
+func TestProjection(t *testing.T) {
+	p, err := projection([]string{"a", "b"}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, bson.M{"a": 1, "b": 1}, p)
+
+	p, err = projection(nil, []string{"a"})
+	assert.NoError(t, err)
+	assert.Equal(t, bson.M{"a": 0}, p)
+
+	p, err = projection(nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, bson.M{}, p)
+
+	_, err = projection([]string{"a"}, []string{"b"})
+	assert.Same(t, rerror.ErrInvalidParams, err)
+}
+
+func TestCollection_SoftDelete(t *testing.T) {
+	ctx := context.Background()
+	c := NewCollection(mongotest.Connect(t)(t).Collection("test"))
+	c.SetSoftDelete("deleted")
+	_, _ = c.Client().InsertMany(ctx, []any{
+		bson.M{"id": "a"},
+		bson.M{"id": "b"},
+		bson.M{"id": "c", "deleted": false},
+		bson.M{"id": "d", "deleted": true},
+	})
+
+	assert.NoError(t, c.RemoveOne(ctx, bson.M{"id": "a"}))
+	assert.Same(t, rerror.ErrNotFound, c.RemoveOne(ctx, bson.M{"id": "a"}))
+
+	got, err := c.Count(ctx, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), got)
+
+	cons := &SliceConsumer[struct{ ID string }]{}
+	assert.NoError(t, c.Find(ctx, bson.M{}, cons))
+	assert.Equal(t, []string{"b", "c"}, lo.Map(cons.Result, func(r struct{ ID string }, _ int) string { return r.ID }))
+
+	assert.Same(t, rerror.ErrNotFound, c.FindOne(ctx, bson.M{"id": "a"}, &SliceConsumer[bson.M]{}))
+
+	cons = &SliceConsumer[struct{ ID string }]{}
+	assert.NoError(t, c.FindIncludingDeleted(ctx, bson.M{}, cons))
+	assert.Equal(t, []string{"a", "b", "c", "d"}, lo.Map(cons.Result, func(r struct{ ID string }, _ int) string { return r.ID }))
+
+	assert.NoError(t, c.RemoveAll(ctx, bson.M{}))
+	got, err = c.Count(ctx, bson.M{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), got)
+
+	// documents are not removed physically
+	got2, err := c.Client().CountDocuments(ctx, bson.M{"deleted": true})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), got2)
+}
+
+func TestCollection_notDeletedFilter(t *testing.T) {
+	c := NewCollection(nil)
+	assert.Equal(t, bson.M{"a": 1}, c.notDeletedFilter(bson.M{"a": 1}))
+	assert.Nil(t, c.notDeletedFilter(nil))
+
+	c.SetSoftDelete("deleted")
+	assert.Equal(t, bson.M{"deleted": bson.M{"$ne": true}}, c.notDeletedFilter(nil))
+	assert.Equal(t, bson.M{"deleted": bson.M{"$ne": true}}, c.notDeletedFilter(bson.M{}))
+	assert.Equal(t, bson.M{"$and": []any{bson.M{"a": 1}, bson.M{"deleted": bson.M{"$ne": true}}}}, c.notDeletedFilter(bson.M{"a": 1}))
+}
+
+func TestNewCollectionWithTimeout(t *testing.T) {
+	ctx := context.Background()
+	db := mongotest.Connect(t)(t)
+
+	c := NewCollectionWithTimeout(db.Collection("test"), time.Minute)
+	assert.NoError(t, c.SaveOne(ctx, "a", bson.M{"id": "a"}))
+	got, err := c.Count(ctx, bson.M{"id": "a"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), got)
+
+	// writes time out as well as reads
+	c = NewCollectionWithTimeout(db.Collection("test"), time.Nanosecond)
+	assert.Error(t, c.SaveOne(ctx, "b", bson.M{"id": "b"}))
+	_, err = c.Count(ctx, bson.M{"id": "a"})
+	assert.Error(t, err)
+}
+
+func TestCollection_WithConcern(t *testing.T) {
+	ctx := context.Background()
+	c := NewCollectionWithTimeout(mongotest.Connect(t)(t).Collection("test"), time.Minute)
+	c.SetSoftDelete("deleted")
+
+	w := c.WithWriteConcern(writeconcern.New(writeconcern.WMajority()))
+	r := c.WithReadConcern(readconcern.Majority())
+	assert.NotSame(t, c.Client(), w.Client())
+	assert.NotSame(t, c.Client(), r.Client())
+	assert.Equal(t, "deleted", w.softDelete)
+	assert.Equal(t, time.Minute, r.timeout)
+
+	assert.NoError(t, w.SaveOne(ctx, "a", bson.M{"id": "a"}))
+	assert.NoError(t, w.SaveOne(ctx, "b", bson.M{"id": "b", "deleted": true}))
+	got, err := r.Count(ctx, bson.M{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), got)
+}
+
+func TestCollection_Find_OneConsumer(t *testing.T) {
+	ctx := context.Background()
+	c := NewCollection(mongotest.Connect(t)(t).Collection("test"))
+	_, _ = c.Client().InsertMany(ctx, []any{
+		bson.M{"id": "a"},
+		bson.M{"id": "b"},
+	})
+
+	type d struct{ ID string }
+	cons := &OneConsumer[d]{}
+	assert.NoError(t, c.Find(ctx, bson.M{}, cons, options.Find().SetSort(bson.M{"id": -1})))
+	got, err := cons.Result()
+	assert.NoError(t, err)
+	assert.Equal(t, d{ID: "b"}, got)
+
+	cons = &OneConsumer[d]{}
+	assert.NoError(t, c.FindOne(ctx, bson.M{"id": "a"}, cons))
+	got, err = cons.Result()
+	assert.NoError(t, err)
+	assert.Equal(t, d{ID: "a"}, got)
+
+	cons = &OneConsumer[d]{}
+	assert.NoError(t, c.Find(ctx, bson.M{"id": "c"}, cons))
+	_, err = cons.Result()
+	assert.Same(t, rerror.ErrNotFound, err)
+}
+
+func TestGetCursor(t *testing.T) {
+	raw, _ := bson.Marshal(bson.M{"id": "a", "i": 1, "n": bson.M{"s": "x"}})
+
+	got, err := getCursor(raw, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, usecasex.Cursor("a"), *got)
+
+	got, err = getCursor(raw, lo.ToPtr("i"))
+	assert.NoError(t, err)
+	cc, err := usecasex.DecodeCompositeCursor(*got)
+	assert.NoError(t, err)
+	assert.Equal(t, &usecasex.CompositeCursor{Value: int32(1), ID: "a"}, cc)
+
+	got, err = getCursor(raw, lo.ToPtr("n.s"))
+	assert.NoError(t, err)
+	cc, err = usecasex.DecodeCompositeCursor(*got)
+	assert.NoError(t, err)
+	assert.Equal(t, &usecasex.CompositeCursor{Value: "x", ID: "a"}, cc)
+
+	_, err = getCursor(raw, lo.ToPtr("x"))
+	assert.Error(t, err)
+}
+
+func TestCollection_RemoveOneIfMatch(t *testing.T) {
+	ctx := context.Background()
+	c := NewCollection(mongotest.Connect(t)(t).Collection("test"))
+	_, _ = c.Client().InsertMany(ctx, []any{
+		bson.M{"id": "a", "state": "done", "v": 2},
+		bson.M{"id": "b", "state": "running"},
+	})
+
+	assert.Same(t, ErrVersionConflict, c.RemoveOneIfMatch(ctx, "a", bson.M{"state": "done", "v": 1}))
+	assert.Same(t, ErrVersionConflict, c.RemoveOneIfMatch(ctx, "b", bson.M{"state": "done"}))
+	assert.Same(t, rerror.ErrNotFound, c.RemoveOneIfMatch(ctx, "c", bson.M{"state": "done"}))
+	// id in expected is ignored
+	assert.Same(t, ErrVersionConflict, c.RemoveOneIfMatch(ctx, "b", bson.M{"id": "a"}))
+
+	assert.NoError(t, c.RemoveOneIfMatch(ctx, "a", bson.M{"state": "done", "v": 2}))
+	assert.Same(t, rerror.ErrNotFound, c.RemoveOneIfMatch(ctx, "a", bson.M{"state": "done", "v": 2}))
+	assert.NoError(t, c.RemoveOneIfMatch(ctx, "b", nil))
+
+	got, err := c.Count(ctx, bson.M{"id": bson.M{"$in": []string{"a", "b"}}})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), got)
+}
+
+func TestCollection_UpdateOneArrayFilters(t *testing.T) {
+	ctx := context.Background()
+	c := NewCollection(mongotest.Connect(t)(t).Collection("test"))
+	_, _ = c.Client().InsertOne(ctx, bson.M{
+		"id": "a",
+		"items": bson.A{
+			bson.M{"id": "x", "status": "todo"},
+			bson.M{"id": "y", "status": "todo"},
+		},
+	})
+
+	assert.NoError(t, c.UpdateOneArrayFilters(ctx, bson.M{"id": "a"}, bson.M{"items.$[elem].status": "done"}, []any{bson.M{"elem.id": "x"}}))
+
+	var got struct {
+		Items []struct{ ID, Status string }
+	}
+	assert.NoError(t, c.Client().FindOne(ctx, bson.M{"id": "a"}).Decode(&got))
+	assert.Equal(t, []struct{ ID, Status string }{{ID: "x", Status: "done"}, {ID: "y", Status: "todo"}}, got.Items)
+
+	assert.Same(t, rerror.ErrNotFound, c.UpdateOneArrayFilters(ctx, bson.M{"id": "b"}, bson.M{"items.$[elem].status": "done"}, []any{bson.M{"elem.id": "x"}}))
+}
+
+func TestCollection_UpdateOne(t *testing.T) {
+	ctx := context.Background()
+	c := NewCollection(mongotest.Connect(t)(t).Collection("test"))
+	_, _ = c.Client().InsertOne(ctx, bson.M{
+		"id": "a",
+		"n":  1,
+		"items": bson.A{
+			bson.M{"id": "x", "status": "todo"},
+			bson.M{"id": "y", "status": "todo"},
+		},
+	})
+
+	assert.NoError(t, c.UpdateOne(ctx, Update{
+		Filter:       bson.M{"id": "a"},
+		Update:       bson.M{"items.$[elem].status": "done"},
+		ArrayFilters: []any{bson.M{"elem.id": "y"}},
+	}))
+
+	var got struct {
+		N     int
+		Items []struct{ ID, Status string }
+	}
+	assert.NoError(t, c.Client().FindOne(ctx, bson.M{"id": "a"}).Decode(&got))
+	assert.Equal(t, 1, got.N)
+	assert.Equal(t, []struct{ ID, Status string }{{ID: "x", Status: "todo"}, {ID: "y", Status: "done"}}, got.Items)
+
+	// not found
+	assert.Same(t, rerror.ErrNotFound, c.UpdateOne(ctx, Update{
+		Filter: bson.M{"id": "b"},
+		Update: bson.M{"n": 2},
+	}))
+
+	// upsert
+	assert.NoError(t, c.UpdateOne(ctx, Update{
+		Filter: bson.M{"id": "b"},
+		Update: bson.M{"n": 2},
+		Upsert: true,
+	}))
+	assert.NoError(t, c.Client().FindOne(ctx, bson.M{"id": "b"}).Decode(&got))
+	assert.Equal(t, 2, got.N)
+}
+
+func TestCollection_RawUpdateOne(t *testing.T) {
+	ctx := context.Background()
+	c := NewCollection(mongotest.Connect(t)(t).Collection("test"))
+	_, _ = c.Client().InsertOne(ctx, bson.M{"id": "a", "n": 1, "tags": bson.A{"x"}})
+
+	assert.NoError(t, c.RawUpdateOne(ctx, bson.M{"id": "a"}, bson.M{
+		"$inc":  bson.M{"n": 2},
+		"$push": bson.M{"tags": "y"},
+	}))
+
+	var got struct {
+		N    int
+		Tags []string
+	}
+	assert.NoError(t, c.Client().FindOne(ctx, bson.M{"id": "a"}).Decode(&got))
+	assert.Equal(t, 3, got.N)
+	assert.Equal(t, []string{"x", "y"}, got.Tags)
+
+	assert.Same(t, rerror.ErrNotFound, c.RawUpdateOne(ctx, bson.M{"id": "b"}, bson.M{"$inc": bson.M{"n": 1}}))
+}
+
+func TestCollection_RawUpdateMany(t *testing.T) {
+	ctx := context.Background()
+	c := NewCollection(mongotest.Connect(t)(t).Collection("test"))
+	_, _ = c.Client().InsertMany(ctx, []any{
+		bson.M{"id": "a", "n": 1},
+		bson.M{"id": "b", "n": 2},
+	})
+
+	assert.NoError(t, c.RawUpdateMany(ctx, bson.M{}, bson.M{"$inc": bson.M{"n": 10}}))
+	got, err := c.Count(ctx, bson.M{"n": bson.M{"$gt": 10}})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), got)
+
+	assert.NoError(t, c.RawUpdateMany(ctx, bson.M{"id": "c"}, bson.M{"$inc": bson.M{"n": 1}}))
+}
+
+func TestCollection_ReplaceOneNoUpsert(t *testing.T) {
+	ctx := context.Background()
+	c := NewCollection(mongotest.Connect(t)(t).Collection("test"))
+	_, _ = c.Client().InsertOne(ctx, bson.M{"id": "a", "n": 1})
+
+	assert.NoError(t, c.ReplaceOneNoUpsert(ctx, bson.M{"id": "a"}, bson.M{"id": "a", "n": 2}))
+	var got struct{ N int }
+	assert.NoError(t, c.Client().FindOne(ctx, bson.M{"id": "a"}).Decode(&got))
+	assert.Equal(t, 2, got.N)
+
+	assert.Same(t, rerror.ErrNotFound, c.ReplaceOneNoUpsert(ctx, bson.M{"id": "b"}, bson.M{"id": "b", "n": 1}))
+	count, err := c.Count(ctx, bson.M{"id": "b"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), count)
+}
+
+func TestCollection_SaveOneWithVersion(t *testing.T) {
+	ctx := context.Background()
+	c := NewCollection(mongotest.Connect(t)(t).Collection("test"))
+
+	// insert
+	assert.NoError(t, c.SaveOneWithVersion(ctx, "a", bson.M{"id": "a", "n": 1}, 0))
+	assert.Same(t, ErrVersionConflict, c.SaveOneWithVersion(ctx, "a", bson.M{"id": "a", "n": 2}, 0))
+	assert.Same(t, rerror.ErrNotFound, c.SaveOneWithVersion(ctx, "b", bson.M{"id": "b", "n": 1}, 1))
+
+	// update
+	assert.NoError(t, c.SaveOneWithVersion(ctx, "a", bson.M{"id": "a", "n": 2, "__v": 100}, 1))
+	var got struct {
+		N int
+		V int `bson:"__v"`
+	}
+	assert.NoError(t, c.Client().FindOne(ctx, bson.M{"id": "a"}).Decode(&got))
+	assert.Equal(t, 2, got.N)
+	assert.Equal(t, 2, got.V)
+
+	// a document without version
+	_, _ = c.Client().InsertOne(ctx, bson.M{"id": "c"})
+	assert.NoError(t, c.SaveOneWithVersion(ctx, "c", bson.M{"id": "c", "n": 1}, 0))
+
+	// concurrent saves with the same version
+	errs := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		i := i
+		go func() {
+			errs <- c.SaveOneWithVersion(ctx, "a", bson.M{"id": "a", "n": 10 + i}, 2)
+		}()
+	}
+	err1, err2 := <-errs, <-errs
+	assert.ElementsMatch(t, []error{nil, ErrVersionConflict}, []error{err1, err2})
+}
+
+func TestVersionedDocument(t *testing.T) {
+	got, err := versionedDocument(bson.M{"id": "a", "__v": 1}, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, bson.D{{Key: "id", Value: "a"}, {Key: "__v", Value: 2}}, got)
+
+	got, err = versionedDocument(struct{ ID string }{ID: "a"}, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, bson.D{{Key: "id", Value: "a"}, {Key: "__v", Value: 1}}, got)
+}
+
+func TestIsDuplicateKey(t *testing.T) {
+	dup := mongo.WriteException{WriteErrors: mongo.WriteErrors{{Code: 11000}}}
+	assert.True(t, IsDuplicateKey(dup))
+	assert.True(t, IsDuplicateKey(rerror.ErrInternalBy(dup)))
+	assert.True(t, IsDuplicateKey(ErrDuplicateKey))
+	assert.False(t, IsDuplicateKey(mongo.WriteException{WriteErrors: mongo.WriteErrors{{Code: 1}}}))
+	assert.False(t, IsDuplicateKey(errors.New("a")))
+	assert.False(t, IsDuplicateKey(nil))
+
+	c := &Collection{}
+	assert.NotNil(t, rerror.UnwrapErrInternal(c.wrapWriteError(dup)))
+	c.SetDuplicateKeyError(true)
+	assert.Same(t, ErrDuplicateKey, c.wrapWriteError(dup))
+	assert.NotNil(t, rerror.UnwrapErrInternal(c.wrapWriteError(errors.New("a"))))
+}
+
+func TestCollection_SetDuplicateKeyError(t *testing.T) {
+	ctx := context.Background()
+	c := NewCollection(mongotest.Connect(t)(t).Collection("test"))
+	_, err := c.Client().Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.M{"email": 1},
+		Options: options.Index().SetUnique(true),
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, c.SaveOne(ctx, "a", bson.M{"id": "a", "email": "a"}))
+
+	err = c.SaveOne(ctx, "b", bson.M{"id": "b", "email": "a"})
+	assert.True(t, IsDuplicateKey(err))
+	assert.NotSame(t, ErrDuplicateKey, err)
+
+	c.SetDuplicateKeyError(true)
+	assert.Same(t, ErrDuplicateKey, c.SaveOne(ctx, "b", bson.M{"id": "b", "email": "a"}))
+	assert.Same(t, ErrDuplicateKey, c.SaveAll(ctx, []string{"b"}, []any{bson.M{"id": "b", "email": "a"}}))
+}
@@ -1,6 +1,13 @@
 package mongox
 
-import "go.mongodb.org/mongo-driver/bson"
+import (
+	"context"
+	"io"
+	"sync"
+
+	"github.com/reearth/reearthx/rerror"
+	"go.mongodb.org/mongo-driver/bson"
+)
 
 type Consumer interface {
 	Consume(raw bson.Raw) error
@@ -22,19 +29,62 @@ func (s SimpleConsumer[T]) Consume(raw bson.Raw) error {
 	return s(t)
 }
 
+// SliceConsumer decodes each document into T and appends it to Result.
+// If IgnoreErrors is true, documents that fail to be decoded are skipped instead of returning the error.
 type SliceConsumer[T any] struct {
-	Result []T
-	c      SimpleConsumer[T]
+	Result       []T
+	IgnoreErrors bool
+}
+
+// NewSliceConsumer returns a SliceConsumer whose Result is pre-allocated with the capacity hint.
+func NewSliceConsumer[T any](capacity int) *SliceConsumer[T] {
+	return &SliceConsumer[T]{
+		Result: make([]T, 0, capacity),
+	}
 }
 
 func (s *SliceConsumer[T]) Consume(raw bson.Raw) error {
-	if s.c == nil {
-		s.c = SimpleConsumer[T](func(data T) error {
-			s.Result = append(s.Result, data)
+	if raw == nil {
+		return nil
+	}
+
+	var t T
+	if err := bson.Unmarshal(raw, &t); err != nil {
+		if s.IgnoreErrors {
 			return nil
-		})
+		}
+		return err
 	}
-	return s.c.Consume(raw)
+	s.Result = append(s.Result, t)
+	return nil
+}
+
+// OneConsumer decodes only the first document into T. It returns io.EOF after the first document
+// so that Find stops iterating the cursor.
+type OneConsumer[T any] struct {
+	result *T
+}
+
+func (s *OneConsumer[T]) Consume(raw bson.Raw) error {
+	if raw == nil || s.result != nil {
+		return nil
+	}
+
+	var t T
+	if err := bson.Unmarshal(raw, &t); err != nil {
+		return err
+	}
+	s.result = &t
+	return io.EOF
+}
+
+// Result returns the decoded document, or rerror.ErrNotFound if no document has been consumed.
+func (s *OneConsumer[T]) Result() (T, error) {
+	if s.result == nil {
+		var t T
+		return t, rerror.ErrNotFound
+	}
+	return *s.result, nil
 }
 
 type SliceRawFuncConsumer[T any] struct {
@@ -107,3 +157,49 @@ func (c *BatchConsumer) Consume(raw bson.Raw) error {
 
 	return nil
 }
+
+// ChannelConsumer sends each document to a buffered channel so that documents can be streamed without buffering all of them.
+// Sending blocks while the buffer is full, so a slow reader applies backpressure to the cursor iteration.
+// The channel is closed when the terminal nil is consumed or the context is done.
+// Call Close after Find returns in case Find fails before consuming the terminal nil.
+type ChannelConsumer struct {
+	ctx  context.Context
+	ch   chan bson.Raw
+	once sync.Once
+}
+
+func NewChannelConsumer(ctx context.Context, size int) *ChannelConsumer {
+	return &ChannelConsumer{
+		ctx: ctx,
+		ch:  make(chan bson.Raw, size),
+	}
+}
+
+func (c *ChannelConsumer) Chan() <-chan bson.Raw {
+	return c.ch
+}
+
+func (c *ChannelConsumer) Consume(raw bson.Raw) error {
+	if raw == nil {
+		c.Close()
+		return nil
+	}
+
+	// the driver reuses the buffer of the current document
+	r := make(bson.Raw, len(raw))
+	copy(r, raw)
+
+	select {
+	case c.ch <- r:
+		return nil
+	case <-c.ctx.Done():
+		c.Close()
+		return c.ctx.Err()
+	}
+}
+
+func (c *ChannelConsumer) Close() {
+	c.once.Do(func() {
+		close(c.ch)
+	})
+}
@@ -1,9 +1,13 @@
 package mongox
 
 import (
+	"context"
 	"errors"
+	"io"
 	"testing"
+	"time"
 
+	"github.com/reearth/reearthx/rerror"
 	"github.com/stretchr/testify/assert"
 	"go.mongodb.org/mongo-driver/bson"
 )
@@ -51,12 +55,74 @@ func TestSliceConsumer(t *testing.T) {
 
 	c := &SliceConsumer[d]{}
 	assert.NoError(t, c.Consume(raw))
+	assert.NoError(t, c.Consume(nil))
+	assert.Equal(t, []d{{
+		Test: "hoge",
+	},
+	}, c.Result)
+
+	// invalid document
+	assert.Error(t, c.Consume(bson.Raw{0}))
+	c.IgnoreErrors = true
+	assert.NoError(t, c.Consume(bson.Raw{0}))
 	assert.Equal(t, []d{{
 		Test: "hoge",
 	},
 	}, c.Result)
 }
 
+func TestSliceConsumer_Order(t *testing.T) {
+	type d struct {
+		ID   string `bson:"_id"`
+		Name string `bson:"n"`
+	}
+
+	c := &SliceConsumer[d]{}
+	for _, id := range []string{"c", "a", "b"} {
+		raw, _ := bson.Marshal(bson.M{"_id": id, "n": "name_" + id})
+		assert.NoError(t, c.Consume(raw))
+	}
+	assert.NoError(t, c.Consume(nil))
+	assert.Equal(t, []d{
+		{ID: "c", Name: "name_c"},
+		{ID: "a", Name: "name_a"},
+		{ID: "b", Name: "name_b"},
+	}, c.Result)
+}
+
+func TestNewSliceConsumer(t *testing.T) {
+	c := NewSliceConsumer[string](10)
+	assert.Equal(t, 10, cap(c.Result))
+	assert.Empty(t, c.Result)
+	assert.False(t, c.IgnoreErrors)
+}
+
+func TestOneConsumer(t *testing.T) {
+	type d struct{ Test string }
+	raw, _ := bson.Marshal(bson.M{"test": "hoge"})
+	raw2, _ := bson.Marshal(bson.M{"test": "foo"})
+
+	c := &OneConsumer[d]{}
+	assert.Equal(t, io.EOF, c.Consume(raw))
+	assert.NoError(t, c.Consume(raw2))
+	assert.NoError(t, c.Consume(nil))
+	got, err := c.Result()
+	assert.NoError(t, err)
+	assert.Equal(t, d{Test: "hoge"}, got)
+
+	// not found
+	c = &OneConsumer[d]{}
+	assert.NoError(t, c.Consume(nil))
+	got, err = c.Result()
+	assert.Same(t, rerror.ErrNotFound, err)
+	assert.Equal(t, d{}, got)
+
+	// invalid document
+	assert.Error(t, c.Consume(bson.Raw{0}))
+	_, err = c.Result()
+	assert.Same(t, rerror.ErrNotFound, err)
+}
+
 func TestSliceRawFuncConsumer(t *testing.T) {
 	type d struct{ Test string }
 	raw, _ := bson.Marshal(map[string]any{
@@ -133,3 +199,52 @@ func TestBatchConsumerWithError(t *testing.T) {
 
 	assert.EqualError(t, c.Consume(nil), "hoge")
 }
+
+func TestChannelConsumer(t *testing.T) {
+	raw, _ := bson.Marshal(map[string]any{
+		"test": "hoge",
+	})
+
+	c := NewChannelConsumer(context.Background(), 1)
+	assert.NoError(t, c.Consume(raw))
+
+	// the document is copied
+	raw[len(raw)-3] = 'a' // hoga
+	got := <-c.Chan()
+	assert.Equal(t, "hoge", got.Lookup("test").StringValue())
+
+	assert.NoError(t, c.Consume(nil))
+	_, ok := <-c.Chan()
+	assert.False(t, ok)
+	c.Close() // closing twice is safe
+}
+
+func TestChannelConsumer_Backpressure(t *testing.T) {
+	raw, _ := bson.Marshal(map[string]any{
+		"test": "hoge",
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c := NewChannelConsumer(ctx, 1)
+	assert.NoError(t, c.Consume(raw))
+
+	done := make(chan error)
+	go func() {
+		done <- c.Consume(raw)
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("Consume should block while the buffer is full")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	cancel()
+	assert.Same(t, context.Canceled, <-done)
+
+	// buffered documents can still be read
+	_, ok := <-c.Chan()
+	assert.True(t, ok)
+	_, ok = <-c.Chan()
+	assert.False(t, ok)
+}
@@ -0,0 +1,117 @@
+package mongox
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/reearth/reearthx/rerror"
+	"github.com/reearth/reearthx/util"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// CounterCache stores results of expensive count functions in a collection so that they can be read without running the counts every time.
+type CounterCache struct {
+	c        *Collection
+	lock     sync.RWMutex
+	counters map[string]func(context.Context) (int64, error)
+}
+
+type counterDocument struct {
+	ID         string    `bson:"id"`
+	Value      int64     `bson:"value"`
+	ComputedAt time.Time `bson:"computedat"`
+}
+
+func NewCounterCache(c *Collection) *CounterCache {
+	return &CounterCache{
+		c:        c,
+		counters: map[string]func(context.Context) (int64, error){},
+	}
+}
+
+func (c *CounterCache) Init(ctx context.Context) error {
+	_, err := c.c.ensureIndexes(ctx, IndexList{
+		IndexFromKey(idKey, true),
+	})
+	return err
+}
+
+// Register sets the count function of the key. It replaces the function already registered with the same key.
+func (c *CounterCache) Register(key string, f func(context.Context) (int64, error)) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.counters[key] = f
+}
+
+// Get returns the cached value of the key and the time when it was computed.
+// It returns rerror.ErrNotFound if the value has not been computed yet.
+func (c *CounterCache) Get(ctx context.Context, key string) (int64, time.Time, error) {
+	consumer := &OneConsumer[counterDocument]{}
+	if err := c.c.FindOne(ctx, bson.M{idKey: key}, consumer); err != nil {
+		return 0, time.Time{}, err
+	}
+	d, err := consumer.Result()
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	return d.Value, d.ComputedAt, nil
+}
+
+// Refresh runs the count function of the key and stores the result.
+func (c *CounterCache) Refresh(ctx context.Context, key string) error {
+	c.lock.RLock()
+	f, ok := c.counters[key]
+	c.lock.RUnlock()
+	if !ok {
+		return rerror.ErrNotFound
+	}
+
+	v, err := f(ctx)
+	if err != nil {
+		return err
+	}
+	return c.c.SaveOne(ctx, key, counterDocument{
+		ID:         key,
+		Value:      v,
+		ComputedAt: util.Now(),
+	})
+}
+
+// Run refreshes all registered counters immediately and then every interval until ctx is done.
+// Errors of each counter are passed to onError if it is not nil, and the previous value is kept.
+// It returns rerror.ErrInvalidParams if interval is not positive.
+func (c *CounterCache) Run(ctx context.Context, interval time.Duration, onError func(key string, err error)) error {
+	if interval <= 0 {
+		return rerror.ErrInvalidParams
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		for _, key := range c.keys() {
+			if err := c.Refresh(ctx, key); err != nil && onError != nil {
+				onError(key, err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *CounterCache) keys() []string {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	keys := make([]string, 0, len(c.counters))
+	for k := range c.counters {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,75 @@
+package mongox
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/reearth/reearthx/mongox/mongotest"
+	"github.com/reearth/reearthx/rerror"
+	"github.com/reearth/reearthx/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCounterCache(t *testing.T) {
+	ctx := context.Background()
+	c := NewCollection(mongotest.Connect(t)(t).Collection("test"))
+	cc := NewCounterCache(c)
+	assert.NoError(t, cc.Init(ctx))
+
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	defer util.MockNow(now)()
+
+	calls := int64(0)
+	cc.Register("users", func(ctx context.Context) (int64, error) {
+		calls++
+		return calls * 10, nil
+	})
+	cc.Register("failing", func(ctx context.Context) (int64, error) {
+		return 0, errors.New("failed")
+	})
+
+	_, _, err := cc.Get(ctx, "users")
+	assert.Same(t, rerror.ErrNotFound, err)
+	assert.Same(t, rerror.ErrNotFound, cc.Refresh(ctx, "unknown"))
+	assert.EqualError(t, cc.Refresh(ctx, "failing"), "failed")
+
+	assert.NoError(t, cc.Refresh(ctx, "users"))
+	v, at, err := cc.Get(ctx, "users")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(10), v)
+	assert.True(t, now.Equal(at))
+
+	// Get does not run the count function
+	_, _, _ = cc.Get(ctx, "users")
+	assert.Equal(t, int64(1), calls)
+
+	// Run refreshes all counters and reports errors
+	defer util.MockNow(now.Add(time.Hour))()
+	ctx2, cancel := context.WithCancel(ctx)
+	failed := make(chan string, 1)
+	done := make(chan error)
+	go func() {
+		done <- cc.Run(ctx2, time.Hour, func(key string, err error) {
+			failed <- key
+		})
+	}()
+	assert.Equal(t, "failing", <-failed)
+	assert.Eventually(t, func() bool {
+		v, _, _ := cc.Get(ctx, "users")
+		return v == 20
+	}, time.Second, 10*time.Millisecond)
+	cancel()
+	assert.Same(t, context.Canceled, <-done)
+
+	_, at, err = cc.Get(ctx, "users")
+	assert.NoError(t, err)
+	assert.True(t, now.Add(time.Hour).Equal(at))
+}
+
+func TestCounterCache_Run_InvalidInterval(t *testing.T) {
+	cc := NewCounterCache(nil)
+	assert.Same(t, rerror.ErrInvalidParams, cc.Run(context.Background(), 0, nil))
+	assert.Same(t, rerror.ErrInvalidParams, cc.Run(context.Background(), -time.Second, nil))
+}
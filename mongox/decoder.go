@@ -0,0 +1,114 @@
+package mongox
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/reearth/reearthx/rerror"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const schemaVersionKey = "_schemaVersion"
+
+// Decoder registers the function that decodes documents whose "_schemaVersion" field is the version.
+// Documents without the field are treated as version 0. Decoders of older versions can convert the documents into the current model
+// so that documents can be read correctly without migrating all of them at once.
+// It replaces the decoder already registered with the same version. Copies of the collection made after the call have the decoder,
+// but the collection and its copies do not share decoders registered later.
+func (c *Collection) Decoder(version int, fn func(bson.Raw) (any, error)) {
+	if c.decoders == nil {
+		c.decoders = newDecoders()
+	}
+	c.decoders.set(version, fn)
+}
+
+// Decode decodes the document with the decoder registered for its schema version.
+func (c *Collection) Decode(raw bson.Raw) (any, error) {
+	version, err := schemaVersion(raw)
+	if err != nil {
+		return nil, err
+	}
+	fn, ok := c.decoders.get(version)
+	if !ok {
+		return nil, rerror.ErrInternalBy(fmt.Errorf("no decoder for schema version %d", version))
+	}
+	return fn(raw)
+}
+
+type decoders struct {
+	lock sync.RWMutex
+	m    map[int]func(bson.Raw) (any, error)
+}
+
+func newDecoders() *decoders {
+	return &decoders{m: map[int]func(bson.Raw) (any, error){}}
+}
+
+func (d *decoders) get(version int) (func(bson.Raw) (any, error), bool) {
+	if d == nil {
+		return nil, false
+	}
+	d.lock.RLock()
+	defer d.lock.RUnlock()
+	fn, ok := d.m[version]
+	return fn, ok
+}
+
+func (d *decoders) set(version int, fn func(bson.Raw) (any, error)) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.m[version] = fn
+}
+
+func (d *decoders) clone() *decoders {
+	if d == nil {
+		return newDecoders()
+	}
+	d.lock.RLock()
+	defer d.lock.RUnlock()
+	d2 := newDecoders()
+	for k, v := range d.m {
+		d2.m[k] = v
+	}
+	return d2
+}
+
+// DecoderConsumer decodes each document with the decoders registered in the collection and appends it to Result.
+// Decoders must return T.
+type DecoderConsumer[T any] struct {
+	Result []T
+	c      *Collection
+}
+
+func NewDecoderConsumer[T any](c *Collection) *DecoderConsumer[T] {
+	return &DecoderConsumer[T]{c: c}
+}
+
+func (s *DecoderConsumer[T]) Consume(raw bson.Raw) error {
+	if raw == nil {
+		return nil
+	}
+
+	v, err := s.c.Decode(raw)
+	if err != nil {
+		return err
+	}
+	t, ok := v.(T)
+	if !ok {
+		return rerror.ErrInternalBy(fmt.Errorf("decoder returned %T instead of %T", v, t))
+	}
+	s.Result = append(s.Result, t)
+	return nil
+}
+
+func schemaVersion(raw bson.Raw) (int, error) {
+	v, err := raw.LookupErr(schemaVersionKey)
+	if err != nil {
+		return 0, nil
+	}
+	version, ok := v.AsInt64OK()
+	if !ok {
+		return 0, rerror.ErrInternalBy(fmt.Errorf("invalid schema version: %s", v))
+	}
+	return int(version), nil
+}
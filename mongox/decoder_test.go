@@ -0,0 +1,127 @@
+package mongox
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/reearth/reearthx/mongox/mongotest"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+)
+
+type testSchemaDocument struct {
+	ID   string `bson:"id"`
+	Name string `bson:"name"`
+}
+
+func testSchemaCollection(c *Collection) *Collection {
+	// version 0 has "title" instead of "name"
+	c.Decoder(0, func(raw bson.Raw) (any, error) {
+		var d struct {
+			ID    string `bson:"id"`
+			Title string `bson:"title"`
+		}
+		if err := bson.Unmarshal(raw, &d); err != nil {
+			return nil, err
+		}
+		return testSchemaDocument{ID: d.ID, Name: d.Title}, nil
+	})
+	c.Decoder(1, func(raw bson.Raw) (any, error) {
+		var d testSchemaDocument
+		err := bson.Unmarshal(raw, &d)
+		return d, err
+	})
+	return c
+}
+
+func TestCollection_Decode(t *testing.T) {
+	c := testSchemaCollection(&Collection{})
+
+	v0, _ := bson.Marshal(bson.M{"id": "a", "title": "A"})
+	got, err := c.Decode(v0)
+	assert.NoError(t, err)
+	assert.Equal(t, testSchemaDocument{ID: "a", Name: "A"}, got)
+
+	v1, _ := bson.Marshal(bson.M{"id": "b", "name": "B", "_schemaVersion": int32(1)})
+	got, err = c.Decode(v1)
+	assert.NoError(t, err)
+	assert.Equal(t, testSchemaDocument{ID: "b", Name: "B"}, got)
+
+	v1l, _ := bson.Marshal(bson.M{"id": "b", "name": "B", "_schemaVersion": int64(1)})
+	got, err = c.Decode(v1l)
+	assert.NoError(t, err)
+	assert.Equal(t, testSchemaDocument{ID: "b", Name: "B"}, got)
+
+	v2, _ := bson.Marshal(bson.M{"id": "c", "_schemaVersion": 2})
+	_, err = c.Decode(v2)
+	assert.EqualError(t, err, "internal")
+
+	invalid, _ := bson.Marshal(bson.M{"id": "c", "_schemaVersion": "x"})
+	_, err = c.Decode(invalid)
+	assert.EqualError(t, err, "internal")
+}
+
+func TestNewDecoderConsumer(t *testing.T) {
+	c := testSchemaCollection(&Collection{})
+	consumer := NewDecoderConsumer[testSchemaDocument](c)
+
+	v0, _ := bson.Marshal(bson.M{"id": "a", "title": "A"})
+	v1, _ := bson.Marshal(bson.M{"id": "b", "name": "B", "_schemaVersion": 1})
+	assert.NoError(t, consumer.Consume(v0))
+	assert.NoError(t, consumer.Consume(v1))
+	assert.NoError(t, consumer.Consume(nil))
+	assert.Equal(t, []testSchemaDocument{{ID: "a", Name: "A"}, {ID: "b", Name: "B"}}, consumer.Result)
+
+	assert.EqualError(t, NewDecoderConsumer[string](c).Consume(v0), "internal")
+}
+
+func TestCollection_Decoder(t *testing.T) {
+	ctx := context.Background()
+	c := testSchemaCollection(NewCollection(mongotest.Connect(t)(t).Collection("test")))
+	_, _ = c.Client().InsertMany(ctx, []any{
+		bson.M{"id": "a", "title": "A"},
+		bson.M{"id": "b", "name": "B", "_schemaVersion": 1},
+	})
+
+	consumer := NewDecoderConsumer[testSchemaDocument](c)
+	assert.NoError(t, c.Find(ctx, bson.M{}, consumer))
+	assert.ElementsMatch(t, []testSchemaDocument{{ID: "a", Name: "A"}, {ID: "b", Name: "B"}}, consumer.Result)
+}
+
+func TestCollection_Decoder_Clone(t *testing.T) {
+	c := testSchemaCollection(NewCollection(mongotest.Connect(t)(t).Collection("test")))
+	w := c.WithWriteConcern(writeconcern.New(writeconcern.WMajority()))
+
+	// decoders registered after the copy is made are not shared
+	w.Decoder(2, func(raw bson.Raw) (any, error) { return testSchemaDocument{}, nil })
+	_, err := w.Decode(lo.Must(bson.Marshal(bson.M{"_schemaVersion": 2})))
+	assert.NoError(t, err)
+	_, err = c.Decode(lo.Must(bson.Marshal(bson.M{"_schemaVersion": 2})))
+	assert.Error(t, err)
+	_, err = w.Decode(lo.Must(bson.Marshal(bson.M{"_schemaVersion": 1})))
+	assert.NoError(t, err)
+}
+
+func TestCollection_Decoder_Concurrent(t *testing.T) {
+	c := NewCollection(nil)
+	raw := lo.Must(bson.Marshal(bson.M{"_schemaVersion": 0}))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		i := i
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.Decoder(i, func(raw bson.Raw) (any, error) { return i, nil })
+			_, _ = c.Decode(raw)
+		}()
+	}
+	wg.Wait()
+
+	got, err := c.Decode(raw)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, got)
+}
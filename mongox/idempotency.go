@@ -0,0 +1,99 @@
+package mongox
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/reearth/reearthx/i18n"
+	"github.com/reearth/reearthx/rerror"
+	"github.com/reearth/reearthx/util"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const idempotencyExpiresAtKey = "expiresat"
+
+var ErrIdempotencyInProgress = rerror.NewE(i18n.T("operation in progress"))
+
+// Idempotency records idempotency keys in a collection so that an operation runs only once per key.
+type Idempotency struct {
+	c *Collection
+}
+
+type idempotencyDocument struct {
+	ID        string    `bson:"id"`
+	Done      bool      `bson:"done"`
+	Error     string    `bson:"error,omitempty"`
+	ExpiresAt time.Time `bson:"expiresat"`
+}
+
+func NewIdempotency(c *Collection) *Idempotency {
+	return &Idempotency{c: c}
+}
+
+// Init creates the unique index on the key and the TTL index that removes expired keys.
+func (i *Idempotency) Init(ctx context.Context) error {
+	_, err := i.c.ensureIndexes(ctx, IndexList{
+		IndexFromKey(idKey, true),
+		TTLIndexFromKey(idempotencyExpiresAtKey, 0),
+	})
+	return err
+}
+
+// Once runs fn only the first time it is called with the key until the key expires after ttl.
+// On retries it returns the prior outcome of fn, or ErrIdempotencyInProgress if fn has not finished yet.
+func (i *Idempotency) Once(ctx context.Context, key string, ttl time.Duration, fn func(ctx context.Context) error) error {
+	now := util.Now()
+	doc := idempotencyDocument{ID: key, ExpiresAt: now.Add(ttl)}
+
+	inserted, err := i.c.InsertIdempotent(ctx, key, doc)
+	if err != nil {
+		return err
+	}
+
+	if !inserted {
+		c := &OneConsumer[idempotencyDocument]{}
+		if err := i.c.FindOne(ctx, bson.M{idKey: key}, c); err != nil {
+			return err
+		}
+		prev, err := c.Result()
+		if err != nil {
+			return err
+		}
+
+		if prev.ExpiresAt.After(now) {
+			if !prev.Done {
+				return ErrIdempotencyInProgress
+			}
+			if prev.Error != "" {
+				return errors.New(prev.Error)
+			}
+			return nil
+		}
+
+		// the TTL monitor removes expired documents lazily, so take over the expired key
+		if err := i.c.RawUpdateOne(ctx, bson.M{
+			idKey:                   key,
+			idempotencyExpiresAtKey: prev.ExpiresAt,
+		}, bson.M{"$set": bson.M{
+			"done":                  false,
+			"error":                 "",
+			idempotencyExpiresAtKey: doc.ExpiresAt,
+		}}); err != nil {
+			if errors.Is(err, rerror.ErrNotFound) {
+				return ErrIdempotencyInProgress
+			}
+			return err
+		}
+	}
+
+	fnErr := fn(ctx)
+	result := bson.M{"done": true, "error": ""}
+	if fnErr != nil {
+		result["error"] = fnErr.Error()
+	}
+	if err := i.c.RawUpdateOne(ctx, bson.M{idKey: key}, bson.M{"$set": result}); err != nil {
+		return err
+	}
+	return fnErr
+}
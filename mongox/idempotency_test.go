@@ -0,0 +1,53 @@
+package mongox
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/reearth/reearthx/mongox/mongotest"
+	"github.com/reearth/reearthx/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdempotency_Once(t *testing.T) {
+	ctx := context.Background()
+	c := NewCollection(mongotest.Connect(t)(t).Collection("test"))
+	i := NewIdempotency(c)
+	assert.NoError(t, i.Init(ctx))
+
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	defer util.MockNow(now)()
+
+	calls := 0
+	fn := func(ctx context.Context) error {
+		calls++
+		return nil
+	}
+
+	assert.NoError(t, i.Once(ctx, "a", time.Hour, fn))
+	assert.NoError(t, i.Once(ctx, "a", time.Hour, fn))
+	assert.Equal(t, 1, calls)
+
+	// prior failure is returned on retries
+	failing := func(ctx context.Context) error {
+		calls++
+		return errors.New("failed")
+	}
+	assert.EqualError(t, i.Once(ctx, "b", time.Hour, failing), "failed")
+	assert.EqualError(t, i.Once(ctx, "b", time.Hour, failing), "failed")
+	assert.Equal(t, 2, calls)
+
+	// in progress
+	assert.NoError(t, i.Once(ctx, "c", time.Hour, func(ctx context.Context) error {
+		assert.Same(t, ErrIdempotencyInProgress, i.Once(ctx, "c", time.Hour, fn))
+		return nil
+	}))
+	assert.Equal(t, 2, calls)
+
+	// expired keys run again
+	defer util.MockNow(now.Add(2 * time.Hour))()
+	assert.NoError(t, i.Once(ctx, "a", time.Hour, fn))
+	assert.Equal(t, 3, calls)
+}
@@ -2,9 +2,12 @@ package mongox
 
 import (
 	"context"
+	"reflect"
+	"strings"
 
 	"github.com/reearth/reearthx/mongox/mongoxindexcompat"
 	"github.com/reearth/reearthx/util"
+	"github.com/samber/lo"
 )
 
 // Indexes creates and deletes indexes by keys declaratively
@@ -44,6 +47,36 @@ func (c *Collection) Indexes2(ctx context.Context, inputs ...Index) (IndexResult
 	return IndexResult(diff), nil
 }
 
+// EnsureIndexes creates unique and non-unique indexes only if equivalent indexes do not exist yet, and returns the names of created indexes.
+// Unlike Indexes and Indexes2, it never deletes existing indexes, so it is safe to call on every startup.
+func (c *Collection) EnsureIndexes(ctx context.Context, uniqueKeys, keys []string) ([]string, error) {
+	return c.ensureIndexes(ctx, append(IndexFromKeys(uniqueKeys, true), IndexFromKeys(keys, false)...))
+}
+
+// EnsureIndexesCompound is the same as EnsureIndexes, but each element of keys is a set of fields of a compound index.
+func (c *Collection) EnsureIndexesCompound(ctx context.Context, uniqueKeys, keys [][]string) ([]string, error) {
+	join := func(k []string, _ int) string { return strings.Join(k, ",") }
+	return c.EnsureIndexes(ctx, lo.Map(uniqueKeys, join), lo.Map(keys, join))
+}
+
+func (c *Collection) ensureIndexes(ctx context.Context, inputs IndexList) ([]string, error) {
+	indexes, err := c.findIndexes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	newIndexes := lo.Filter(IndexList(inputs.Normalize()), func(i Index, _ int) bool {
+		return !lo.ContainsBy(indexes, func(j Index) bool {
+			return i.Unique == j.Unique && reflect.DeepEqual(i.Key, j.Key)
+		})
+	})
+
+	if err := c.createIndexes(ctx, newIndexes); err != nil {
+		return nil, err
+	}
+	return IndexList(newIndexes).NamesWithoutPrefix(), nil
+}
+
 func (c *Collection) findIndexes(ctx context.Context) (IndexList, error) {
 	cur, err := c.client.Indexes().List(ctx)
 	if err != nil {
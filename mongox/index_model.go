@@ -19,6 +19,23 @@ type Index struct {
 	Unique             bool
 	ExpireAfterSeconds *int32
 	Filter             bson.M `bson:"partialFilterExpression"`
+	Collation          *IndexCollation
+}
+
+// IndexCollation is the collation of an index. Queries have to specify the same collation to use the index.
+type IndexCollation struct {
+	Locale   string
+	Strength int
+}
+
+func (c *IndexCollation) Options() *options.Collation {
+	if c == nil {
+		return nil
+	}
+	return &options.Collation{
+		Locale:   c.Locale,
+		Strength: c.Strength,
+	}
 }
 
 func IndexFromKey(key string, unique bool) Index {
@@ -84,6 +101,9 @@ func (i Index) Model() mongo.IndexModel {
 	if i.ExpireAfterSeconds != nil {
 		o.SetExpireAfterSeconds(*i.ExpireAfterSeconds)
 	}
+	if i.Collation != nil {
+		o.SetCollation(i.Collation.Options())
+	}
 	return mongo.IndexModel{
 		Keys:    i.Key,
 		Options: o,
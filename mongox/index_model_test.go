@@ -65,6 +65,17 @@ func TestIndex_Model(t *testing.T) {
 	}.Model())
 }
 
+func TestIndex_Model_Collation(t *testing.T) {
+	assert.Equal(t, mongo.IndexModel{
+		Keys:    bson.D{{Key: "a", Value: 1}},
+		Options: options.Index().SetName("aaa").SetCollation(&options.Collation{Locale: "en", Strength: 2}),
+	}, Index{
+		Name:      "aaa",
+		Key:       bson.D{{Key: "a", Value: 1}},
+		Collation: &IndexCollation{Locale: "en", Strength: 2},
+	}.Model())
+}
+
 func TestIndex_Normalize(t *testing.T) {
 	assert.Equal(t, Index{
 		Name:   "aaa",
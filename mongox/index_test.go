@@ -80,3 +80,66 @@ func TestClientCollection_Indexes2(t *testing.T) {
 		{Name: "_id_", Key: bson.D{{Key: "_id", Value: int32(1)}}, Unique: false},
 	}, indexes)
 }
+
+func TestClientCollection_Indexes2_Collation(t *testing.T) {
+	ctx := context.Background()
+	db := mongotest.Connect(t)(t)
+	c := NewCollection(db.Collection("test"))
+	index := Index{
+		Name:      "a",
+		Key:       bson.D{{Key: "a", Value: 1}},
+		Unique:    true,
+		Collation: &IndexCollation{Locale: "en", Strength: 2},
+	}
+
+	res, err := c.Indexes2(ctx, index)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a"}, res.AddedNames())
+
+	// the collation returned by the server has more fields, but the index is not updated
+	res, err = c.Indexes2(ctx, index)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{}, res.AddedNames())
+	assert.Equal(t, []string{}, res.UpdatedNames())
+	assert.Equal(t, []string{}, res.DeletedNames())
+
+	_, err = c.Client().InsertOne(ctx, bson.M{"a": "Foo"})
+	assert.NoError(t, err)
+	_, err = c.Client().InsertOne(ctx, bson.M{"a": "foo"})
+	assert.True(t, mongo.IsDuplicateKeyError(err))
+}
+
+func TestCollection_EnsureIndexes(t *testing.T) {
+	ctx := context.Background()
+	db := mongotest.Connect(t)(t)
+	col := db.Collection("test")
+	c := NewCollection(col)
+
+	// an equivalent index created by someone else
+	_, _ = col.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "a", Value: 1}}, // a_1
+	})
+
+	got, err := c.EnsureIndexes(ctx, []string{"id"}, []string{"a", "b"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"id", "b"}, got)
+
+	// restart
+	got, err = c.EnsureIndexes(ctx, []string{"id"}, []string{"a", "b"})
+	assert.NoError(t, err)
+	assert.Empty(t, got)
+
+	got, err = c.EnsureIndexesCompound(ctx, [][]string{{"id", "c"}}, [][]string{{"a", "b"}})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"id,c", "a,b"}, got)
+
+	got, err = c.EnsureIndexesCompound(ctx, [][]string{{"id", "c"}}, [][]string{{"a", "b"}})
+	assert.NoError(t, err)
+	assert.Empty(t, got)
+
+	cur, err := col.Indexes().List(ctx)
+	assert.NoError(t, err)
+	var indexes IndexList
+	assert.NoError(t, cur.All(ctx, &indexes))
+	assert.Equal(t, []string{"_id_", "a_1", "re_id", "re_b", "re_id,c", "re_a,b"}, indexes.Names())
+}
@@ -0,0 +1,74 @@
+package mongox
+
+import (
+	"context"
+
+	"github.com/reearth/reearthx/rerror"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// IterateResumable iterates all documents in ascending order of the sort key in batches and calls fn for each document.
+// The sort key is a field name string and defaults to id when it is nil or empty. fn returns the sort key value of the document.
+// It resumes after lastID if it is not empty, and returns the last processed value so that the caller can checkpoint it
+// even when an error is returned.
+//
+// The sort key must be a unique field of string values such as id, as documents are resumed by comparing the value only:
+// documents having the same value as the last processed one would be skipped. rerror.ErrInvalidParams is returned
+// when a document whose sort key value is not a string is found.
+func (c *Collection) IterateResumable(ctx context.Context, sort any, batchSize int, lastID string, fn func(bson.Raw) (string, error)) (string, error) {
+	key := idKey
+	if sort != nil {
+		s, ok := sort.(string)
+		if !ok {
+			return lastID, rerror.ErrInvalidParams
+		}
+		if s != "" {
+			key = s
+		}
+	}
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+
+	o := options.Find().SetSort(bson.D{{Key: key, Value: 1}}).SetLimit(int64(batchSize))
+	for {
+		if err := ctx.Err(); err != nil {
+			return lastID, err
+		}
+
+		filter := bson.M{}
+		if lastID != "" {
+			filter[key] = bson.M{"$gt": lastID}
+		}
+
+		cursor, err := c.client.Find(ctx, c.notDeletedFilter(filter), o)
+		if err != nil {
+			return lastID, wrapError(err)
+		}
+
+		n := 0
+		for cursor.Next(ctx) {
+			if _, ok := cursor.Current.Lookup(key).StringValueOK(); !ok {
+				_ = cursor.Close(ctx)
+				return lastID, rerror.ErrInvalidParams
+			}
+			id, err := fn(cursor.Current)
+			if err != nil {
+				_ = cursor.Close(ctx)
+				return lastID, err
+			}
+			lastID = id
+			n++
+		}
+		err = cursor.Err()
+		_ = cursor.Close(ctx)
+		if err != nil {
+			return lastID, wrapError(err)
+		}
+
+		if n < batchSize {
+			return lastID, nil
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package mongox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/reearth/reearthx/mongox/mongotest"
+	"github.com/reearth/reearthx/rerror"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestCollection_IterateResumable(t *testing.T) {
+	ctx := context.Background()
+	c := NewCollection(mongotest.Connect(t)(t).Collection("test"))
+	docs := make([]any, 0, 10)
+	for i := 0; i < 10; i++ {
+		docs = append(docs, bson.M{"id": fmt.Sprintf("%02d", i)})
+	}
+	_, _ = c.Client().InsertMany(ctx, docs)
+
+	var ids []string
+	crash := true
+	fn := func(raw bson.Raw) (string, error) {
+		id := raw.Lookup("id").StringValue()
+		if id == "05" && crash {
+			return "", errors.New("crash")
+		}
+		ids = append(ids, id)
+		return id, nil
+	}
+
+	// crash in the middle
+	last, err := c.IterateResumable(ctx, nil, 3, "", fn)
+	assert.EqualError(t, err, "crash")
+	assert.Equal(t, "04", last)
+	assert.Equal(t, []string{"00", "01", "02", "03", "04"}, ids)
+
+	// resume
+	crash = false
+	last, err = c.IterateResumable(ctx, "id", 3, last, fn)
+	assert.NoError(t, err)
+	assert.Equal(t, "09", last)
+	assert.Equal(t, []string{"00", "01", "02", "03", "04", "05", "06", "07", "08", "09"}, ids)
+
+	_, err = c.IterateResumable(ctx, bson.D{}, 3, "", fn)
+	assert.Same(t, rerror.ErrInvalidParams, err)
+
+	// non-string sort keys
+	_, _ = c.Client().InsertOne(ctx, bson.M{"id": "10", "n": 1})
+	last, err = c.IterateResumable(ctx, "n", 3, "", fn)
+	assert.Same(t, rerror.ErrInvalidParams, err)
+	assert.Equal(t, "", last)
+}
@@ -0,0 +1,95 @@
+package mongox
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/reearth/reearthx/rerror"
+	"github.com/reearth/reearthx/usecasex"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ErrMigrationInProgress is returned by Migrator.Apply when the migration is being applied by another process.
+var ErrMigrationInProgress = errors.New("migration is in progress")
+
+// Migrator records applied migration IDs in a dedicated collection so that each migration runs only once.
+type Migrator struct {
+	c      *Client
+	client *Collection
+}
+
+type migrationDocument struct {
+	ID        string    `bson:"id"`
+	AppliedAt time.Time `bson:"appliedat"`
+	// Running is true while the migration is being applied
+	Running bool `bson:"running,omitempty"`
+}
+
+func NewMigrator(c *Client, col string) *Migrator {
+	return &Migrator{
+		c:      c,
+		client: c.Collection(col),
+	}
+}
+
+// Init creates the unique index of migration IDs, which Apply relies on to lock migrations.
+func (m *Migrator) Init(ctx context.Context) error {
+	_, err := m.client.Indexes2(ctx, IndexFromKey(idKey, true))
+	return err
+}
+
+// Applied returns true if the migration has already been applied.
+func (m *Migrator) Applied(ctx context.Context, id string) (bool, error) {
+	count, err := m.client.Count(ctx, bson.M{idKey: id, "running": bson.M{"$ne": true}})
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// Apply runs fn only if the migration has not been applied yet, and then records it as applied.
+// fn and the record are done in a transaction if the client is configured to use transactions when Apply is called.
+// The migration is recorded as running before fn is run, so that concurrent calls do not run fn twice but return ErrMigrationInProgress.
+// Without transactions, if the process stops while fn is running, the record has to be removed before the migration can be applied again.
+func (m *Migrator) Apply(ctx context.Context, id string, fn func(ctx context.Context) error) error {
+	if id == "" {
+		return rerror.ErrInvalidParams
+	}
+
+	return usecasex.DoTransaction(ctx, m.c.Transaction(), 0, func(ctx context.Context) error {
+		applied, err := m.Applied(ctx, id)
+		if err != nil {
+			return err
+		}
+		if applied {
+			return nil
+		}
+
+		if _, err := m.client.Client().InsertOne(ctx, migrationDocument{
+			ID:        id,
+			AppliedAt: time.Now(),
+			Running:   true,
+		}); err != nil {
+			if mongo.IsDuplicateKeyError(err) {
+				return ErrMigrationInProgress
+			}
+			return wrapError(err)
+		}
+
+		if err := fn(ctx); err != nil {
+			// unlock the migration so that it can be applied again
+			_, _ = m.client.Client().DeleteOne(ctx, bson.M{idKey: id, "running": true})
+			return err
+		}
+
+		if _, err := m.client.Client().UpdateOne(ctx, bson.M{idKey: id}, bson.M{
+			"$set":   bson.M{"appliedat": time.Now()},
+			"$unset": bson.M{"running": ""},
+		}); err != nil {
+			return wrapError(err)
+		}
+		return nil
+	})
+}
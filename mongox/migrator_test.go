@@ -0,0 +1,98 @@
+package mongox
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/reearth/reearthx/mongox/mongotest"
+	"github.com/reearth/reearthx/rerror"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMigrator_Apply(t *testing.T) {
+	ctx := context.Background()
+	db := mongotest.Connect(t)(t)
+	m := NewMigrator(NewClientWithDatabase(db), "migration")
+	assert.NoError(t, m.Init(ctx))
+
+	called := 0
+	fn := func(ctx context.Context) error {
+		called++
+		return nil
+	}
+
+	assert.NoError(t, m.Apply(ctx, "a", fn))
+	assert.Equal(t, 1, called)
+	applied, err := m.Applied(ctx, "a")
+	assert.NoError(t, err)
+	assert.True(t, applied)
+
+	// already applied
+	assert.NoError(t, m.Apply(ctx, "a", fn))
+	assert.Equal(t, 1, called)
+
+	// failed migrations are not recorded
+	err2 := errors.New("failed")
+	assert.Same(t, err2, m.Apply(ctx, "b", func(ctx context.Context) error { return err2 }))
+	applied, err = m.Applied(ctx, "b")
+	assert.NoError(t, err)
+	assert.False(t, applied)
+
+	// invalid id
+	assert.Same(t, rerror.ErrInvalidParams, m.Apply(ctx, "", fn))
+}
+
+func TestMigrator_Apply_Concurrent(t *testing.T) {
+	ctx := context.Background()
+	db := mongotest.Connect(t)(t)
+	m := NewMigrator(NewClientWithDatabase(db), "migration")
+	assert.NoError(t, m.Init(ctx))
+
+	var called int32
+	errs := make([]error, 10)
+	var wg sync.WaitGroup
+	for i := range errs {
+		i := i
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = m.Apply(ctx, "a", func(ctx context.Context) error {
+				atomic.AddInt32(&called, 1)
+				time.Sleep(10 * time.Millisecond)
+				return nil
+			})
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), called)
+	for _, err := range errs {
+		if err != nil {
+			assert.Same(t, ErrMigrationInProgress, err)
+		}
+	}
+	applied, err := m.Applied(ctx, "a")
+	assert.NoError(t, err)
+	assert.True(t, applied)
+
+	// a migration recorded as running is not applied
+	_, err = m.client.Client().InsertOne(ctx, migrationDocument{ID: "b", Running: true})
+	assert.NoError(t, err)
+	applied, err = m.Applied(ctx, "b")
+	assert.NoError(t, err)
+	assert.False(t, applied)
+	assert.Same(t, ErrMigrationInProgress, m.Apply(ctx, "b", func(ctx context.Context) error { return nil }))
+}
+
+func TestMigrator_Transaction(t *testing.T) {
+	c := NewClientWithDatabase(mongotest.Connect(t)(t))
+	m := NewMigrator(c, "migration")
+
+	// the transaction configured after the migrator is created is used
+	c.WithTransaction()
+	assert.IsType(t, &Transaction{}, m.c.Transaction())
+}
@@ -0,0 +1,156 @@
+package mongotest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"sync"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongodPath is the path to the mongod binary used by ConnectEphemeral. If empty, mongod is looked up in PATH.
+var MongodPath = ""
+
+var ephemeral = &ephemeralServer{}
+
+// ConnectEphemeral is the same as Connect, but if the env var is not configured, it starts a disposable mongod instead of skipping the test.
+// The mongod is started by the first test using it and shared by all tests in the package until StopEphemeral is called,
+// so packages using ConnectEphemeral should call StopEphemeral from TestMain after running the tests.
+// The test is skipped if mongod is not installed.
+func ConnectEphemeral(t *testing.T, opts ...Option) func(*testing.T) *mongo.Database {
+	t.Helper()
+
+	conf := newConfig(opts)
+	if conf.env != "" && os.Getenv(conf.env) != "" {
+		return Connect(t, opts...)
+	}
+
+	uri, err := ephemeral.acquire()
+	if err != nil {
+		t.Skipf("mongotest: ephemeral mongod is not available: %v", err)
+		return nil
+	}
+	return connect(uri, conf)
+}
+
+// StopEphemeral stops the mongod started by ConnectEphemeral. It does nothing if no mongod has been started.
+//
+//	func TestMain(m *testing.M) {
+//		code := m.Run()
+//		mongotest.StopEphemeral()
+//		os.Exit(code)
+//	}
+func StopEphemeral() {
+	ephemeral.release()
+}
+
+type ephemeralServer struct {
+	lock    sync.Mutex
+	started bool
+	err     error
+	cmd     *exec.Cmd
+	dir     string
+	uri     string
+}
+
+// acquire starts mongod only once, so a failure to start it is also reused by the following tests instead of waiting for it again.
+func (s *ephemeralServer) acquire() (string, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if !s.started {
+		s.started = true
+		s.err = s.start()
+	}
+	return s.uri, s.err
+}
+
+func (s *ephemeralServer) release() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.stop()
+	s.started, s.err = false, nil
+}
+
+func (s *ephemeralServer) start() error {
+	path := MongodPath
+	if path == "" {
+		p, err := exec.LookPath("mongod")
+		if err != nil {
+			return err
+		}
+		path = p
+	}
+
+	port, err := freePort()
+	if err != nil {
+		return err
+	}
+	dir, err := os.MkdirTemp("", "mongotest")
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.Command(path, "--dbpath", dir, "--port", fmt.Sprint(port), "--bind_ip", "127.0.0.1", "--quiet")
+	if err := cmd.Start(); err != nil {
+		_ = os.RemoveAll(dir)
+		return err
+	}
+	s.cmd, s.dir, s.uri = cmd, dir, fmt.Sprintf("mongodb://127.0.0.1:%d", port)
+
+	if err := waitForServer(s.uri, 30*time.Second); err != nil {
+		s.stop()
+		return err
+	}
+	return nil
+}
+
+func (s *ephemeralServer) stop() {
+	if s.cmd != nil && s.cmd.Process != nil {
+		_ = s.cmd.Process.Kill()
+		_ = s.cmd.Wait()
+	}
+	if s.dir != "" {
+		_ = os.RemoveAll(s.dir)
+	}
+	s.cmd, s.dir, s.uri = nil, "", ""
+}
+
+func freePort() (int, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = l.Close() }()
+	return l.Addr().(*net.TCPAddr).Port, nil
+}
+
+func waitForServer(uri string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	c, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	if err != nil {
+		return err
+	}
+	defer func() { _ = c.Disconnect(context.Background()) }()
+
+	for {
+		if err := c.Ping(ctx, nil); err == nil {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return errors.New("mongod did not start in time")
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
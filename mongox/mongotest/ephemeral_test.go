@@ -0,0 +1,56 @@
+package mongotest
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestMain(m *testing.M) {
+	code := m.Run()
+	StopEphemeral()
+	os.Exit(code)
+}
+
+func TestConnectEphemeral(t *testing.T) {
+	db := ConnectEphemeral(t)(t)
+	_, err := db.Collection("test").InsertOne(context.Background(), bson.M{"a": 1})
+	assert.NoError(t, err)
+}
+
+func TestEphemeralServer(t *testing.T) {
+	s := &ephemeralServer{}
+	uri, err := s.acquire()
+	if err != nil {
+		t.Skipf("mongod is not available: %v", err)
+	}
+	defer s.release()
+
+	// the same mongod is used by the following tests
+	uri2, err := s.acquire()
+	assert.NoError(t, err)
+	assert.Equal(t, uri, uri2)
+	cmd := s.cmd
+
+	s.release()
+	assert.Nil(t, s.cmd)
+	assert.False(t, s.started)
+	assert.Error(t, cmd.Process.Signal(os.Interrupt))
+}
+
+func TestEphemeralServer_notInstalled(t *testing.T) {
+	defer func(p string) { MongodPath = p }(MongodPath)
+	MongodPath = "/nonexistent/mongod"
+
+	s := &ephemeralServer{}
+	_, err := s.acquire()
+	assert.Error(t, err)
+	assert.Nil(t, s.cmd)
+
+	// the failure is reused
+	_, err2 := s.acquire()
+	assert.Same(t, err, err2)
+}
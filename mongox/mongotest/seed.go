@@ -0,0 +1,63 @@
+package mongotest
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Seed inserts the documents into the collection and removes them when the test finishes. It fails the test if the documents cannot be inserted.
+func Seed(t *testing.T, db *mongo.Database, collection string, docs ...any) {
+	t.Helper()
+
+	if len(docs) == 0 {
+		return
+	}
+
+	c := db.Collection(collection)
+	res, err := c.InsertMany(context.Background(), docs)
+	if err != nil {
+		t.Fatalf("mongotest: failed to seed %s: %v", collection, err)
+	}
+	t.Cleanup(func() {
+		_, _ = c.DeleteMany(context.Background(), bson.M{"_id": bson.M{"$in": res.InsertedIDs}})
+	})
+}
+
+// SeedJSON is the same as Seed, but reads the documents from the file of a JSON array, e.g. in testdata.
+// Documents are parsed as MongoDB Extended JSON, so values such as {"$date": "2022-01-01T00:00:00Z"} can be used.
+func SeedJSON(t *testing.T, db *mongo.Database, collection, path string) {
+	t.Helper()
+
+	docs, err := readJSONDocuments(path)
+	if err != nil {
+		t.Fatalf("mongotest: failed to read %s: %v", path, err)
+	}
+	Seed(t, db, collection, docs...)
+}
+
+func readJSONDocuments(path string) ([]any, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var raws []json.RawMessage
+	if err := json.Unmarshal(b, &raws); err != nil {
+		return nil, err
+	}
+
+	docs := make([]any, 0, len(raws))
+	for _, r := range raws {
+		var d bson.D
+		if err := bson.UnmarshalExtJSON(r, false, &d); err != nil {
+			return nil, err
+		}
+		docs = append(docs, d)
+	}
+	return docs, nil
+}
@@ -0,0 +1,50 @@
+package mongotest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestSeed(t *testing.T) {
+	ctx := context.Background()
+	db := ConnectEphemeral(t)(t)
+
+	t.Run("seed", func(t *testing.T) {
+		Seed(t, db, "test", bson.M{"id": "x"})
+		SeedJSON(t, db, "test", "testdata/seed.json")
+
+		got, err := db.Collection("test").CountDocuments(ctx, bson.M{})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), got)
+	})
+
+	// seeded documents are removed after the subtest
+	got, err := db.Collection("test").CountDocuments(ctx, bson.M{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), got)
+}
+
+func TestReadJSONDocuments(t *testing.T) {
+	got, err := readJSONDocuments("testdata/seed.json")
+	assert.NoError(t, err)
+	assert.Equal(t, []any{
+		bson.D{
+			{Key: "id", Value: "a"},
+			{Key: "name", Value: "foo"},
+			{Key: "createdAt", Value: primitive.NewDateTimeFromTime(time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC))},
+		},
+		bson.D{
+			{Key: "id", Value: "b"},
+			{Key: "name", Value: "bar"},
+			{Key: "n", Value: int32(1)},
+		},
+	}, got)
+
+	_, err = readJSONDocuments("testdata/none.json")
+	assert.Error(t, err)
+}
@@ -9,40 +9,135 @@ import (
 	"github.com/google/uuid"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readconcern"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
 )
 
 var (
-	Env      = ""
+	// Env is the default name of the env var that holds the URI of the database server.
+	//
+	// Deprecated: use WithEnv instead.
+	Env = ""
+	// Database is the default prefix of database names.
+	//
+	// Deprecated: use WithPrefix instead.
 	Database = "test"
 )
 
-func Connect(t *testing.T) func(*testing.T) *mongo.Database {
+// Option configures Connect.
+type Option func(*config)
+
+type config struct {
+	env            string
+	prefix         string
+	connectTimeout time.Duration
+	readPreference *readpref.ReadPref
+}
+
+func newConfig(opts []Option) *config {
+	c := &config{
+		env:            Env,
+		prefix:         Database,
+		connectTimeout: time.Second * 10,
+	}
+	for _, o := range opts {
+		o(c)
+	}
+	return c
+}
+
+// WithEnv sets the name of the env var that holds the URI of the database server. The default is Env.
+func WithEnv(env string) Option {
+	return func(c *config) {
+		c.env = env
+	}
+}
+
+// WithPrefix sets the prefix of database names, which is followed by "_" and a random UUID. The default is Database.
+func WithPrefix(prefix string) Option {
+	return func(c *config) {
+		c.prefix = prefix
+	}
+}
+
+// WithConnectTimeout sets the timeout to connect to the database server. The default is 10 seconds.
+func WithConnectTimeout(d time.Duration) Option {
+	return func(c *config) {
+		c.connectTimeout = d
+	}
+}
+
+// WithReadPreference sets the read preference of the client.
+func WithReadPreference(rp *readpref.ReadPref) Option {
+	return func(c *config) {
+		c.readPreference = rp
+	}
+}
+
+func Connect(t *testing.T, opts ...Option) func(*testing.T) *mongo.Database {
 	t.Helper()
 
+	conf := newConfig(opts)
+
 	// Skip unit testing if the env var is not configured
 	var db string
-	if Env != "" {
-		db = os.Getenv(Env)
+	if conf.env != "" {
+		db = os.Getenv(conf.env)
 	}
 	if db == "" {
 		t.SkipNow()
 		return nil
 	}
 
-	c, _ := mongo.Connect(
-		context.Background(),
-		options.Client().
-			ApplyURI(db).
-			SetConnectTimeout(time.Second*10),
-	)
+	return connect(db, conf)
+}
+
+func connect(uri string, conf *config) func(*testing.T) *mongo.Database {
+	o := options.Client().
+		ApplyURI(uri).
+		SetConnectTimeout(conf.connectTimeout)
+	if conf.readPreference != nil {
+		o.SetReadPreference(conf.readPreference)
+	}
+	c, _ := mongo.Connect(context.Background(), o)
 
 	return func(t *testing.T) *mongo.Database {
 		t.Helper()
 
-		databaseName := Database + "_" + uuid.NewString()
+		databaseName := conf.prefix + "_" + uuid.NewString()
 		t.Cleanup(func() {
 			_ = c.Database(databaseName).Drop(context.Background())
 		})
 		return c.Database(databaseName)
 	}
 }
+
+// ConnectWithSession is the same as Connect, but it also returns a context bound to a causally consistent session.
+// Operations that use the context can read their own writes even against a replica set.
+func ConnectWithSession(t *testing.T, opts ...Option) func(*testing.T) (context.Context, *mongo.Database) {
+	t.Helper()
+
+	connect := Connect(t, opts...)
+
+	return func(t *testing.T) (context.Context, *mongo.Database) {
+		t.Helper()
+
+		d := connect(t)
+		db := d.Client().Database(
+			d.Name(),
+			options.Database().
+				SetReadConcern(readconcern.Majority()).
+				SetWriteConcern(writeconcern.New(writeconcern.WMajority())),
+		)
+
+		s, err := db.Client().StartSession(options.Session().SetCausalConsistency(true))
+		if err != nil {
+			t.Fatalf("failed to start a session: %v", err)
+		}
+		t.Cleanup(func() {
+			s.EndSession(context.Background())
+		})
+		return mongo.NewSessionContext(context.Background(), s), db
+	}
+}
@@ -0,0 +1,38 @@
+package mongotest
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+)
+
+func TestNewConfig(t *testing.T) {
+	defer func(e, d string) { Env, Database = e, d }(Env, Database)
+	Env, Database = "DB", "db"
+
+	assert.Equal(t, &config{
+		env:            "DB",
+		prefix:         "db",
+		connectTimeout: 10 * time.Second,
+	}, newConfig(nil))
+
+	assert.Equal(t, &config{
+		env:            "DB2",
+		prefix:         "pkg",
+		connectTimeout: time.Second,
+		readPreference: readpref.Primary(),
+	}, newConfig([]Option{
+		WithEnv("DB2"),
+		WithPrefix("pkg"),
+		WithConnectTimeout(time.Second),
+		WithReadPreference(readpref.Primary()),
+	}))
+}
+
+func TestConnect_WithPrefix(t *testing.T) {
+	db := ConnectEphemeral(t, WithPrefix("mongotest"))(t)
+	assert.True(t, strings.HasPrefix(db.Name(), "mongotest_"))
+}
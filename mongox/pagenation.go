@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 
 	"github.com/reearth/reearthx/rerror"
 	"github.com/reearth/reearthx/usecasex"
@@ -12,22 +13,41 @@ import (
 )
 
 func (c *Collection) Paginate(ctx context.Context, rawFilter any, sort *usecasex.Sort, p *usecasex.Pagination, consumer Consumer, opts ...*options.FindOptions) (*usecasex.PageInfo, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
 	if p == nil || p.Cursor == nil && p.Offset == nil {
 		return nil, nil
 	}
+	if err := p.Cursor.Validate(); err != nil {
+		return nil, rerror.ErrInvalidParams
+	}
 
-	filter, findOptions, err := c.paginationFilter(ctx, *p, sort, rawFilter)
+	rawFilter = And(c.notDeletedFilter(rawFilter), "", sortFilter(sort))
+	p, err := c.compositeCursorPagination(ctx, p, sort)
+	if err != nil {
+		return nil, rerror.ErrInternalBy(err)
+	}
+	q, err := NewPaginationQuery(p, rawFilter, sort)
 	if err != nil {
 		return nil, rerror.ErrInternalBy(err)
 	}
 
-	limit := int(*findOptions.Limit)
+	// read one more element so that we can see whether there's a further one
+	limit := q.Limit + 1
+	findOptions := options.Find().
+		SetAllowDiskUse(true).
+		SetSkip(q.Skip).
+		SetLimit(limit).
+		SetCollation(&options.Collation{Strength: 1, Locale: "en"}).
+		SetSort(q.Sort)
+
 	count, err := c.client.CountDocuments(ctx, rawFilter)
 	if err != nil {
 		return nil, rerror.ErrInternalBy(fmt.Errorf("failed to count: %w", err))
 	}
 
-	cursor, err := c.client.Find(ctx, filter, append([]*options.FindOptions{findOptions}, opts...)...)
+	cursor, err := c.client.Find(ctx, q.Filter, append([]*options.FindOptions{findOptions}, opts...)...)
 	if err != nil {
 		return nil, rerror.ErrInternalBy(fmt.Errorf("failed to find: %w", err))
 	}
@@ -35,11 +55,22 @@ func (c *Collection) Paginate(ctx context.Context, rawFilter any, sort *usecasex
 		_ = cursor.Close(ctx)
 	}()
 
-	i := 0
+	// cursors of cursor-based pagination have the sort key value so that the next page can be found without looking up the cursor element
+	var cursorSortKey *string
+	if p.Cursor != nil && sort != nil && sort.Key != "" {
+		cursorSortKey = &sort.Key
+	}
+
+	var i int64
+	stopped, more := false, false
 	var startCursor, endCursor *usecasex.Cursor
 	for cursor.Next(ctx) {
+		if stopped {
+			more = true
+			break
+		}
 		if i < limit-1 {
-			cur, err := getCursor(cursor.Current)
+			cur, err := getCursor(cursor.Current, cursorSortKey)
 			if err != nil {
 				return nil, rerror.ErrInternalBy(fmt.Errorf("failed to get cursor: %w", err))
 			}
@@ -50,7 +81,11 @@ func (c *Collection) Paginate(ctx context.Context, rawFilter any, sort *usecasex
 			endCursor = cur
 
 			if err := consumer.Consume(cursor.Current); err != nil {
-				return nil, err
+				if !errors.Is(err, io.EOF) {
+					return nil, err
+				}
+				// the consumer does not need more documents, so the page ends with this document
+				stopped = true
 			}
 		}
 
@@ -64,15 +99,27 @@ func (c *Collection) Paginate(ctx context.Context, rawFilter any, sort *usecasex
 	// ref: https://facebook.github.io/relay/graphql/connections.htm#sec-undefined.PageInfo.Fields
 	// If first is set, false can be returned unless it can be efficiently determined whether or not a previous page exists.
 	// If last is set, false can be returned unless it can be efficiently determined whether or not a next page exists.
-	// Returning absolutely false because the existing implementation cannot determine it efficiently.
-	hasMore := i == limit
+	// A previous page is assumed to exist when after is set, since the cursor element precedes the page.
+	hasMore := i == limit || more
 	hasNextPage := (p.Cursor != nil && p.Cursor.First != nil || p.Offset != nil) && hasMore
-	hasPreviousPage := (p.Cursor != nil && p.Cursor.Last != nil) && hasMore
+	hasPreviousPage := p.Cursor != nil && (p.Cursor.Last != nil && hasMore || p.Cursor.After != nil)
 
 	return usecasex.NewPageInfo(count, startCursor, endCursor, hasNextPage, hasPreviousPage), nil
 }
 
-func (c *Collection) paginationFilter(ctx context.Context, p usecasex.Pagination, sort *usecasex.Sort, filter any) (any, *options.FindOptions, error) {
+// PaginateOffset finds documents with skip and limit and returns the total count of documents matched by the filter.
+func (c *Collection) PaginateOffset(ctx context.Context, filter any, sort *usecasex.Sort, p *usecasex.OffsetPagination, consumer Consumer, opts ...*options.FindOptions) (int64, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	if p == nil {
+		return 0, nil
+	}
+	if p.Offset < 0 || p.Limit < 0 {
+		return 0, rerror.ErrInvalidParams
+	}
+
+	filter = And(c.notDeletedFilter(filter), "", sortFilter(sort))
 	var sortKey *string
 	reverted := false
 	if sort != nil {
@@ -80,82 +127,114 @@ func (c *Collection) paginationFilter(ctx context.Context, p usecasex.Pagination
 		reverted = sort.Reverted
 	}
 
-	opts := findOptionsFromPagination(p, sortKey, reverted || (p.Cursor != nil && p.Cursor.Last != nil))
-
-	if p.Offset != nil {
-		return filter, opts, nil
+	count, err := c.client.CountDocuments(ctx, filter)
+	if err != nil {
+		return 0, rerror.ErrInternalBy(fmt.Errorf("failed to count: %w", err))
 	}
 
-	if p.Cursor == nil {
-		return nil, nil, errors.New("invalid cursor")
+	o := options.Find().
+		SetAllowDiskUse(true).
+		SetSkip(p.Offset).
+		SetLimit(p.Limit).
+		SetCollation(&options.Collation{Strength: 1, Locale: "en"}).
+		SetSort(sortOptionsFrom(sortKey, reverted))
+
+	cursor, err := c.client.Find(ctx, filter, append([]*options.FindOptions{o}, opts...)...)
+	if err != nil {
+		return 0, rerror.ErrInternalBy(fmt.Errorf("failed to find: %w", err))
 	}
+	defer func() {
+		_ = cursor.Close(ctx)
+	}()
 
-	var op string
-	var cur *usecasex.Cursor
+	for cursor.Next(ctx) {
+		if err := consumer.Consume(cursor.Current); err != nil {
+			if errors.Is(err, io.EOF) {
+				// the consumer does not need more documents
+				break
+			}
+			return 0, err
+		}
+	}
 
-	if p.Cursor.First != nil {
-		op = "$gt"
-		cur = p.Cursor.After
-	} else if p.Cursor.Last != nil {
-		op = "$lt"
-		cur = p.Cursor.Before
-	} else {
-		return nil, nil, errors.New("neither first nor last are set")
+	if err := cursor.Err(); err != nil {
+		return 0, rerror.ErrInternalBy(fmt.Errorf("failed to read cursor: %w", err))
 	}
 
-	var paginationFilter bson.M
-	if cur != nil {
-		if sortKey == nil || *sortKey == "" {
-			paginationFilter = bson.M{idKey: bson.M{op: *cur}}
-		} else {
-			var cursorDoc bson.M
-			if err := c.client.FindOne(ctx, bson.M{idKey: *cur}).Decode(&cursorDoc); err != nil {
-				return nil, nil, fmt.Errorf("failed to find cursor element")
-			}
+	return count, nil
+}
 
-			if cursorDoc[*sortKey] == nil {
-				return nil, nil, fmt.Errorf("invalied sort key")
-			}
+// compositeCursorPagination replaces cursors of ids with composite cursors looking up the sort key values of the cursor elements,
+// so that cursors issued before composite cursors were introduced are still accepted.
+func (c *Collection) compositeCursorPagination(ctx context.Context, p *usecasex.Pagination, sort *usecasex.Sort) (*usecasex.Pagination, error) {
+	if p.Cursor == nil || sort == nil || sort.Key == "" || sort.Key == idKey {
+		return p, nil
+	}
 
-			paginationFilter = bson.M{
-				"$or": []bson.M{
-					{*sortKey: bson.M{op: cursorDoc[*sortKey]}},
-					{
-						*sortKey: cursorDoc[*sortKey],
-						idKey:    bson.M{op: *cur},
-					},
-				},
-			}
+	resolve := func(cur *usecasex.Cursor) (*usecasex.Cursor, error) {
+		if cur == nil {
+			return nil, nil
 		}
+		if _, err := usecasex.DecodeCompositeCursor(*cur); err == nil {
+			return cur, nil
+		}
+
+		// the cursor is an id
+		var cursorDoc bson.M
+		if err := c.client.FindOne(ctx, bson.M{idKey: *cur}).Decode(&cursorDoc); err != nil {
+			return nil, fmt.Errorf("failed to find cursor element")
+		}
+		value := cursorDoc[sort.Key]
+		if value == nil {
+			return nil, fmt.Errorf("invalied sort key")
+		}
+		cc, err := usecasex.CompositeCursor{Value: value, ID: string(*cur)}.Encode()
+		if err != nil {
+			return nil, err
+		}
+		return &cc, nil
 	}
 
-	return And(filter, "", paginationFilter), opts, nil
+	after, err := resolve(p.Cursor.After)
+	if err != nil {
+		return nil, err
+	}
+	before, err := resolve(p.Cursor.Before)
+	if err != nil {
+		return nil, err
+	}
+
+	cp := *p.Cursor
+	cp.After, cp.Before = after, before
+	return &usecasex.Pagination{Cursor: &cp, Offset: p.Offset}, nil
 }
 
-func findOptionsFromPagination(p usecasex.Pagination, sort *string, reverted bool) *options.FindOptions {
-	const defaultLimit = 20
-	o := options.Find().SetAllowDiskUse(true)
-
-	if p.Offset != nil {
-		o = o.SetSkip(p.Offset.Offset).SetLimit(p.Offset.Limit)
-	} else if p.Cursor != nil {
-		var limit int64
-		if p.Cursor.First != nil {
-			limit = int64(*p.Cursor.First)
-		} else if p.Cursor.Last != nil {
-			limit = int64(*p.Cursor.Last)
-		}
-		o = o.SetLimit(limit)
+// sortFilter returns a filter to find documents after the position of sort.Filter in the sort order.
+func sortFilter(sort *usecasex.Sort) bson.M {
+	if sort == nil || sort.Filter == nil {
+		return nil
 	}
 
-	if o.Limit == nil || *o.Limit <= 0 {
-		o = o.SetLimit(defaultLimit + 1)
-	} else {
-		// Read one more element so that we can see whether there's a further one
-		o = o.SetLimit(*o.Limit + 1)
+	op := sort.Operator()
+	if sort.Key == "" || sort.Key == idKey {
+		var v any = sort.Filter.ID
+		if sort.Filter.ID == "" {
+			v = sort.Filter.Value
+		}
+		return bson.M{idKey: bson.M{op: v}}
 	}
+	if sort.Filter.ID == "" {
+		return bson.M{sort.Key: bson.M{op: sort.Filter.Value}}
+	}
+	return bson.M{
+		"$or": []bson.M{
+			{sort.Key: bson.M{op: sort.Filter.Value}},
+			{sort.Key: sort.Filter.Value, idKey: bson.M{op: sort.Filter.ID}},
+		},
+	}
+}
 
-	// sort
+func sortOptionsFrom(sort *string, reverted bool) bson.D {
 	sortDirection := 1
 	if reverted {
 		sortDirection = -1
@@ -166,8 +245,5 @@ func findOptionsFromPagination(p usecasex.Pagination, sort *string, reverted boo
 		sortOptions = append(sortOptions, bson.E{Key: *sort, Value: sortDirection})
 	}
 	sortOptions = append(sortOptions, bson.E{Key: idKey, Value: sortDirection})
-
-	o = o.SetCollation(&options.Collation{Strength: 1, Locale: "en"}).SetSort(sortOptions)
-
-	return o
+	return sortOptions
 }
@@ -0,0 +1,133 @@
+package mongox
+
+import (
+	"github.com/reearth/reearthx/usecasex"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// DefaultPaginationLimit is the limit used when neither first nor last of a cursor pagination, nor the limit of an offset pagination is specified.
+const DefaultPaginationLimit int64 = 20
+
+// PaginationQuery is a query built from a pagination, a base filter and a sort.
+type PaginationQuery struct {
+	Filter any
+	Sort   bson.D
+	Skip   int64
+	Limit  int64
+	// Reversed is true if the query is sorted in the reverse order to paginate backward.
+	// Results should be passed to RestoreOrder.
+	Reversed bool
+}
+
+// NewPaginationQuery returns a query that merges the base filter and the condition of the cursor.
+// A cursor other than id needs to be encoded by usecasex.CompositeCursor when the sort key is specified.
+// The offset takes precedence over the cursor when both are set.
+func NewPaginationQuery(p *usecasex.Pagination, filter any, sort *usecasex.Sort) (*PaginationQuery, error) {
+	key := idKey
+	if sort != nil && sort.Key != "" {
+		key = sort.Key
+	}
+
+	if p == nil || p.Cursor == nil || p.Offset != nil {
+		q := &PaginationQuery{
+			Filter: filter,
+			Sort:   sortOptionsFrom(&key, sort != nil && sort.Reverted),
+		}
+		if p != nil && p.Offset != nil {
+			q.Skip, q.Limit = p.Offset.SkipLimit()
+			if q.Limit <= 0 {
+				q.Limit = DefaultPaginationLimit
+			}
+		}
+		return q, nil
+	}
+
+	if err := p.Cursor.Validate(); err != nil {
+		return nil, err
+	}
+
+	rev := sort != nil && sort.Reverted
+	forward := &usecasex.Sort{Key: key, Reverted: rev}
+	backward := &usecasex.Sort{Key: key, Reverted: !rev}
+
+	after, err := cursorCondition(key, forward.Operator(), p.Cursor.After)
+	if err != nil {
+		return nil, err
+	}
+	before, err := cursorCondition(key, backward.Operator(), p.Cursor.Before)
+	if err != nil {
+		return nil, err
+	}
+
+	reversed := p.Cursor.First == nil && (p.Cursor.Last != nil || p.Cursor.Before != nil)
+	s, limit := forward, p.Cursor.First
+	if reversed {
+		s, limit = backward, p.Cursor.Last
+	}
+
+	q := &PaginationQuery{
+		Filter:   andFilter(filter, after, before),
+		Sort:     sortOptionsFrom(&key, s.Reverted),
+		Limit:    DefaultPaginationLimit,
+		Reversed: reversed,
+	}
+	if limit != nil && *limit > 0 {
+		q.Limit = *limit
+	}
+	return q, nil
+}
+
+// RestoreOrder reverses the results of the query if it was sorted in the reverse order.
+func RestoreOrder[T any](q *PaginationQuery, items []T) []T {
+	if q == nil || !q.Reversed {
+		return items
+	}
+	res := make([]T, len(items))
+	for i, item := range items {
+		res[len(items)-1-i] = item
+	}
+	return res
+}
+
+func cursorCondition(key, op string, cursor *usecasex.Cursor) (bson.M, error) {
+	if cursor == nil {
+		return nil, nil
+	}
+
+	cc, err := usecasex.DecodeCompositeCursor(*cursor)
+	if err != nil {
+		if key != idKey {
+			return nil, usecasex.ErrInvalidCursor
+		}
+		return bson.M{idKey: bson.M{op: string(*cursor)}}, nil
+	}
+
+	if key == idKey {
+		return bson.M{idKey: bson.M{op: cc.ID}}, nil
+	}
+	return bson.M{
+		"$or": []bson.M{
+			{key: bson.M{op: cc.Value}},
+			{key: cc.Value, idKey: bson.M{op: cc.ID}},
+		},
+	}, nil
+}
+
+func andFilter(filter any, conds ...bson.M) any {
+	var filters []any
+	if !isEmptyFilter(filter) {
+		filters = append(filters, filter)
+	}
+	for _, c := range conds {
+		if c != nil {
+			filters = append(filters, c)
+		}
+	}
+	if len(filters) == 0 {
+		return filter
+	}
+	if len(filters) == 1 {
+		return filters[0]
+	}
+	return bson.M{"$and": filters}
+}
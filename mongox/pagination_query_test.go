@@ -0,0 +1,155 @@
+package mongox
+
+import (
+	"testing"
+
+	"github.com/reearth/reearthx/usecasex"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestNewPaginationQuery(t *testing.T) {
+	base := bson.M{"workspace": "w"}
+	cc := lo.Must(usecasex.CompositeCursor{Value: 10, ID: "x"}.Encode())
+
+	tests := []struct {
+		name    string
+		p       *usecasex.Pagination
+		sort    *usecasex.Sort
+		want    *PaginationQuery
+		wantErr error
+	}{
+		{
+			name: "nil",
+			want: &PaginationQuery{
+				Filter: base,
+				Sort:   bson.D{{Key: "id", Value: 1}},
+			},
+		},
+		{
+			name: "offset",
+			p:    usecasex.OffsetPagination{Offset: 10, Limit: 5}.Wrap(),
+			sort: &usecasex.Sort{Key: "n", Reverted: true},
+			want: &PaginationQuery{
+				Filter: base,
+				Sort:   bson.D{{Key: "n", Value: -1}, {Key: "id", Value: -1}},
+				Skip:   10,
+				Limit:  5,
+			},
+		},
+		{
+			name: "offset without limit",
+			p:    &usecasex.Pagination{Offset: &usecasex.OffsetPagination{Offset: 10}, Cursor: &usecasex.CursorPagination{After: usecasex.Cursor("x").Ref()}},
+			want: &PaginationQuery{
+				Filter: base,
+				Sort:   bson.D{{Key: "id", Value: 1}},
+				Skip:   10,
+				Limit:  DefaultPaginationLimit,
+			},
+		},
+		{
+			name: "forward without cursor",
+			p:    usecasex.CursorPagination{First: lo.ToPtr(int64(5))}.Wrap(),
+			want: &PaginationQuery{
+				Filter: base,
+				Sort:   bson.D{{Key: "id", Value: 1}},
+				Limit:  5,
+			},
+		},
+		{
+			name: "forward",
+			p:    usecasex.CursorPagination{First: lo.ToPtr(int64(5)), After: usecasex.Cursor("x").Ref()}.Wrap(),
+			want: &PaginationQuery{
+				Filter: bson.M{"$and": []any{base, bson.M{"id": bson.M{"$gt": "x"}}}},
+				Sort:   bson.D{{Key: "id", Value: 1}},
+				Limit:  5,
+			},
+		},
+		{
+			name: "forward with sort key",
+			p:    usecasex.CursorPagination{First: lo.ToPtr(int64(5)), After: &cc}.Wrap(),
+			sort: &usecasex.Sort{Key: "n"},
+			want: &PaginationQuery{
+				Filter: bson.M{"$and": []any{base, bson.M{"$or": []bson.M{
+					{"n": bson.M{"$gt": int32(10)}},
+					{"n": int32(10), "id": bson.M{"$gt": "x"}},
+				}}}},
+				Sort:  bson.D{{Key: "n", Value: 1}, {Key: "id", Value: 1}},
+				Limit: 5,
+			},
+		},
+		{
+			name: "backward",
+			p:    usecasex.CursorPagination{Last: lo.ToPtr(int64(5)), Before: usecasex.Cursor("x").Ref()}.Wrap(),
+			want: &PaginationQuery{
+				Filter:   bson.M{"$and": []any{base, bson.M{"id": bson.M{"$lt": "x"}}}},
+				Sort:     bson.D{{Key: "id", Value: -1}},
+				Limit:    5,
+				Reversed: true,
+			},
+		},
+		{
+			name: "backward with reverted sort key",
+			p:    usecasex.CursorPagination{Last: lo.ToPtr(int64(5)), Before: &cc}.Wrap(),
+			sort: &usecasex.Sort{Key: "n", Reverted: true},
+			want: &PaginationQuery{
+				Filter: bson.M{"$and": []any{base, bson.M{"$or": []bson.M{
+					{"n": bson.M{"$gt": int32(10)}},
+					{"n": int32(10), "id": bson.M{"$gt": "x"}},
+				}}}},
+				Sort:     bson.D{{Key: "n", Value: 1}, {Key: "id", Value: 1}},
+				Limit:    5,
+				Reversed: true,
+			},
+		},
+		{
+			name: "default limit",
+			p:    usecasex.CursorPagination{Before: usecasex.Cursor("x").Ref()}.Wrap(),
+			want: &PaginationQuery{
+				Filter:   bson.M{"$and": []any{base, bson.M{"id": bson.M{"$lt": "x"}}}},
+				Sort:     bson.D{{Key: "id", Value: -1}},
+				Limit:    DefaultPaginationLimit,
+				Reversed: true,
+			},
+		},
+		{
+			name:    "plain cursor with sort key",
+			p:       usecasex.CursorPagination{First: lo.ToPtr(int64(5)), After: usecasex.Cursor("x").Ref()}.Wrap(),
+			sort:    &usecasex.Sort{Key: "n"},
+			wantErr: usecasex.ErrInvalidCursor,
+		},
+		{
+			name:    "invalid",
+			p:       usecasex.CursorPagination{First: lo.ToPtr(int64(5)), Last: lo.ToPtr(int64(5))}.Wrap(),
+			wantErr: usecasex.ErrFirstAndLast,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := NewPaginationQuery(tt.p, base, tt.sort)
+			if tt.wantErr != nil {
+				assert.Same(t, tt.wantErr, err)
+				assert.Nil(t, got)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestNewPaginationQuery_EmptyFilter(t *testing.T) {
+	got, err := NewPaginationQuery(usecasex.CursorPagination{First: lo.ToPtr(int64(5)), After: usecasex.Cursor("x").Ref()}.Wrap(), nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, bson.M{"id": bson.M{"$gt": "x"}}, got.Filter)
+}
+
+func TestRestoreOrder(t *testing.T) {
+	assert.Equal(t, []int{1, 2, 3}, RestoreOrder(&PaginationQuery{}, []int{1, 2, 3}))
+	assert.Equal(t, []int{3, 2, 1}, RestoreOrder(&PaginationQuery{Reversed: true}, []int{1, 2, 3}))
+	assert.Equal(t, []int{1}, RestoreOrder(nil, []int{1}))
+}
@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/reearth/reearthx/mongox/mongotest"
+	"github.com/reearth/reearthx/rerror"
 	"github.com/reearth/reearthx/usecasex"
 	"github.com/samber/lo"
 	"github.com/stretchr/testify/assert"
@@ -27,6 +28,11 @@ func TestClientCollection_Paginate(t *testing.T) {
 	assert.Nil(t, got)
 	assert.NoError(t, goterr)
 
+	// invalid
+	got, goterr = c.Paginate(ctx, nil, nil, usecasex.CursorPagination{First: lo.ToPtr(int64(1)), Last: lo.ToPtr(int64(1))}.Wrap(), nil)
+	assert.Nil(t, got)
+	assert.Same(t, rerror.ErrInvalidParams, goterr)
+
 	// cursor: first
 	p := usecasex.CursorPagination{
 		First: lo.ToPtr(int64(1)),
@@ -57,7 +63,7 @@ func TestClientCollection_Paginate(t *testing.T) {
 		StartCursor:     usecasex.Cursor("c").Ref(),
 		EndCursor:       usecasex.Cursor("c").Ref(),
 		HasNextPage:     false,
-		HasPreviousPage: false,
+		HasPreviousPage: true,
 	}, got)
 	assert.NoError(t, goterr)
 	assert.Equal(t, []usecasex.Cursor{"c"}, con.Cursors)
@@ -136,11 +142,166 @@ func TestClientCollection_Paginate(t *testing.T) {
 	assert.Equal(t, []usecasex.Cursor{"c", "b", "a"}, con.Cursors)
 }
 
+func TestClientCollection_Paginate_CompositeCursor(t *testing.T) {
+	ctx := context.Background()
+	c := NewCollection(mongotest.Connect(t)(t).Collection("test"))
+
+	// the sort key is not unique
+	_, _ = c.Client().InsertMany(ctx, []any{
+		bson.M{"id": "a", "i": 2},
+		bson.M{"id": "b", "i": 1},
+		bson.M{"id": "c", "i": 1},
+		bson.M{"id": "d", "i": 2},
+	})
+
+	sort := &usecasex.Sort{Key: "i"}
+	var ids []usecasex.Cursor
+	var after *usecasex.Cursor
+	for {
+		con := &consumer{}
+		got, err := c.Paginate(ctx, bson.M{}, sort, usecasex.CursorPagination{First: lo.ToPtr(int64(1)), After: after}.Wrap(), con)
+		assert.NoError(t, err)
+		ids = append(ids, con.Cursors...)
+
+		cc, err := usecasex.DecodeCompositeCursor(*got.EndCursor)
+		assert.NoError(t, err)
+		assert.Equal(t, string(con.Cursors[0]), cc.ID)
+
+		if !got.HasNextPage {
+			break
+		}
+		after = got.EndCursor
+	}
+	assert.Equal(t, []usecasex.Cursor{"b", "c", "a", "d"}, ids)
+
+	// a plain id cursor is still accepted
+	con := &consumer{}
+	_, err := c.Paginate(ctx, bson.M{}, sort, usecasex.CursorPagination{First: lo.ToPtr(int64(2)), After: usecasex.Cursor("c").Ref()}.Wrap(), con)
+	assert.NoError(t, err)
+	assert.Equal(t, []usecasex.Cursor{"a", "d"}, con.Cursors)
+}
+
+func TestClientCollection_PaginateOffset(t *testing.T) {
+	ctx := context.Background()
+	initDB := mongotest.Connect(t)
+	c := NewCollection(initDB(t).Collection("test"))
+
+	// seeds
+	seeds := []string{"a", "b", "c", "d"}
+	_, _ = c.Client().InsertMany(ctx, lo.Map(seeds, func(s string, i int) any {
+		return bson.M{"id": s, "i": len(seeds) - i, "odd": i%2 == 1}
+	}))
+
+	// nil
+	got, goterr := c.PaginateOffset(ctx, bson.M{}, nil, nil, nil)
+	assert.Equal(t, int64(0), got)
+	assert.NoError(t, goterr)
+
+	// invalid params
+	got, goterr = c.PaginateOffset(ctx, bson.M{}, nil, &usecasex.OffsetPagination{Offset: -1, Limit: 1}, &consumer{})
+	assert.Equal(t, int64(0), got)
+	assert.Same(t, rerror.ErrInvalidParams, goterr)
+
+	got, goterr = c.PaginateOffset(ctx, bson.M{}, nil, &usecasex.OffsetPagination{Offset: 0, Limit: -1}, &consumer{})
+	assert.Equal(t, int64(0), got)
+	assert.Same(t, rerror.ErrInvalidParams, goterr)
+
+	// offset and limit
+	con := &consumer{}
+	got, goterr = c.PaginateOffset(ctx, bson.M{}, nil, &usecasex.OffsetPagination{Offset: 1, Limit: 2}, con)
+	assert.Equal(t, int64(4), got)
+	assert.NoError(t, goterr)
+	assert.Equal(t, []usecasex.Cursor{"b", "c"}, con.Cursors)
+
+	// filter and sort: total count reflects the whole filter
+	con = &consumer{}
+	got, goterr = c.PaginateOffset(ctx, bson.M{"odd": false}, &usecasex.Sort{Key: "i"}, &usecasex.OffsetPagination{Offset: 0, Limit: 1}, con)
+	assert.Equal(t, int64(2), got)
+	assert.NoError(t, goterr)
+	assert.Equal(t, []usecasex.Cursor{"c"}, con.Cursors)
+}
+
+func TestClientCollection_Paginate_OneConsumer(t *testing.T) {
+	ctx := context.Background()
+	c := NewCollection(mongotest.Connect(t)(t).Collection("test"))
+	_, _ = c.Client().InsertMany(ctx, []any{
+		bson.M{"id": "a"},
+		bson.M{"id": "b"},
+		bson.M{"id": "c"},
+	})
+
+	// the page ends with the document consumed by OneConsumer
+	con := &OneConsumer[bson.M]{}
+	got, err := c.Paginate(ctx, bson.M{}, nil, usecasex.CursorPagination{First: lo.ToPtr(int64(2))}.Wrap(), con)
+	assert.NoError(t, err)
+	assert.Equal(t, &usecasex.PageInfo{
+		TotalCount:  3,
+		StartCursor: usecasex.Cursor("a").Ref(),
+		EndCursor:   usecasex.Cursor("a").Ref(),
+		HasNextPage: true,
+	}, got)
+	res, err := con.Result()
+	assert.NoError(t, err)
+	assert.Equal(t, "a", res["id"])
+
+	con = &OneConsumer[bson.M]{}
+	count, err := c.PaginateOffset(ctx, bson.M{}, nil, &usecasex.OffsetPagination{Offset: 1, Limit: 2}, con)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), count)
+	res, err = con.Result()
+	assert.NoError(t, err)
+	assert.Equal(t, "b", res["id"])
+}
+
 type consumer struct {
 	Cursors []usecasex.Cursor
 }
 
 func (c *consumer) Consume(b bson.Raw) error {
-	c.Cursors = append(c.Cursors, lo.FromPtr(lo.Must(getCursor(b))))
+	c.Cursors = append(c.Cursors, lo.FromPtr(lo.Must(getCursor(b, nil))))
 	return nil
 }
+
+func TestClientCollection_PaginateOffset_SortFilter(t *testing.T) {
+	ctx := context.Background()
+	c := NewCollection(mongotest.Connect(t)(t).Collection("test"))
+	_, _ = c.Client().InsertMany(ctx, []any{
+		bson.M{"id": "a", "i": 1},
+		bson.M{"id": "b", "i": 2},
+		bson.M{"id": "c", "i": 2},
+		bson.M{"id": "d", "i": 3},
+	})
+
+	con := &consumer{}
+	got, err := c.PaginateOffset(ctx, bson.M{}, &usecasex.Sort{Key: "i", Filter: &usecasex.SortFilter{Value: 2, ID: "b"}}, &usecasex.OffsetPagination{Limit: 10}, con)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), got)
+	assert.Equal(t, []usecasex.Cursor{"c", "d"}, con.Cursors)
+
+	con = &consumer{}
+	_, err = c.PaginateOffset(ctx, bson.M{}, &usecasex.Sort{Key: "i", Reverted: true, Filter: &usecasex.SortFilter{Value: 2}}, &usecasex.OffsetPagination{Limit: 10}, con)
+	assert.NoError(t, err)
+	assert.Equal(t, []usecasex.Cursor{"a"}, con.Cursors)
+}
+
+func TestSortFilter(t *testing.T) {
+	assert.Nil(t, sortFilter(nil))
+	assert.Nil(t, sortFilter(usecasex.ByID()))
+	assert.Equal(t, bson.M{"id": bson.M{"$gt": "a"}}, sortFilter(&usecasex.Sort{Key: "id", Filter: &usecasex.SortFilter{ID: "a"}}))
+	assert.Equal(t, bson.M{"id": bson.M{"$lt": "a"}}, sortFilter(&usecasex.Sort{Reverted: true, Filter: &usecasex.SortFilter{Value: "a"}}))
+	assert.Equal(t, bson.M{"i": bson.M{"$gt": 1}}, sortFilter(&usecasex.Sort{Key: "i", Filter: &usecasex.SortFilter{Value: 1}}))
+	assert.Equal(t, bson.M{
+		"$or": []bson.M{
+			{"i": bson.M{"$lt": 1}},
+			{"i": 1, "id": bson.M{"$lt": "a"}},
+		},
+	}, sortFilter(&usecasex.Sort{Key: "i", Reverted: true, Filter: &usecasex.SortFilter{Value: 1, ID: "a"}}))
+}
+
+func TestSortOptionsFrom(t *testing.T) {
+	assert.Equal(t, bson.D{{Key: "id", Value: 1}}, sortOptionsFrom(nil, false))
+	assert.Equal(t, bson.D{{Key: "id", Value: -1}}, sortOptionsFrom(lo.ToPtr("id"), true))
+	// id is always the last key to break ties
+	assert.Equal(t, bson.D{{Key: "i", Value: 1}, {Key: "id", Value: 1}}, sortOptionsFrom(lo.ToPtr("i"), false))
+	assert.Equal(t, bson.D{{Key: "i", Value: -1}, {Key: "id", Value: -1}}, sortOptionsFrom(lo.ToPtr("i"), true))
+}
@@ -0,0 +1,141 @@
+package mongox
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/reearth/reearthx/rerror"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Operation types of change events
+const (
+	OperationInsert  = "insert"
+	OperationUpdate  = "update"
+	OperationReplace = "replace"
+	OperationDelete  = "delete"
+)
+
+type ProjectorHandler func(ctx context.Context, change bson.Raw) error
+
+// Projector watches changes of a collection and applies them to handlers registered per operation type, e.g. to maintain read models.
+// The resume token of the last applied change is stored in the tokens collection with the name of the projector,
+// so that a restarted projector continues from the change after it instead of replaying all changes.
+type Projector struct {
+	name     string
+	c        *Collection
+	tokens   *Collection
+	lock     sync.RWMutex
+	handlers map[string]ProjectorHandler
+}
+
+type projectorTokenDocument struct {
+	ID    string   `bson:"id"`
+	Token bson.Raw `bson:"token"`
+}
+
+func NewProjector(name string, c, tokens *Collection) *Projector {
+	return &Projector{
+		name:     name,
+		c:        c,
+		tokens:   tokens,
+		handlers: map[string]ProjectorHandler{},
+	}
+}
+
+// On sets the handler of the operation type such as OperationInsert. It replaces the handler already registered with the same operation type.
+// Insert, update and replace events carry the current document as "fullDocument".
+func (p *Projector) On(opType string, handler ProjectorHandler) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.handlers[opType] = handler
+}
+
+// ResumeToken returns the resume token of the last applied change, or nil if no change has been applied yet.
+func (p *Projector) ResumeToken(ctx context.Context) (bson.Raw, error) {
+	consumer := &OneConsumer[projectorTokenDocument]{}
+	if err := p.tokens.FindOne(ctx, bson.M{idKey: p.name}, consumer); err != nil {
+		if errors.Is(err, rerror.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	d, err := consumer.Result()
+	if err != nil {
+		return nil, err
+	}
+	return d.Token, nil
+}
+
+// Run watches changes from the stored resume token and applies them until the context is done or a handler returns an error.
+// The resume token is stored after each change is applied, so a change whose handler failed is applied again by the next Run.
+// Changes whose operation type has no handler are not delivered.
+func (p *Projector) Run(ctx context.Context) error {
+	token, err := p.ResumeToken(ctx)
+	if err != nil {
+		return err
+	}
+
+	opts := options.ChangeStream().SetFullDocument(options.UpdateLookup)
+	if token != nil {
+		opts.SetResumeAfter(token)
+	}
+
+	stream, err := p.c.client.Watch(ctx, p.pipeline(), opts)
+	if err != nil {
+		return wrapError(err)
+	}
+	defer func() {
+		_ = stream.Close(context.Background())
+	}()
+
+	for stream.Next(ctx) {
+		if err := p.apply(ctx, stream.Current); err != nil {
+			return err
+		}
+		if err := p.saveResumeToken(ctx, stream.ResumeToken()); err != nil {
+			return err
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return wrapError(stream.Err())
+}
+
+func (p *Projector) pipeline() mongo.Pipeline {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	types := make([]string, 0, len(p.handlers))
+	for t := range p.handlers {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"operationType": bson.M{"$in": types}}}},
+	}
+}
+
+func (p *Projector) apply(ctx context.Context, change bson.Raw) error {
+	opType, _ := change.Lookup("operationType").StringValueOK()
+
+	p.lock.RLock()
+	h, ok := p.handlers[opType]
+	p.lock.RUnlock()
+	if !ok {
+		return nil
+	}
+	return h(ctx, change)
+}
+
+func (p *Projector) saveResumeToken(ctx context.Context, token bson.Raw) error {
+	return p.tokens.SaveOne(ctx, p.name, projectorTokenDocument{
+		ID:    p.name,
+		Token: token,
+	})
+}
@@ -0,0 +1,97 @@
+package mongox
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/reearth/reearthx/mongox/mongotest"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestProjector_pipeline(t *testing.T) {
+	p := NewProjector("test", &Collection{}, &Collection{})
+	p.On(OperationUpdate, func(context.Context, bson.Raw) error { return nil })
+	p.On(OperationInsert, func(context.Context, bson.Raw) error { return nil })
+
+	assert.Equal(t, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"operationType": bson.M{"$in": []string{"insert", "update"}}}}},
+	}, p.pipeline())
+}
+
+func TestProjector_apply(t *testing.T) {
+	ctx := context.Background()
+	p := NewProjector("test", &Collection{}, &Collection{})
+	var got []string
+	p.On(OperationInsert, func(_ context.Context, change bson.Raw) error {
+		got = append(got, change.Lookup("documentKey", "_id").StringValue())
+		return nil
+	})
+	errTest := errors.New("test")
+	p.On(OperationDelete, func(context.Context, bson.Raw) error { return errTest })
+
+	insert, _ := bson.Marshal(bson.M{"operationType": "insert", "documentKey": bson.M{"_id": "a"}})
+	update, _ := bson.Marshal(bson.M{"operationType": "update", "documentKey": bson.M{"_id": "b"}})
+	remove, _ := bson.Marshal(bson.M{"operationType": "delete", "documentKey": bson.M{"_id": "c"}})
+
+	assert.NoError(t, p.apply(ctx, insert))
+	assert.NoError(t, p.apply(ctx, update))
+	assert.Same(t, errTest, p.apply(ctx, remove))
+	assert.Equal(t, []string{"a"}, got)
+}
+
+func TestProjector_Run(t *testing.T) {
+	db := mongotest.Connect(t)(t)
+	c := NewCollection(db.Collection("test"))
+	tokens := NewCollection(db.Collection("projector"))
+
+	run := func(p *Projector) (context.CancelFunc, chan error) {
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error, 1)
+		go func() { done <- p.Run(ctx) }()
+		return cancel, done
+	}
+
+	ids := make(chan string, 10)
+	newProjector := func() *Projector {
+		p := NewProjector("test", c, tokens)
+		p.On(OperationInsert, func(_ context.Context, change bson.Raw) error {
+			ids <- change.Lookup("fullDocument", "id").StringValue()
+			return nil
+		})
+		return p
+	}
+	receive := func() string {
+		select {
+		case id := <-ids:
+			return id
+		case <-time.After(10 * time.Second):
+			t.Fatal("timeout")
+			return ""
+		}
+	}
+
+	ctx := context.Background()
+	p := newProjector()
+	cancel, done := run(p)
+	// wait for the stream to open
+	time.Sleep(500 * time.Millisecond)
+	assert.NoError(t, c.SaveOne(ctx, "a", bson.M{"id": "a"}))
+	assert.Equal(t, "a", receive())
+	assert.Eventually(t, func() bool {
+		token, err := p.ResumeToken(ctx)
+		return err == nil && token != nil
+	}, 10*time.Second, 100*time.Millisecond)
+	cancel()
+	assert.Same(t, context.Canceled, <-done)
+
+	// changes made while the projector is stopped are applied after restart
+	assert.NoError(t, c.SaveOne(ctx, "b", bson.M{"id": "b"}))
+	cancel, done = run(newProjector())
+	assert.Equal(t, "b", receive())
+	cancel()
+	assert.Same(t, context.Canceled, <-done)
+}
@@ -0,0 +1,66 @@
+package mongox
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"github.com/reearth/reearthx/rerror"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+)
+
+// LoadRelated finds documents of the related collection whose foreignField matches the localField of any of the parents
+// with a single query, and passes them to the consumer in no particular order. If localField is an array, each element is used as a key.
+// Unlike $lookup, the related collection can be in another database.
+func LoadRelated(ctx context.Context, parents []bson.Raw, localField, foreignField string, related *Collection, consumer Consumer) error {
+	if localField == "" || foreignField == "" || related == nil {
+		return rerror.ErrInvalidParams
+	}
+
+	keys := relatedKeys(parents, localField)
+	if len(keys) == 0 {
+		if err := consumer.Consume(nil); err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+		return nil
+	}
+
+	return related.Find(ctx, bson.M{foreignField: bson.M{"$in": keys}}, consumer)
+}
+
+func relatedKeys(parents []bson.Raw, field string) []any {
+	seen := map[string]struct{}{}
+	var keys []any
+
+	add := func(v bson.RawValue) {
+		if v.Type == bsontype.Null || v.Type == bsontype.Undefined {
+			return
+		}
+		k := rawValueKey(v)
+		if _, ok := seen[k]; ok {
+			return
+		}
+		seen[k] = struct{}{}
+		keys = append(keys, v)
+	}
+
+	for _, p := range parents {
+		v, ok := lookupField(p, field)
+		if !ok {
+			continue
+		}
+		if v.Type == bsontype.Array {
+			values, err := v.Array().Values()
+			if err != nil {
+				continue
+			}
+			for _, e := range values {
+				add(e)
+			}
+			continue
+		}
+		add(v)
+	}
+	return keys
+}
@@ -0,0 +1,70 @@
+package mongox
+
+import (
+	"context"
+	"testing"
+
+	"github.com/reearth/reearthx/mongox/mongotest"
+	"github.com/reearth/reearthx/rerror"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestLoadRelated(t *testing.T) {
+	ctx := context.Background()
+	db := mongotest.Connect(t)(t)
+	related := NewCollection(db.Collection("children"))
+	_, _ = related.Client().InsertMany(ctx, []any{
+		bson.M{"id": "x", "parent": "a"},
+		bson.M{"id": "y", "parent": "b"},
+		bson.M{"id": "z", "parent": "c"},
+	})
+
+	parents := lo.Map([]bson.M{
+		{"id": "a", "children": bson.A{"x", "y"}},
+		{"id": "b", "children": bson.A{"y"}},
+		{"id": "d"},
+	}, func(m bson.M, _ int) bson.Raw {
+		return lo.Must(bson.Marshal(m))
+	})
+
+	type child struct{ ID, Parent string }
+
+	cons := &SliceConsumer[child]{}
+	assert.NoError(t, LoadRelated(ctx, parents, "children", "id", related, cons))
+	assert.ElementsMatch(t, []child{{ID: "x", Parent: "a"}, {ID: "y", Parent: "b"}}, cons.Result)
+
+	cons = &SliceConsumer[child]{}
+	assert.NoError(t, LoadRelated(ctx, parents, "id", "parent", related, cons))
+	assert.ElementsMatch(t, []child{{ID: "x", Parent: "a"}, {ID: "y", Parent: "b"}}, cons.Result)
+
+	cons = &SliceConsumer[child]{}
+	assert.NoError(t, LoadRelated(ctx, nil, "id", "parent", related, cons))
+	assert.Empty(t, cons.Result)
+
+	assert.Same(t, rerror.ErrInvalidParams, LoadRelated(ctx, parents, "", "parent", related, cons))
+}
+
+func TestRelatedKeys(t *testing.T) {
+	parents := lo.Map([]bson.M{
+		{"id": "a", "c": bson.A{"x", "y"}, "p": bson.M{"q": 1}},
+		{"id": "b", "c": bson.A{"y"}, "p": bson.M{"q": 1}},
+		{"id": "a", "c": nil},
+	}, func(m bson.M, _ int) bson.Raw {
+		return lo.Must(bson.Marshal(m))
+	})
+
+	values := func(keys []any) []any {
+		return lo.Map(keys, func(k any, _ int) any {
+			var v any
+			_ = k.(bson.RawValue).Unmarshal(&v)
+			return v
+		})
+	}
+
+	assert.Equal(t, []any{"a", "b"}, values(relatedKeys(parents, "id")))
+	assert.Equal(t, []any{"x", "y"}, values(relatedKeys(parents, "c")))
+	assert.Equal(t, []any{int32(1)}, values(relatedKeys(parents, "p.q")))
+	assert.Empty(t, relatedKeys(parents, "z"))
+}
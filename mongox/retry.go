@@ -0,0 +1,41 @@
+package mongox
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/reearth/reearthx/rerror"
+	"github.com/reearth/reearthx/usecasex"
+	"go.mongodb.org/mongo-driver/x/mongo/driver"
+)
+
+// Retry calls fn up to attempts times while it fails with a transient error such as a network error.
+// The wait between attempts starts from backoff and doubles each time. It stops waiting and returns the error of ctx when ctx is done.
+func Retry(ctx context.Context, attempts int, backoff time.Duration, fn func() error) error {
+	wait := backoff
+	for i := 1; ; i++ {
+		err := fn()
+		if err == nil || i >= attempts || !IsTransientError(err) {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+		wait *= 2
+	}
+}
+
+// IsTransientError returns true if the operation that returned err may succeed by retrying it.
+func IsTransientError(err error) bool {
+	if err == nil || errors.Is(err, rerror.ErrNotFound) || errors.Is(err, rerror.ErrInvalidParams) {
+		return false
+	}
+	return errors.Is(err, usecasex.ErrTransaction) ||
+		errorHasLabel(err, driver.NetworkError) ||
+		errorHasLabel(err, driver.RetryableWriteError) ||
+		errorHasLabel(err, driver.TransientTransactionError)
+}
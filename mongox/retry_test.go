@@ -0,0 +1,72 @@
+package mongox
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/reearth/reearthx/rerror"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/x/mongo/driver"
+)
+
+func failingFunc(failures int, err error) (func() error, *int) {
+	calls := 0
+	return func() error {
+		calls++
+		if calls <= failures {
+			return err
+		}
+		return nil
+	}, &calls
+}
+
+func TestRetry(t *testing.T) {
+	ctx := context.Background()
+	networkErr := testLabeledError{label: driver.NetworkError}
+
+	// succeeds after failures
+	fn, calls := failingFunc(2, networkErr)
+	assert.NoError(t, Retry(ctx, 3, time.Millisecond, fn))
+	assert.Equal(t, 3, *calls)
+
+	// attempts are exhausted
+	fn, calls = failingFunc(3, networkErr)
+	assert.Equal(t, networkErr, Retry(ctx, 3, time.Millisecond, fn))
+	assert.Equal(t, 3, *calls)
+
+	// wrapped errors are retried too
+	fn, calls = failingFunc(1, rerror.ErrInternalBy(networkErr))
+	assert.NoError(t, Retry(ctx, 3, time.Millisecond, fn))
+	assert.Equal(t, 2, *calls)
+
+	// not transient
+	fn, calls = failingFunc(1, rerror.ErrNotFound)
+	assert.Same(t, rerror.ErrNotFound, Retry(ctx, 3, time.Millisecond, fn))
+	assert.Equal(t, 1, *calls)
+
+	err := errors.New("test")
+	fn, calls = failingFunc(1, err)
+	assert.Same(t, err, Retry(ctx, 3, time.Millisecond, fn))
+	assert.Equal(t, 1, *calls)
+
+	// canceled while waiting
+	ctx2, cancel := context.WithCancel(ctx)
+	cancel()
+	fn, calls = failingFunc(1, networkErr)
+	assert.Same(t, context.Canceled, Retry(ctx2, 3, time.Hour, fn))
+	assert.Equal(t, 1, *calls)
+}
+
+func TestIsTransientError(t *testing.T) {
+	assert.True(t, IsTransientError(testLabeledError{label: driver.NetworkError}))
+	assert.True(t, IsTransientError(testLabeledError{label: driver.RetryableWriteError}))
+	assert.True(t, IsTransientError(testLabeledError{label: driver.TransientTransactionError}))
+	assert.True(t, IsTransientError(wrapError(testLabeledError{label: driver.TransientTransactionError})))
+	assert.False(t, IsTransientError(testLabeledError{label: "a"}))
+	assert.False(t, IsTransientError(rerror.ErrNotFound))
+	assert.False(t, IsTransientError(rerror.ErrInvalidParams))
+	assert.False(t, IsTransientError(errors.New("a")))
+	assert.False(t, IsTransientError(nil))
+}
@@ -0,0 +1,131 @@
+package mongox
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/reearth/reearthx/rerror"
+	"github.com/reearth/reearthx/util"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// SagaLog persists states of saga steps in a collection so that orchestrators can recover incomplete sagas.
+type SagaLog struct {
+	c *Collection
+}
+
+type Saga struct {
+	ID        string     `bson:"id"`
+	Steps     []SagaStep `bson:"steps"`
+	Completed bool       `bson:"completed"`
+	CreatedAt time.Time  `bson:"createdat"`
+	UpdatedAt time.Time  `bson:"updatedat"`
+}
+
+type SagaStep struct {
+	Name        string    `bson:"name"`
+	State       string    `bson:"state"`
+	Compensated bool      `bson:"compensated"`
+	UpdatedAt   time.Time `bson:"updatedat"`
+}
+
+func (s Saga) Step(name string) *SagaStep {
+	for i := range s.Steps {
+		if s.Steps[i].Name == name {
+			return &s.Steps[i]
+		}
+	}
+	return nil
+}
+
+func NewSagaLog(c *Collection) *SagaLog {
+	return &SagaLog{c: c}
+}
+
+// Init creates the unique index on the saga ID and the index to find incomplete sagas.
+func (l *SagaLog) Init(ctx context.Context) error {
+	_, err := l.c.EnsureIndexes(ctx, []string{idKey}, []string{"completed"})
+	return err
+}
+
+// Begin starts the saga. It does nothing if the saga has already begun, so it is safe to retry.
+func (l *SagaLog) Begin(ctx context.Context, sagaID string) error {
+	now := util.Now()
+	_, err := l.c.InsertIdempotent(ctx, sagaID, Saga{
+		ID:        sagaID,
+		Steps:     []SagaStep{},
+		CreatedAt: now,
+		UpdatedAt: now,
+	})
+	return err
+}
+
+// RecordStep sets the state of the step, adding the step if it has not been recorded yet.
+func (l *SagaLog) RecordStep(ctx context.Context, sagaID, step, state string) error {
+	now := util.Now()
+
+	// update the existing step
+	err := l.c.RawUpdateOne(ctx, bson.M{idKey: sagaID, "steps.name": step}, bson.M{"$set": bson.M{
+		"steps.$.state":     state,
+		"steps.$.updatedat": now,
+		"updatedat":         now,
+	}})
+	if !errors.Is(err, rerror.ErrNotFound) {
+		return err
+	}
+
+	// add the step only if it is still missing, as it may have been added concurrently
+	err = l.c.RawUpdateOne(ctx, bson.M{idKey: sagaID, "steps.name": bson.M{"$ne": step}}, bson.M{
+		"$push": bson.M{"steps": SagaStep{Name: step, State: state, UpdatedAt: now}},
+		"$set":  bson.M{"updatedat": now},
+	})
+	if !errors.Is(err, rerror.ErrNotFound) {
+		return err
+	}
+
+	count, err := l.c.Count(ctx, bson.M{idKey: sagaID})
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return rerror.ErrNotFound
+	}
+	return l.RecordStep(ctx, sagaID, step, state)
+}
+
+// Compensated marks the step as compensated. It returns rerror.ErrNotFound if the step has not been recorded.
+func (l *SagaLog) Compensated(ctx context.Context, sagaID, step string) error {
+	now := util.Now()
+	return l.c.RawUpdateOne(ctx, bson.M{idKey: sagaID, "steps.name": step}, bson.M{"$set": bson.M{
+		"steps.$.compensated": true,
+		"steps.$.updatedat":   now,
+		"updatedat":           now,
+	}})
+}
+
+// Complete marks the saga as completed so that FindIncomplete no longer returns it.
+func (l *SagaLog) Complete(ctx context.Context, sagaID string) error {
+	return l.c.RawUpdateOne(ctx, bson.M{idKey: sagaID}, bson.M{"$set": bson.M{
+		"completed": true,
+		"updatedat": util.Now(),
+	}})
+}
+
+func (l *SagaLog) Find(ctx context.Context, sagaID string) (Saga, error) {
+	c := &OneConsumer[Saga]{}
+	if err := l.c.FindOne(ctx, bson.M{idKey: sagaID}, c); err != nil {
+		return Saga{}, err
+	}
+	return c.Result()
+}
+
+// FindIncomplete returns sagas that have not been completed, oldest first.
+func (l *SagaLog) FindIncomplete(ctx context.Context) ([]Saga, error) {
+	c := NewSliceConsumer[Saga](0)
+	if err := l.c.Find(ctx, bson.M{"completed": false}, c, options.Find().SetSort(bson.D{{Key: "createdat", Value: 1}})); err != nil {
+		return nil, err
+	}
+	return c.Result, nil
+}
@@ -0,0 +1,53 @@
+package mongox
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/reearth/reearthx/mongox/mongotest"
+	"github.com/reearth/reearthx/rerror"
+	"github.com/reearth/reearthx/util"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSaga_Step(t *testing.T) {
+	s := Saga{Steps: []SagaStep{{Name: "a", State: "done"}}}
+	assert.Equal(t, &SagaStep{Name: "a", State: "done"}, s.Step("a"))
+	assert.Nil(t, s.Step("b"))
+}
+
+func TestSagaLog(t *testing.T) {
+	ctx := context.Background()
+	l := NewSagaLog(NewCollection(mongotest.Connect(t)(t).Collection("test")))
+	assert.NoError(t, l.Init(ctx))
+
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	defer util.MockNow(now)()
+
+	assert.NoError(t, l.Begin(ctx, "s1"))
+	assert.NoError(t, l.Begin(ctx, "s1"))
+	assert.NoError(t, l.Begin(ctx, "s2"))
+
+	assert.NoError(t, l.RecordStep(ctx, "s1", "reserve", "pending"))
+	assert.NoError(t, l.RecordStep(ctx, "s1", "charge", "pending"))
+	assert.NoError(t, l.RecordStep(ctx, "s1", "reserve", "done"))
+	assert.NoError(t, l.Compensated(ctx, "s1", "reserve"))
+	assert.Equal(t, rerror.ErrNotFound, l.Compensated(ctx, "s1", "ship"))
+	assert.Equal(t, rerror.ErrNotFound, l.RecordStep(ctx, "s3", "reserve", "done"))
+
+	got, err := l.Find(ctx, "s1")
+	assert.NoError(t, err)
+	assert.Len(t, got.Steps, 2)
+	assert.Equal(t, "done", got.Step("reserve").State)
+	assert.True(t, got.Step("reserve").Compensated)
+	assert.Equal(t, "pending", got.Step("charge").State)
+	assert.False(t, got.Step("charge").Compensated)
+	assert.True(t, now.Equal(got.UpdatedAt))
+
+	assert.NoError(t, l.Complete(ctx, "s2"))
+	incomplete, err := l.FindIncomplete(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"s1"}, lo.Map(incomplete, func(s Saga, _ int) string { return s.ID }))
+}
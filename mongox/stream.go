@@ -0,0 +1,76 @@
+package mongox
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Stream is a pull-based iterator of documents found by FindStream.
+type Stream struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	cursor *mongo.Cursor
+	err    error
+	closed bool
+}
+
+// FindStream finds documents and returns a Stream to iterate them one by one. The stream must be closed after use.
+func (c *Collection) FindStream(ctx context.Context, filter any, opts ...*options.FindOptions) (*Stream, error) {
+	ctx, cancel := c.withTimeout(ctx)
+
+	cursor, err := c.client.Find(ctx, c.notDeletedFilter(filter), append(findOptions, opts...)...)
+	if err != nil {
+		cancel()
+		return nil, wrapError(err)
+	}
+	return &Stream{ctx: ctx, cancel: cancel, cursor: cursor}, nil
+}
+
+// Next prepares the next document to be decoded. It returns false when there are no more documents or an error occurs.
+func (s *Stream) Next() bool {
+	if s.closed || s.err != nil {
+		return false
+	}
+	if s.cursor.Next(s.ctx) {
+		return true
+	}
+	if err := s.cursor.Err(); err != nil && !errors.Is(err, io.EOF) {
+		s.err = wrapError(err)
+	}
+	return false
+}
+
+// Decode decodes the current document into v. A decode error is also returned by Err and stops the iteration.
+func (s *Stream) Decode(v any) error {
+	if s.closed {
+		return errors.New("stream is closed")
+	}
+	if err := s.cursor.Decode(v); err != nil {
+		s.err = err
+		return err
+	}
+	return nil
+}
+
+// Err returns the first error that occurred during the iteration.
+func (s *Stream) Err() error {
+	return s.err
+}
+
+// Close closes the cursor. It is safe to call Close more than once.
+func (s *Stream) Close() error {
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	defer s.cancel()
+
+	if err := s.cursor.Close(s.ctx); err != nil {
+		return wrapError(err)
+	}
+	return nil
+}
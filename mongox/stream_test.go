@@ -0,0 +1,55 @@
+package mongox
+
+import (
+	"context"
+	"testing"
+
+	"github.com/reearth/reearthx/mongox/mongotest"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func TestCollection_FindStream(t *testing.T) {
+	ctx := context.Background()
+	c := NewCollection(mongotest.Connect(t)(t).Collection("test"))
+	_, _ = c.Client().InsertMany(ctx, []any{
+		bson.M{"id": "a", "n": 1},
+		bson.M{"id": "b", "n": 2},
+		bson.M{"id": "c", "n": "x"},
+	})
+
+	s, err := c.FindStream(ctx, bson.M{}, options.Find().SetSort(bson.M{"id": 1}))
+	assert.NoError(t, err)
+
+	var ids []string
+	for s.Next() {
+		var d struct {
+			ID string
+			N  int
+		}
+		if err := s.Decode(&d); err != nil {
+			break
+		}
+		ids = append(ids, d.ID)
+	}
+	assert.Equal(t, []string{"a", "b"}, ids)
+	// "x" cannot be decoded into int
+	assert.Error(t, s.Err())
+	assert.False(t, s.Next())
+
+	assert.NoError(t, s.Close())
+	assert.NoError(t, s.Close())
+	assert.False(t, s.Next())
+
+	// all documents
+	s, err = c.FindStream(ctx, bson.M{"n": bson.M{"$type": "int"}})
+	assert.NoError(t, err)
+	n := 0
+	for s.Next() {
+		n++
+	}
+	assert.NoError(t, s.Err())
+	assert.Equal(t, 2, n)
+	assert.NoError(t, s.Close())
+}
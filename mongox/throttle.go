@@ -0,0 +1,86 @@
+package mongox
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// SetWriteRateLimit paces bulk writes (SaveAll, SaveAllFiltered, UpdateManyMany and their variants) so that they issue at most opsPerSec operations per second on average,
+// splitting them into batches of at most opsPerSec operations and waiting between batches. UpdateMany is counted as one operation.
+// If opsPerSec is zero or negative, writes are not limited, which is the default.
+func (c *Collection) SetWriteRateLimit(opsPerSec int) {
+	if opsPerSec <= 0 {
+		c.throttle = nil
+		return
+	}
+	c.throttle = &writeThrottle{ops: opsPerSec, interval: time.Second / time.Duration(opsPerSec)}
+}
+
+type writeThrottle struct {
+	ops      int
+	interval time.Duration
+	lock     sync.Mutex
+	next     time.Time
+}
+
+// wait blocks until n operations can be issued, reserving the time for them so that concurrent writes share the limit.
+func (t *writeThrottle) wait(ctx context.Context, n int) error {
+	if t == nil {
+		return nil
+	}
+
+	t.lock.Lock()
+	now := time.Now()
+	if t.next.Before(now) {
+		t.next = now
+	}
+	start := t.next
+	t.next = start.Add(time.Duration(n) * t.interval)
+	t.lock.Unlock()
+
+	d := time.Until(start)
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func (t *writeThrottle) batchSize(size int) int {
+	if t != nil && (size <= 0 || size > t.ops) {
+		return t.ops
+	}
+	return size
+}
+
+// writeBatches calls f for each batch of models with the offset of the batch, pacing the batches by the write rate limit.
+// If batchSize is zero or negative, models are split only by the write rate limit.
+func (c *Collection) writeBatches(ctx context.Context, models []mongo.WriteModel, batchSize int, f func(offset int, models []mongo.WriteModel) error) error {
+	size := c.throttle.batchSize(batchSize)
+	if size <= 0 {
+		size = len(models)
+	}
+
+	for offset := 0; offset < len(models); offset += size {
+		end := offset + size
+		if end > len(models) {
+			end = len(models)
+		}
+		if err := c.throttle.wait(ctx, end-offset); err != nil {
+			return err
+		}
+		if err := f(offset, models[offset:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package mongox
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/reearth/reearthx/mongox/mongotest"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestCollection_writeBatches(t *testing.T) {
+	ctx := context.Background()
+	models := make([]mongo.WriteModel, 150)
+	c := &Collection{}
+
+	var offsets, sizes []int
+	record := func(offset int, models []mongo.WriteModel) error {
+		offsets = append(offsets, offset)
+		sizes = append(sizes, len(models))
+		return nil
+	}
+
+	// no limit
+	assert.NoError(t, c.writeBatches(ctx, models, 0, record))
+	assert.Equal(t, []int{0}, offsets)
+	assert.Equal(t, []int{150}, sizes)
+
+	offsets, sizes = nil, nil
+	assert.NoError(t, c.writeBatches(ctx, models, 60, record))
+	assert.Equal(t, []int{0, 60, 120}, offsets)
+	assert.Equal(t, []int{60, 60, 30}, sizes)
+
+	// limited
+	c.SetWriteRateLimit(100)
+	offsets, sizes = nil, nil
+	start := time.Now()
+	assert.NoError(t, c.writeBatches(ctx, models, 0, record))
+	assert.Equal(t, []int{0, 100}, offsets)
+	assert.Equal(t, []int{100, 50}, sizes)
+	assert.GreaterOrEqual(t, time.Since(start), 900*time.Millisecond)
+
+	// the next batch waits for the previous batches
+	ctx2, cancel := context.WithCancel(ctx)
+	cancel()
+	assert.Same(t, context.Canceled, c.writeBatches(ctx2, models[:1], 0, record))
+
+	c.SetWriteRateLimit(0)
+	assert.Nil(t, c.throttle)
+}
+
+func TestCollection_SetWriteRateLimit(t *testing.T) {
+	ctx := context.Background()
+	c := NewCollection(mongotest.Connect(t)(t).Collection("test"))
+	c.SetWriteRateLimit(2)
+
+	start := time.Now()
+	assert.NoError(t, c.SaveAll(ctx, []string{"a", "b", "c"}, []any{
+		bson.M{"id": "a"},
+		bson.M{"id": "b"},
+		bson.M{"id": "c"},
+	}))
+	assert.GreaterOrEqual(t, time.Since(start), 900*time.Millisecond)
+
+	got, err := c.Count(ctx, bson.M{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), got)
+}
@@ -35,6 +35,82 @@ func (t *Transaction) Begin(ctx context.Context) (usecasex.Tx, error) {
 	return newTx(ctx, s), nil
 }
 
+// Do runs fn in a transaction. The context passed to fn carries the session, so Collection operations with it join the transaction.
+// The transaction is committed if fn returns nil, or else it is aborted and the error is returned.
+func (t *Transaction) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	return WithTransaction(ctx, t.client, fn)
+}
+
+// DefaultTransactionRetries is the number of retries used by WithTransaction.
+var DefaultTransactionRetries = 3
+
+// WithTransaction runs fn in a transaction and retries it when the transaction fails with a transient error.
+func WithTransaction(ctx context.Context, client *mongo.Client, fn func(ctx context.Context) error) error {
+	return WithTransactionRetries(ctx, client, DefaultTransactionRetries, fn)
+}
+
+// WithTransactionRetries is the same as WithTransaction, but retries up to the specified number of times.
+// If the transaction still fails with a transient error after all retries, usecasex.ErrTransaction is returned.
+// When the result of the commit is unknown, only the commit is retried so that fn is not run again for a transaction which may have been committed.
+func WithTransactionRetries(ctx context.Context, client *mongo.Client, retries int, fn func(ctx context.Context) error) error {
+	s, err := client.StartSession(options.Session())
+	if err != nil {
+		return wrapError(err)
+	}
+	defer s.EndSession(ctx)
+
+	return retryTransaction(ctx, s, retries, fn)
+}
+
+func retryTransaction(ctx context.Context, s mongo.Session, retries int, fn func(ctx context.Context) error) error {
+	for r := 0; ; r++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		err := runTransaction(ctx, s, retries, fn)
+		if err == nil {
+			return nil
+		}
+		if !isRetryableTransactionError(err) {
+			return err
+		}
+		if r >= retries {
+			return usecasex.ErrTransaction
+		}
+	}
+}
+
+func runTransaction(ctx context.Context, s mongo.Session, retries int, fn func(ctx context.Context) error) error {
+	if err := s.StartTransaction(options.Transaction()); err != nil {
+		return wrapError(err)
+	}
+
+	if err := fn(mongo.NewSessionContext(ctx, s)); err != nil {
+		_ = s.AbortTransaction(ctx)
+		return err
+	}
+
+	for r := 0; ; r++ {
+		err := s.CommitTransaction(ctx)
+		if err == nil {
+			return nil
+		}
+		if !errorHasLabel(err, driver.UnknownTransactionCommitResult) || r >= retries {
+			return wrapError(err)
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+	}
+}
+
+// isRetryableTransactionError reports whether the whole transaction including fn can be run again.
+func isRetryableTransactionError(err error) bool {
+	return errors.Is(err, usecasex.ErrTransaction) ||
+		errorHasLabel(err, driver.TransientTransactionError)
+}
+
 func IsTransactionError(err error) bool {
 	return errorHasLabel(err, driver.TransientTransactionError)
 }
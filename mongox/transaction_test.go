@@ -0,0 +1,156 @@
+package mongox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/reearth/reearthx/mongox/mongotest"
+	"github.com/reearth/reearthx/usecasex"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/x/mongo/driver"
+)
+
+type testLabeledError struct {
+	label string
+}
+
+func (e testLabeledError) Error() string {
+	return e.label
+}
+
+func (e testLabeledError) HasErrorLabel(label string) bool {
+	return e.label == label
+}
+
+func TestIsTransactionError(t *testing.T) {
+	assert.True(t, IsTransactionError(testLabeledError{label: driver.TransientTransactionError}))
+	assert.True(t, IsTransactionError(fmt.Errorf("wrapped: %w", testLabeledError{label: driver.TransientTransactionError})))
+	assert.False(t, IsTransactionError(testLabeledError{label: driver.UnknownTransactionCommitResult}))
+	assert.False(t, IsTransactionError(errors.New("a")))
+	assert.False(t, IsTransactionError(nil))
+}
+
+func TestIsRetryableTransactionError(t *testing.T) {
+	assert.True(t, isRetryableTransactionError(testLabeledError{label: driver.TransientTransactionError}))
+	assert.False(t, isRetryableTransactionError(testLabeledError{label: driver.UnknownTransactionCommitResult}))
+	assert.True(t, isRetryableTransactionError(usecasex.ErrTransaction))
+	assert.True(t, isRetryableTransactionError(wrapError(testLabeledError{label: driver.TransientTransactionError})))
+	assert.False(t, isRetryableTransactionError(testLabeledError{label: "a"}))
+	assert.False(t, isRetryableTransactionError(errors.New("a")))
+}
+
+// testSession is a mongo.Session whose commits fail with the errors in order.
+type testSession struct {
+	mongo.Session
+	commitErrs []error
+	commits    int
+}
+
+func (s *testSession) StartTransaction(...*options.TransactionOptions) error {
+	return nil
+}
+
+func (s *testSession) AbortTransaction(context.Context) error {
+	return nil
+}
+
+func (s *testSession) CommitTransaction(context.Context) error {
+	s.commits++
+	if len(s.commitErrs) == 0 {
+		return nil
+	}
+	err := s.commitErrs[0]
+	s.commitErrs = s.commitErrs[1:]
+	return err
+}
+
+func TestRetryTransaction(t *testing.T) {
+	ctx := context.Background()
+	unknown := testLabeledError{label: driver.UnknownTransactionCommitResult}
+	transient := testLabeledError{label: driver.TransientTransactionError}
+
+	// only the commit is retried when its result is unknown
+	s := &testSession{commitErrs: []error{unknown, unknown}}
+	calls := 0
+	assert.NoError(t, retryTransaction(ctx, s, 3, func(ctx context.Context) error {
+		calls++
+		return nil
+	}))
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, 3, s.commits)
+
+	// fn is not run again even after the commit retries are exhausted
+	s = &testSession{commitErrs: []error{unknown, unknown}}
+	calls = 0
+	assert.Error(t, retryTransaction(ctx, s, 1, func(ctx context.Context) error {
+		calls++
+		return nil
+	}))
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, 2, s.commits)
+
+	// the whole transaction is retried on transient errors
+	s = &testSession{commitErrs: []error{transient}}
+	calls = 0
+	assert.NoError(t, retryTransaction(ctx, s, 3, func(ctx context.Context) error {
+		calls++
+		return nil
+	}))
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, 2, s.commits)
+}
+
+func TestTransaction_Do_Count(t *testing.T) {
+	ctx := context.Background()
+	db := mongotest.Connect(t)(t)
+	c := NewCollection(db.Collection("test"))
+	_, _ = c.Client().InsertOne(ctx, bson.M{"id": "x"})
+	tr := NewTransaction(db.Client())
+
+	// the estimated count is not allowed in transactions
+	assert.NoError(t, tr.Do(ctx, func(ctx context.Context) error {
+		if err := c.SaveOne(ctx, "a", bson.M{"id": "a"}); err != nil {
+			return err
+		}
+		got, err := c.Count(ctx, bson.M{})
+		if err != nil {
+			return err
+		}
+		assert.Equal(t, int64(2), got)
+		return nil
+	}))
+}
+
+func TestTransaction_Do(t *testing.T) {
+	ctx := context.Background()
+	db := mongotest.Connect(t)(t)
+	c := NewCollection(db.Collection("test"))
+	// collections cannot be created in a transaction on older servers
+	_, _ = c.Client().InsertOne(ctx, bson.M{"id": "x"})
+	tr := NewTransaction(db.Client())
+
+	// commit
+	assert.NoError(t, tr.Do(ctx, func(ctx context.Context) error {
+		return c.SaveOne(ctx, "a", bson.M{"id": "a"})
+	}))
+	got, err := c.Count(ctx, bson.M{"id": "a"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), got)
+
+	// rollback
+	err2 := errors.New("a")
+	assert.Same(t, err2, tr.Do(ctx, func(ctx context.Context) error {
+		if err := c.SaveOne(ctx, "b", bson.M{"id": "b"}); err != nil {
+			return err
+		}
+		return err2
+	}))
+	got, err = c.Count(ctx, bson.M{"id": "b"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), got)
+}
@@ -153,3 +153,17 @@ func And(filter interface{}, key string, f interface{}) interface{} {
 	}
 	return AppendE(filter, bson.E{Key: key, Value: f})
 }
+
+func isEmptyFilter(f any) bool {
+	switch g := f.(type) {
+	case nil:
+		return true
+	case bson.M:
+		return len(g) == 0
+	case bson.D:
+		return len(g) == 0
+	case map[string]any:
+		return len(g) == 0
+	}
+	return false
+}
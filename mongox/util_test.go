@@ -102,3 +102,13 @@ func TestAnd(t *testing.T) {
 		},
 	}, And(bson.D{{Key: "$and", Value: []bson.M{{"a": "b"}}}}, "", "y"))
 }
+
+func TestIsEmptyFilter(t *testing.T) {
+	assert.True(t, isEmptyFilter(nil))
+	assert.True(t, isEmptyFilter(bson.M{}))
+	assert.True(t, isEmptyFilter(bson.D{}))
+	assert.True(t, isEmptyFilter(map[string]any{}))
+	assert.False(t, isEmptyFilter(bson.M{"a": 1}))
+	assert.False(t, isEmptyFilter(bson.D{{Key: "a", Value: 1}}))
+	assert.False(t, isEmptyFilter("a"))
+}
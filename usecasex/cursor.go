@@ -1,5 +1,22 @@
 package usecasex
 
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"sync/atomic"
+
+	"github.com/reearth/reearthx/rerror"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// cursorMACLen is the length of the truncated HMAC appended to cursors
+const cursorMACLen = 16
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
 type Cursor string
 
 func CursorFromRef(c *string) *Cursor {
@@ -29,3 +46,94 @@ func (c *Cursor) StringRef() *string {
 	s := string(*c)
 	return &s
 }
+
+// CompositeCursor is a cursor that holds the value of the sort key in addition to the id,
+// so that keyset pagination stays stable even when the sort key is not unique.
+type CompositeCursor struct {
+	Value any    `bson:"v"`
+	ID    string `bson:"id"`
+}
+
+// Encode encodes the cursor in the same way as EncodeCursor.
+func (c CompositeCursor) Encode() (Cursor, error) {
+	return encodeCursor(c, loadCursorKey())
+}
+
+// DecodeCompositeCursor decodes a cursor encoded by CompositeCursor.Encode.
+func DecodeCompositeCursor(c Cursor) (*CompositeCursor, error) {
+	var cc CompositeCursor
+	if err := decodeCursor(c, loadCursorKey(), &cc); err != nil || cc.ID == "" {
+		return nil, ErrInvalidCursor
+	}
+	return &cc, nil
+}
+
+// EncodeCursor encodes the fields, e.g. the sort key and the id, as an opaque cursor of a base64 string of a BSON document
+// signed with the key set by SetCursorKey, so that cursors modified or forged by clients are rejected by Decode.
+func EncodeCursor(fields map[string]any) (Cursor, error) {
+	c, err := encodeCursor(fields, loadCursorKey())
+	if err != nil {
+		return "", rerror.ErrInvalidParams
+	}
+	return c, nil
+}
+
+// Decode decodes the cursor encoded by EncodeCursor. Timestamps are decoded as time.Time in UTC.
+// It returns rerror.ErrInvalidParams if the cursor is invalid or is not signed with the current key.
+func (c Cursor) Decode() (map[string]any, error) {
+	var m bson.M
+	if err := decodeCursor(c, loadCursorKey(), &m); err != nil {
+		return nil, rerror.ErrInvalidParams
+	}
+	res := make(map[string]any, len(m))
+	for k, v := range m {
+		if d, ok := v.(primitive.DateTime); ok {
+			v = d.Time().UTC()
+		}
+		res[k] = v
+	}
+	return res, nil
+}
+
+var cursorKey atomic.Value
+
+// SetCursorKey sets the secret key to sign cursors with HMAC-SHA256. It should be called once at startup with the same key on all servers,
+// as cursors signed with another key are rejected. Until it is called, cursors are signed with an empty key,
+// which only detects accidental damage as anyone can sign cursors with it.
+func SetCursorKey(key []byte) {
+	cursorKey.Store(append([]byte{}, key...))
+}
+
+func loadCursorKey() []byte {
+	k, _ := cursorKey.Load().([]byte)
+	return k
+}
+
+func encodeCursor(v any, key []byte) (Cursor, error) {
+	b, err := bson.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return Cursor(base64.RawURLEncoding.EncodeToString(append(b, cursorMAC(b, key)...))), nil
+}
+
+func decodeCursor(c Cursor, key []byte, v any) error {
+	b, err := base64.RawURLEncoding.DecodeString(string(c))
+	if err != nil || len(b) < cursorMACLen {
+		return ErrInvalidCursor
+	}
+	doc, mac := b[:len(b)-cursorMACLen], b[len(b)-cursorMACLen:]
+	if !hmac.Equal(mac, cursorMAC(doc, key)) {
+		return ErrInvalidCursor
+	}
+	if err := bson.Unmarshal(doc, v); err != nil {
+		return ErrInvalidCursor
+	}
+	return nil
+}
+
+func cursorMAC(b, key []byte) []byte {
+	h := hmac.New(sha256.New, key)
+	_, _ = h.Write(b)
+	return h.Sum(nil)[:cursorMACLen]
+}
@@ -2,7 +2,9 @@ package usecasex
 
 import (
 	"testing"
+	"time"
 
+	"github.com/reearth/reearthx/rerror"
 	"github.com/samber/lo"
 	"github.com/stretchr/testify/assert"
 )
@@ -29,3 +31,65 @@ func TestCursor_StringRef(t *testing.T) {
 	assert.Equal(t, lo.ToPtr("a"), c.StringRef())
 	assert.Nil(t, (*Cursor)(nil).StringRef())
 }
+
+func TestCompositeCursor(t *testing.T) {
+	c, err := CompositeCursor{Value: "x", ID: "a"}.Encode()
+	assert.NoError(t, err)
+	got, err := DecodeCompositeCursor(c)
+	assert.NoError(t, err)
+	assert.Equal(t, &CompositeCursor{Value: "x", ID: "a"}, got)
+
+	c, err = CompositeCursor{Value: int32(1), ID: "a"}.Encode()
+	assert.NoError(t, err)
+	got, err = DecodeCompositeCursor(c)
+	assert.NoError(t, err)
+	assert.Equal(t, &CompositeCursor{Value: int32(1), ID: "a"}, got)
+
+	_, err = DecodeCompositeCursor("a")
+	assert.Same(t, ErrInvalidCursor, err)
+	_, err = DecodeCompositeCursor("01gadwh4k9zc7e4sgndva2ngrp")
+	assert.Same(t, ErrInvalidCursor, err)
+	c, _ = CompositeCursor{Value: "x"}.Encode()
+	_, err = DecodeCompositeCursor(c)
+	assert.Same(t, ErrInvalidCursor, err)
+}
+
+func TestEncodeCursor(t *testing.T) {
+	ts := time.Date(2022, 1, 2, 3, 4, 5, 6000000, time.UTC)
+	c, err := EncodeCursor(map[string]any{"createdAt": ts, "id": "xxx", "n": int64(10)})
+	assert.NoError(t, err)
+
+	got, err := c.Decode()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{"createdAt": ts, "id": "xxx", "n": int64(10)}, got)
+
+	c, err = EncodeCursor(map[string]any{})
+	assert.NoError(t, err)
+	got, err = c.Decode()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{}, got)
+}
+
+func TestCursor_Decode(t *testing.T) {
+	c, _ := EncodeCursor(map[string]any{"id": "xxx"})
+	b := []byte(c)
+	b[len(b)/2] ^= 1 // tampered
+
+	for _, c := range []Cursor{"", "!!!", "aaaa", Cursor(b)} {
+		_, err := c.Decode()
+		assert.Same(t, rerror.ErrInvalidParams, err, c)
+	}
+}
+
+func TestCursor_Key(t *testing.T) {
+	c, err := encodeCursor(map[string]any{"id": "xxx"}, []byte("a"))
+	assert.NoError(t, err)
+
+	var got map[string]any
+	assert.NoError(t, decodeCursor(c, []byte("a"), &got))
+	assert.Equal(t, map[string]any{"id": "xxx"}, got)
+
+	// a cursor signed with another key, e.g. forged by a client, is rejected
+	assert.Same(t, ErrInvalidCursor, decodeCursor(c, []byte("b"), &got))
+	assert.Same(t, ErrInvalidCursor, decodeCursor(c, nil, &got))
+}
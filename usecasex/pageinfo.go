@@ -1,11 +1,11 @@
 package usecasex
 
 type PageInfo struct {
-	TotalCount      int64
-	StartCursor     *Cursor
-	EndCursor       *Cursor
-	HasNextPage     bool
-	HasPreviousPage bool
+	TotalCount      int64   `json:"totalCount"`
+	StartCursor     *Cursor `json:"startCursor"`
+	EndCursor       *Cursor `json:"endCursor"`
+	HasNextPage     bool    `json:"hasNextPage"`
+	HasPreviousPage bool    `json:"hasPreviousPage"`
 }
 
 func NewPageInfo(totalCount int64, startCursor, endCursor *Cursor, hasNextPage, hasPreviousPage bool) *PageInfo {
@@ -1,6 +1,7 @@
 package usecasex
 
 import (
+	"encoding/json"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -45,3 +46,13 @@ func TestPageInfo_Clone(t *testing.T) {
 	assert.NotSame(t, p, got)
 	assert.Nil(t, (*PageInfo)(nil).Clone())
 }
+
+func TestPageInfo_JSON(t *testing.T) {
+	b, err := json.Marshal(NewPageInfo(100, Cursor("a").Ref(), nil, true, false))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"totalCount":100,"startCursor":"a","endCursor":null,"hasNextPage":true,"hasPreviousPage":false}`, string(b))
+
+	var p PageInfo
+	assert.NoError(t, json.Unmarshal(b, &p))
+	assert.Equal(t, NewPageInfo(100, Cursor("a").Ref(), nil, true, false), &p)
+}
@@ -3,6 +3,7 @@ package usecasex
 import (
 	"testing"
 
+	"github.com/reearth/reearthx/rerror"
 	"github.com/samber/lo"
 	"github.com/stretchr/testify/assert"
 )
@@ -34,4 +35,210 @@ func TestPagination_Clone(t *testing.T) {
 	assert.Equal(t, got, target)
 	assert.NotSame(t, got, target)
 	assert.Nil(t, (*Pagination)(nil).Clone())
+
+	// mutating the clone does not affect the original
+	*got.Cursor.Before = "x"
+	*got.Cursor.After = "y"
+	*got.Cursor.First = 1
+	*got.Cursor.Last = 2
+	got.Offset.Offset = 0
+	assert.Equal(t, &Pagination{
+		Cursor: &CursorPagination{
+			Before: lo.ToPtr(Cursor("a")),
+			After:  lo.ToPtr(Cursor("b")),
+			First:  lo.ToPtr(int64(100)),
+			Last:   lo.ToPtr(int64(10)),
+		},
+		Offset: &OffsetPagination{Offset: 100, Limit: 10},
+	}, target)
+}
+
+func TestSort(t *testing.T) {
+	assert.Equal(t, &Sort{Key: "id"}, ByID())
+	assert.Equal(t, &Sort{Key: "createdAt", Reverted: true}, ByDate("createdAt"))
+
+	assert.Equal(t, 1, ByID().Direction())
+	assert.Equal(t, "$gt", ByID().Operator())
+	assert.Equal(t, -1, ByDate("a").Direction())
+	assert.Equal(t, "$lt", ByDate("a").Operator())
+	assert.Equal(t, 1, (*Sort)(nil).Direction())
+	assert.Equal(t, "$gt", (*Sort)(nil).Operator())
+}
+
+func TestSort_Clone(t *testing.T) {
+	s := &Sort{Key: "a", Reverted: true, Filter: &SortFilter{Value: 1, ID: "x"}}
+	got := s.Clone()
+	assert.Equal(t, s, got)
+	assert.NotSame(t, s, got)
+	assert.NotSame(t, s.Filter, got.Filter)
+	assert.Nil(t, (*Sort)(nil).Clone())
+}
+
+func TestCursorPagination_Validate(t *testing.T) {
+	assert.NoError(t, (*CursorPagination)(nil).Validate())
+	assert.NoError(t, (&CursorPagination{}).Validate())
+	assert.NoError(t, (&CursorPagination{First: lo.ToPtr(int64(10)), After: Cursor("a").Ref()}).Validate())
+	assert.NoError(t, (&CursorPagination{Last: lo.ToPtr(int64(10)), Before: Cursor("a").Ref()}).Validate())
+	assert.NoError(t, (&CursorPagination{Last: lo.ToPtr(int64(0)), After: Cursor("a").Ref()}).Validate())
+
+	assert.Same(t, ErrFirstAndLast, (&CursorPagination{First: lo.ToPtr(int64(1)), Last: lo.ToPtr(int64(1))}).Validate())
+	assert.Same(t, ErrNegativeLimit, (&CursorPagination{First: lo.ToPtr(int64(-1))}).Validate())
+	assert.Same(t, ErrNegativeLimit, (&CursorPagination{Last: lo.ToPtr(int64(-1))}).Validate())
+	assert.Same(t, ErrBeforeAndFirst, (&CursorPagination{First: lo.ToPtr(int64(1)), Before: Cursor("a").Ref()}).Validate())
+}
+
+func TestPagination_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		target  *Pagination
+		wantErr bool
+	}{
+		{name: "nil", target: nil},
+		{name: "empty", target: &Pagination{}},
+		{name: "cursor", target: CursorPagination{First: lo.ToPtr(int64(10)), After: Cursor("a").Ref()}.Wrap()},
+		{name: "offset", target: OffsetPagination{Offset: 10, Limit: 10}.Wrap()},
+		{
+			name:    "cursor and offset",
+			target:  &Pagination{Cursor: &CursorPagination{First: lo.ToPtr(int64(10))}, Offset: &OffsetPagination{Limit: 10}},
+			wantErr: true,
+		},
+		{name: "first and last", target: CursorPagination{First: lo.ToPtr(int64(1)), Last: lo.ToPtr(int64(1))}.Wrap(), wantErr: true},
+		{name: "negative first", target: CursorPagination{First: lo.ToPtr(int64(-1))}.Wrap(), wantErr: true},
+		{name: "negative last", target: CursorPagination{Last: lo.ToPtr(int64(-1))}.Wrap(), wantErr: true},
+		{name: "negative limit", target: OffsetPagination{Limit: -1}.Wrap(), wantErr: true},
+		{name: "negative offset", target: OffsetPagination{Offset: -1, Limit: 10}.Wrap(), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := tt.target.Validate()
+			if tt.wantErr {
+				assert.Same(t, rerror.ErrInvalidParams, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestCursorPagination_WithDefaults(t *testing.T) {
+	tests := []struct {
+		name   string
+		target CursorPagination
+		want   CursorPagination
+	}{
+		{
+			name:   "default",
+			target: CursorPagination{After: Cursor("a").Ref()},
+			want:   CursorPagination{After: Cursor("a").Ref(), First: lo.ToPtr(int64(20))},
+		},
+		{
+			name:   "default with before",
+			target: CursorPagination{Before: Cursor("a").Ref()},
+			want:   CursorPagination{Before: Cursor("a").Ref(), Last: lo.ToPtr(int64(20))},
+		},
+		{
+			name:   "first capped",
+			target: CursorPagination{First: lo.ToPtr(int64(1000))},
+			want:   CursorPagination{First: lo.ToPtr(int64(100))},
+		},
+		{
+			name:   "last capped",
+			target: CursorPagination{Last: lo.ToPtr(int64(1000))},
+			want:   CursorPagination{Last: lo.ToPtr(int64(100))},
+		},
+		{
+			name:   "within bounds",
+			target: CursorPagination{First: lo.ToPtr(int64(50))},
+			want:   CursorPagination{First: lo.ToPtr(int64(50))},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.want, tt.target.WithDefaults(20, 100))
+		})
+	}
+
+	p := CursorPagination{First: lo.ToPtr(int64(1000))}
+	_ = p.WithDefaults(20, 100)
+	assert.Equal(t, int64(1000), *p.First)
+	assert.Equal(t, CursorPagination{}, CursorPagination{}.WithDefaults(0, 0))
+}
+
+func TestOffsetPagination_Normalize(t *testing.T) {
+	got, err := OffsetPagination{Offset: 10, Limit: 0}.Normalize(100)
+	assert.NoError(t, err)
+	assert.Equal(t, OffsetPagination{Offset: 10, Limit: DefaultOffsetLimit}, got)
+
+	got, err = OffsetPagination{Offset: 10, Limit: 2000000000}.Normalize(100)
+	assert.NoError(t, err)
+	assert.Equal(t, OffsetPagination{Offset: 10, Limit: 100}, got)
+
+	got, err = OffsetPagination{Limit: 0}.Normalize(10)
+	assert.NoError(t, err)
+	assert.Equal(t, OffsetPagination{Limit: 10}, got)
+
+	got, err = OffsetPagination{Limit: 1000}.Normalize(0)
+	assert.NoError(t, err)
+	assert.Equal(t, OffsetPagination{Limit: 1000}, got)
+
+	_, err = OffsetPagination{Offset: -1, Limit: 10}.Normalize(100)
+	assert.Same(t, ErrNegativeOffset, err)
+	_, err = OffsetPagination{Limit: -1}.Normalize(100)
+	assert.Same(t, ErrNegativeOffset, err)
+}
+
+func TestOffsetPagination_NormalizeStrict(t *testing.T) {
+	got, err := OffsetPagination{Offset: 10, Limit: 100}.NormalizeStrict(100)
+	assert.NoError(t, err)
+	assert.Equal(t, OffsetPagination{Offset: 10, Limit: 100}, got)
+
+	got, err = OffsetPagination{Limit: 0}.NormalizeStrict(100)
+	assert.NoError(t, err)
+	assert.Equal(t, OffsetPagination{Limit: DefaultOffsetLimit}, got)
+
+	_, err = OffsetPagination{Limit: 101}.NormalizeStrict(100)
+	assert.Same(t, ErrLimitExceeded, err)
+	_, err = OffsetPagination{Limit: -1}.NormalizeStrict(100)
+	assert.Same(t, ErrNegativeOffset, err)
+}
+
+func TestPagination_Cap(t *testing.T) {
+	p := CursorPagination{First: lo.ToPtr(int64(200))}.Wrap()
+	got, err := p.Cap(100, false)
+	assert.NoError(t, err)
+	assert.Equal(t, CursorPagination{First: lo.ToPtr(int64(100))}.Wrap(), got)
+	assert.Equal(t, int64(200), *p.Cursor.First)
+
+	got, err = CursorPagination{Last: lo.ToPtr(int64(50))}.Wrap().Cap(100, true)
+	assert.NoError(t, err)
+	assert.Equal(t, CursorPagination{Last: lo.ToPtr(int64(50))}.Wrap(), got)
+
+	got, err = OffsetPagination{Offset: 5, Limit: 200}.Wrap().Cap(100, false)
+	assert.NoError(t, err)
+	assert.Equal(t, OffsetPagination{Offset: 5, Limit: 100}.Wrap(), got)
+
+	_, err = p.Cap(100, true)
+	assert.Same(t, ErrLimitExceeded, err)
+	_, err = OffsetPagination{Limit: 200}.Wrap().Cap(100, true)
+	assert.Same(t, ErrLimitExceeded, err)
+
+	got, err = p.Cap(0, true)
+	assert.NoError(t, err)
+	assert.Same(t, p, got)
+
+	got, err = (*Pagination)(nil).Cap(100, true)
+	assert.NoError(t, err)
+	assert.Nil(t, got)
+}
+
+func TestOffsetPagination_SkipLimit(t *testing.T) {
+	skip, limit := OffsetPagination{Offset: 10, Limit: 20}.SkipLimit()
+	assert.Equal(t, int64(10), skip)
+	assert.Equal(t, int64(20), limit)
 }
@@ -1,6 +1,22 @@
 package usecasex
 
-import "github.com/reearth/reearthx/util"
+import (
+	"errors"
+
+	"github.com/reearth/reearthx/rerror"
+	"github.com/reearth/reearthx/util"
+)
+
+var (
+	ErrFirstAndLast   = errors.New("first and last cannot be specified at the same time")
+	ErrNegativeLimit  = errors.New("first and last must not be negative")
+	ErrBeforeAndFirst = errors.New("before cannot be specified with first")
+	ErrNegativeOffset = errors.New("offset and limit must not be negative")
+	ErrLimitExceeded  = errors.New("limit exceeds the maximum")
+)
+
+// DefaultOffsetLimit is the limit used by OffsetPagination.Normalize when the limit is not specified.
+const DefaultOffsetLimit int64 = 20
 
 // CursorPagination is a struct for Relay-Style Cursor Pagination
 // ref: https://www.apollographql.com/docs/react/features/pagination/#relay-style-cursor-pagination
@@ -24,6 +40,46 @@ func (p *CursorPagination) Clone() *CursorPagination {
 	}
 }
 
+// Validate returns an error if the combination of the arguments is ambiguous.
+func (p *CursorPagination) Validate() error {
+	if p == nil {
+		return nil
+	}
+	if p.First != nil && p.Last != nil {
+		return ErrFirstAndLast
+	}
+	if p.First != nil && *p.First < 0 || p.Last != nil && *p.Last < 0 {
+		return ErrNegativeLimit
+	}
+	if p.Before != nil && p.First != nil {
+		return ErrBeforeAndFirst
+	}
+	return nil
+}
+
+// WithDefaults returns the pagination whose first is defaultLimit when neither first nor last is set, and whose first and last are at most maxLimit.
+// If before is set, last is filled in instead of first, as before cannot be specified with first.
+// Zero or negative defaultLimit and maxLimit are ignored.
+func (p CursorPagination) WithDefaults(defaultLimit, maxLimit int64) CursorPagination {
+	p = *p.Clone()
+	if p.First == nil && p.Last == nil && defaultLimit > 0 {
+		if p.Before != nil {
+			p.Last = &defaultLimit
+		} else {
+			p.First = &defaultLimit
+		}
+	}
+	if maxLimit > 0 {
+		if p.First != nil && *p.First > maxLimit {
+			p.First = &maxLimit
+		}
+		if p.Last != nil && *p.Last > maxLimit {
+			p.Last = &maxLimit
+		}
+	}
+	return p
+}
+
 func (p CursorPagination) Wrap() *Pagination {
 	return &Pagination{
 		Cursor: &p,
@@ -35,6 +91,34 @@ type OffsetPagination struct {
 	Limit  int64 `json:"limit"`
 }
 
+// Normalize returns the pagination whose limit is DefaultOffsetLimit if it is zero and is at most maxLimit.
+// If maxLimit is zero or negative, the limit is not clamped. It returns an error if the offset or the limit is negative.
+func (p OffsetPagination) Normalize(maxLimit int64) (OffsetPagination, error) {
+	if p.Offset < 0 || p.Limit < 0 {
+		return p, ErrNegativeOffset
+	}
+	if p.Limit == 0 {
+		p.Limit = DefaultOffsetLimit
+	}
+	if maxLimit > 0 && p.Limit > maxLimit {
+		p.Limit = maxLimit
+	}
+	return p, nil
+}
+
+// NormalizeStrict is the same as Normalize, but it returns ErrLimitExceeded instead of clamping the limit to maxLimit.
+func (p OffsetPagination) NormalizeStrict(maxLimit int64) (OffsetPagination, error) {
+	if maxLimit > 0 && p.Limit > maxLimit {
+		return p, ErrLimitExceeded
+	}
+	return p.Normalize(maxLimit)
+}
+
+// SkipLimit returns the number of elements to skip and the maximum number of elements to return, e.g. for the skip and limit options of MongoDB.
+func (p OffsetPagination) SkipLimit() (skip, limit int64) {
+	return p.Offset, p.Limit
+}
+
 func (p OffsetPagination) Wrap() *Pagination {
 	return &Pagination{
 		Offset: &p,
@@ -57,7 +141,98 @@ func (p *Pagination) Clone() *Pagination {
 	}
 }
 
+// Validate returns rerror.ErrInvalidParams if both the cursor and the offset are set, the cursor is invalid, or the offset or the limit is negative.
+func (p *Pagination) Validate() error {
+	if p == nil {
+		return nil
+	}
+	if p.Cursor != nil && p.Offset != nil {
+		return rerror.ErrInvalidParams
+	}
+	if p.Cursor.Validate() != nil {
+		return rerror.ErrInvalidParams
+	}
+	if p.Offset != nil && (p.Offset.Offset < 0 || p.Offset.Limit < 0) {
+		return rerror.ErrInvalidParams
+	}
+	return nil
+}
+
+// Cap returns a copy of the pagination whose first, last and limit are at most maxLimit.
+// If strict is true, it returns ErrLimitExceeded instead of clamping them. If maxLimit is zero or negative, nothing is capped.
+func (p *Pagination) Cap(maxLimit int64, strict bool) (*Pagination, error) {
+	if p == nil || maxLimit <= 0 {
+		return p, nil
+	}
+
+	res := p.Clone()
+	limits := []*int64{}
+	if res.Cursor != nil {
+		limits = append(limits, res.Cursor.First, res.Cursor.Last)
+	}
+	if res.Offset != nil {
+		limits = append(limits, &res.Offset.Limit)
+	}
+	for _, l := range limits {
+		if l == nil || *l <= maxLimit {
+			continue
+		}
+		if strict {
+			return nil, ErrLimitExceeded
+		}
+		*l = maxLimit
+	}
+	return res, nil
+}
+
 type Sort struct {
 	Key      string
 	Reverted bool
+	// Filter limits results to the elements after the position in the sort order.
+	Filter *SortFilter
+}
+
+// SortFilter is a position in the sort order for keyset pagination.
+type SortFilter struct {
+	// Value is the value of the sort key.
+	Value any
+	// ID breaks ties between elements with the same value. It is ignored if empty.
+	ID string
+}
+
+// ByID sorts elements by id in ascending order.
+func ByID() *Sort {
+	return &Sort{Key: "id"}
+}
+
+// ByDate sorts elements by the date key from newest to oldest.
+func ByDate(key string) *Sort {
+	return &Sort{Key: key, Reverted: true}
+}
+
+// Direction returns 1 for ascending order or -1 for descending order.
+func (s *Sort) Direction() int {
+	if s != nil && s.Reverted {
+		return -1
+	}
+	return 1
+}
+
+// Operator returns the comparison operator to find elements after a position in the sort order.
+func (s *Sort) Operator() string {
+	if s != nil && s.Reverted {
+		return "$lt"
+	}
+	return "$gt"
+}
+
+func (s *Sort) Clone() *Sort {
+	if s == nil {
+		return nil
+	}
+	return &Sort{
+		Key:      s.Key,
+		Reverted: s.Reverted,
+		Filter:   util.CloneRef(s.Filter),
+	}
 }
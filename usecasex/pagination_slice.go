@@ -0,0 +1,63 @@
+package usecasex
+
+import (
+	"github.com/samber/lo"
+	"golang.org/x/exp/slices"
+)
+
+// PaginateSlice applies the pagination to items in memory. Items are ordered by the key, which is also used as the cursor.
+func PaginateSlice[T any](items []T, p *Pagination, keyOf func(T) string) ([]T, *PageInfo) {
+	items = slices.Clone(items)
+	slices.SortStableFunc(items, func(a, b T) bool { return keyOf(a) < keyOf(b) })
+	cursor := func(i T) Cursor { return Cursor(keyOf(i)) }
+
+	total := int64(len(items))
+	if p == nil || p.Cursor == nil && p.Offset == nil {
+		return items, slicePageInfo(items, cursor, total, false, false)
+	}
+
+	if p.Offset != nil {
+		start := lo.Clamp(p.Offset.Offset, 0, total)
+		end := total
+		if p.Offset.Limit > 0 {
+			end = lo.Clamp(start+p.Offset.Limit, start, total)
+		}
+		res := items[start:end]
+		return res, slicePageInfo(res, cursor, total, end < total, false)
+	}
+
+	if p.Cursor.Last != nil && p.Cursor.First == nil {
+		end := total
+		if p.Cursor.Before != nil {
+			end = 0
+			for end < total && cursor(items[end]) < *p.Cursor.Before {
+				end++
+			}
+		}
+		start := lo.Clamp(end-*p.Cursor.Last, 0, end)
+		res := items[start:end]
+		return res, slicePageInfo(res, cursor, total, false, start > 0)
+	}
+
+	start := int64(0)
+	if p.Cursor.After != nil {
+		for start < total && cursor(items[start]) <= *p.Cursor.After {
+			start++
+		}
+	}
+	end := total
+	if p.Cursor.First != nil {
+		end = lo.Clamp(start+*p.Cursor.First, start, total)
+	}
+	res := items[start:end]
+	return res, slicePageInfo(res, cursor, total, end < total, p.Cursor.After != nil)
+}
+
+func slicePageInfo[T any](items []T, cursor func(T) Cursor, total int64, hasNext, hasPrev bool) *PageInfo {
+	var start, end *Cursor
+	if len(items) > 0 {
+		start = cursor(items[0]).Ref()
+		end = cursor(items[len(items)-1]).Ref()
+	}
+	return NewPageInfo(total, start, end, hasNext, hasPrev)
+}
@@ -0,0 +1,43 @@
+package usecasex
+
+import (
+	"testing"
+
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPaginateSlice(t *testing.T) {
+	items := []string{"c", "a", "d", "b"}
+	sorted := []string{"a", "b", "c", "d"}
+	key := func(s string) string { return s }
+
+	got, info := PaginateSlice(items, nil, key)
+	assert.Equal(t, sorted, got)
+	assert.Equal(t, NewPageInfo(4, Cursor("a").Ref(), Cursor("d").Ref(), false, false), info)
+	assert.Equal(t, []string{"c", "a", "d", "b"}, items)
+
+	got, info = PaginateSlice(items, OffsetPagination{Offset: 1, Limit: 2}.Wrap(), key)
+	assert.Equal(t, []string{"b", "c"}, got)
+	assert.Equal(t, NewPageInfo(4, Cursor("b").Ref(), Cursor("c").Ref(), true, false), info)
+
+	got, info = PaginateSlice(items, OffsetPagination{Offset: 10, Limit: 2}.Wrap(), key)
+	assert.Empty(t, got)
+	assert.Equal(t, NewPageInfo(4, nil, nil, false, false), info)
+
+	got, info = PaginateSlice(items, CursorPagination{First: lo.ToPtr(int64(2)), After: Cursor("a").Ref()}.Wrap(), key)
+	assert.Equal(t, []string{"b", "c"}, got)
+	assert.Equal(t, NewPageInfo(4, Cursor("b").Ref(), Cursor("c").Ref(), true, true), info)
+
+	got, info = PaginateSlice(items, CursorPagination{First: lo.ToPtr(int64(5))}.Wrap(), key)
+	assert.Equal(t, sorted, got)
+	assert.Equal(t, NewPageInfo(4, Cursor("a").Ref(), Cursor("d").Ref(), false, false), info)
+
+	got, info = PaginateSlice(items, CursorPagination{Last: lo.ToPtr(int64(2)), Before: Cursor("d").Ref()}.Wrap(), key)
+	assert.Equal(t, []string{"b", "c"}, got)
+	assert.Equal(t, NewPageInfo(4, Cursor("b").Ref(), Cursor("c").Ref(), false, true), info)
+
+	got, info = PaginateSlice(items, CursorPagination{Last: lo.ToPtr(int64(1))}.Wrap(), key)
+	assert.Equal(t, []string{"d"}, got)
+	assert.Equal(t, NewPageInfo(4, Cursor("d").Ref(), Cursor("d").Ref(), false, true), info)
+}
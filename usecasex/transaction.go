@@ -37,6 +37,7 @@ type NopTransaction struct {
 	BeginError  error
 	CommitError error
 	committed   atomic.Bool
+	rolledBack  atomic.Bool
 }
 
 type NopTx struct {
@@ -55,6 +56,11 @@ func (t *NopTransaction) IsCommitted() bool {
 	return t.committed.Load()
 }
 
+// IsRolledBack returns true if a Tx has been ended without being marked as committed.
+func (t *NopTransaction) IsRolledBack() bool {
+	return t.rolledBack.Load()
+}
+
 func (t *NopTx) Commit() {
 	t.t.committed.Store(true)
 }
@@ -64,6 +70,9 @@ func (t *NopTx) IsCommitted() bool {
 }
 
 func (t *NopTx) End(_ context.Context) error {
+	if !t.IsCommitted() {
+		t.t.rolledBack.Store(true)
+	}
 	return t.t.CommitError
 }
 
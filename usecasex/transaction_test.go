@@ -38,6 +38,35 @@ func TestNopTransactional(t *testing.T) {
 	assert.Nil(t, tx.End(context.Background()))
 }
 
+func TestNopTransaction_Rollback(t *testing.T) {
+	ctx := context.Background()
+
+	// ended without commit
+	tr := &NopTransaction{}
+	tx, err := tr.Begin(ctx)
+	assert.NoError(t, err)
+	assert.False(t, tr.IsRolledBack())
+	assert.NoError(t, tx.End(ctx))
+	assert.False(t, tr.IsCommitted())
+	assert.True(t, tr.IsRolledBack())
+
+	// committed
+	tr = &NopTransaction{}
+	tx, err = tr.Begin(ctx)
+	assert.NoError(t, err)
+	tx.Commit()
+	assert.NoError(t, tx.End(ctx))
+	assert.True(t, tr.IsCommitted())
+	assert.False(t, tr.IsRolledBack())
+
+	// DoTransaction rolls back when fn fails
+	tr = &NopTransaction{}
+	fnErr := errors.New("fn")
+	assert.Same(t, fnErr, DoTransaction(ctx, tr, 0, func(ctx context.Context) error { return fnErr }))
+	assert.False(t, tr.IsCommitted())
+	assert.True(t, tr.IsRolledBack())
+}
+
 func TestDoTransaction(t *testing.T) {
 	ctx := context.Background()
 	tr := &NopTransaction{}
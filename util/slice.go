@@ -162,6 +162,13 @@ func Subset[T comparable](collection, subCollection []T) bool {
 	return !lo.SomeBy(subCollection, func(t T) bool { return !lo.Contains(collection, t) })
 }
 
+// FilterNil returns a new slice without nil elements. It returns nil if the collection is nil.
+func FilterNil[T any](collection []*T) []*T {
+	return Filter(collection, func(e *T) bool {
+		return e != nil
+	})
+}
+
 // DerefSlice drops nil elements in the slice and return a new slice with dereferenced elements.
 func DerefSlice[T any](collection []*T) []T {
 	return FilterMap(collection, func(e *T) *T {
@@ -112,6 +112,13 @@ func TestFilter(t *testing.T) {
 	}))
 }
 
+func TestFilterNil(t *testing.T) {
+	a, b := lo.ToPtr(1), lo.ToPtr(2)
+	assert.Nil(t, FilterNil[int](nil))
+	assert.Equal(t, []*int{}, FilterNil([]*int{nil}))
+	assert.Equal(t, []*int{a, b}, FilterNil([]*int{nil, a, nil, b}))
+}
+
 func TestDerefSlice(t *testing.T) {
 	assert.Nil(t, DerefSlice[int](nil))
 	assert.Equal(t, []int{1, 0, 2}, DerefSlice([]*int{lo.ToPtr(1), nil, lo.ToPtr(0), lo.ToPtr(2)}))
@@ -8,6 +8,10 @@ import (
 
 type SyncMap[K comparable, V any] struct {
 	m sync.Map
+	// computeLock serializes GetOrCompute so that fn is called at most once per missing key
+	computeLock sync.Mutex
+	// writeLock is read-locked by writes, which are atomic by themselves, and locked by CompareAndDelete to exclude writes
+	writeLock sync.RWMutex
 }
 
 func NewSyncMap[K comparable, V any]() *SyncMap[K, V] {
@@ -59,24 +63,57 @@ func (m *SyncMap[K, V]) LoadOr(key K, o V) (res V) {
 }
 
 func (m *SyncMap[K, V]) Store(key K, value V) {
+	m.writeLock.RLock()
+	defer m.writeLock.RUnlock()
 	m.m.Store(key, value)
 }
 
 func (m *SyncMap[K, V]) StoreAll(entries map[K]V) {
 	for k, v := range entries {
-		m.m.Store(k, v)
+		m.Store(k, v)
 	}
 }
 
-func (m *SyncMap[K, V]) LoadOrStore(key K, value V) (vv V, _ bool) {
-	v, ok := m.m.LoadOrStore(key, value)
-	if ok {
-		vv = v.(V)
+// LoadOrStore returns the existing value for the key if present. Otherwise, it stores and returns the given value.
+// The loaded result is true if the value was loaded, false if stored.
+func (m *SyncMap[K, V]) LoadOrStore(key K, value V) (actual V, loaded bool) {
+	m.writeLock.RLock()
+	defer m.writeLock.RUnlock()
+	v, loaded := m.m.LoadOrStore(key, value)
+	if v == nil {
+		// V is an interface type and nil has been stored
+		var zero V
+		return zero, loaded
 	}
-	return vv, ok
+	return v.(V), loaded
+}
+
+// GetOrCompute returns the existing value of the key, or stores and returns the value returned by fn if the key does not exist.
+// fn is called only when the key does not exist, and the bool reports whether the returned value is the one returned by fn.
+// Store and LoadOrStore do not wait for fn, so if one of them stores a value while fn is running, that value is returned with false
+// and the value returned by fn is discarded.
+func (m *SyncMap[K, V]) GetOrCompute(key K, fn func() V) (V, bool) {
+	if v, ok := m.Load(key); ok {
+		return v, false
+	}
+
+	m.computeLock.Lock()
+	defer m.computeLock.Unlock()
+
+	if v, ok := m.Load(key); ok {
+		return v, false
+	}
+	v := fn()
+	// Store may be called without the lock
+	if v2, loaded := m.LoadOrStore(key, v); loaded {
+		return v2, false
+	}
+	return v, true
 }
 
 func (m *SyncMap[K, V]) LoadAndDelete(key K) (vv V, ok bool) {
+	m.writeLock.RLock()
+	defer m.writeLock.RUnlock()
 	v, ok := m.m.LoadAndDelete(key)
 	if ok {
 		vv = v.(V)
@@ -85,7 +122,22 @@ func (m *SyncMap[K, V]) LoadAndDelete(key K) (vv V, ok bool) {
 }
 
 func (m *SyncMap[K, V]) Delete(key K) {
+	m.writeLock.RLock()
+	defer m.writeLock.RUnlock()
+	m.m.Delete(key)
+}
+
+// CompareAndDelete deletes the entry for the key if its value is equal to old, and reports whether it was deleted.
+// As with sync.Map, the values must be of a comparable type, or else it panics.
+func (m *SyncMap[K, V]) CompareAndDelete(key K, old V) (deleted bool) {
+	m.writeLock.Lock()
+	defer m.writeLock.Unlock()
+	v, ok := m.m.Load(key)
+	if !ok || v != any(old) {
+		return false
+	}
 	m.m.Delete(key)
+	return true
 }
 
 func (m *SyncMap[K, V]) DeleteAll(key ...K) {
@@ -131,6 +183,41 @@ func (m *SyncMap[K, V]) FindAll(f func(key K, value V) bool) (v []V) {
 	return
 }
 
+// FindAllSorted is the same as FindAll, but returns the values ordered by their keys with less, so that the result is stable.
+// If less is nil, the result is in no particular order.
+func (m *SyncMap[K, V]) FindAllSorted(less func(a, b K) bool, f func(key K, value V) bool) []V {
+	var keys []K
+	var values []V
+	m.Range(func(key K, value V) bool {
+		if f == nil || f(key, value) {
+			keys = append(keys, key)
+			values = append(values, value)
+		}
+		return true
+	})
+	if less == nil || len(keys) == 0 {
+		return values
+	}
+
+	indexes := make([]int, len(keys))
+	for i := range indexes {
+		indexes[i] = i
+	}
+	slices.SortFunc(indexes, func(a, b int) bool { return less(keys[a], keys[b]) })
+	res := make([]V, 0, len(values))
+	for _, i := range indexes {
+		res = append(res, values[i])
+	}
+	return res
+}
+
+// KeysSorted returns the keys of the map ordered with less.
+func (m *SyncMap[K, V]) KeysSorted(less func(a, b K) bool) []K {
+	keys := m.Keys()
+	slices.SortFunc(keys, less)
+	return keys
+}
+
 func (m *SyncMap[K, V]) CountAll(f func(key K, value V) bool) (i int) {
 	m.Range(func(key K, value V) bool {
 		if f(key, value) {
@@ -169,6 +256,7 @@ func (m *SyncMap[K, V]) Merge(n *SyncMap[K, V]) {
 	})
 }
 
+// Keys returns the keys of the map in no particular order. Sort them for ordered listing.
 func (m *SyncMap[K, V]) Keys() (l []K) {
 	m.Range(func(key K, _ V) bool {
 		l = append(l, key)
@@ -185,6 +273,7 @@ func (m *SyncMap[K, V]) Values() (l []V) {
 	return l
 }
 
+// Len returns the number of entries. It iterates over the map, so entries stored or deleted during the call may or may not be counted.
 func (m *SyncMap[K, V]) Len() (i int) {
 	m.m.Range(func(_ any, _ any) bool {
 		i++
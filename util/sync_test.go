@@ -1,10 +1,13 @@
 package util
 
 import (
+	"errors"
 	"sort"
 	"sync"
+	"sync/atomic"
 	"testing"
 
+	"github.com/samber/lo"
 	"github.com/stretchr/testify/assert"
 	"golang.org/x/exp/slices"
 )
@@ -47,7 +50,7 @@ func TestSyncMap_LoadAll(t *testing.T) {
 func TestSyncMap_LoadOrStore(t *testing.T) {
 	s := &SyncMap[string, string]{}
 	res, ok := s.LoadOrStore("a", "A")
-	assert.Equal(t, "", res)
+	assert.Equal(t, "A", res)
 	assert.False(t, ok)
 	res, ok = s.LoadOrStore("a", "AA")
 	assert.Equal(t, "A", res)
@@ -57,6 +60,130 @@ func TestSyncMap_LoadOrStore(t *testing.T) {
 	assert.True(t, ok)
 }
 
+func TestSyncMap_LoadOrStore_Interface(t *testing.T) {
+	s := &SyncMap[string, error]{}
+	res, ok := s.LoadOrStore("a", nil)
+	assert.Nil(t, res)
+	assert.False(t, ok)
+	res, ok = s.LoadOrStore("a", errors.New("a"))
+	assert.Nil(t, res)
+	assert.True(t, ok)
+
+	err := errors.New("b")
+	res, ok = s.LoadOrStore("b", err)
+	assert.Same(t, err, res)
+	assert.False(t, ok)
+}
+
+func TestSyncMap_LoadOrStore_Concurrent(t *testing.T) {
+	s := &SyncMap[string, int]{}
+	const n = 100
+
+	var wg sync.WaitGroup
+	results := make([]int, n)
+	stored := make([]bool, n)
+	for i := 0; i < n; i++ {
+		i := i
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, loaded := s.LoadOrStore("a", i)
+			results[i] = v
+			stored[i] = !loaded
+		}()
+	}
+	wg.Wait()
+
+	// exactly one store wins and all callers see its value
+	assert.Equal(t, 1, lo.Count(stored, true))
+	winner := lo.IndexOf(stored, true)
+	for _, v := range results {
+		assert.Equal(t, winner, v)
+	}
+	got, _ := s.Load("a")
+	assert.Equal(t, winner, got)
+}
+
+func TestSyncMap_CompareAndDelete(t *testing.T) {
+	s := &SyncMap[string, string]{}
+	s.Store("a", "A")
+
+	assert.False(t, s.CompareAndDelete("a", "B"))
+	assert.False(t, s.CompareAndDelete("b", "A"))
+	_, ok := s.Load("a")
+	assert.True(t, ok)
+
+	assert.True(t, s.CompareAndDelete("a", "A"))
+	_, ok = s.Load("a")
+	assert.False(t, ok)
+	assert.False(t, s.CompareAndDelete("a", "A"))
+}
+
+func TestSyncMap_CompareAndDelete_Concurrent(t *testing.T) {
+	s := &SyncMap[string, int]{}
+	s.Store("a", 1)
+	const n = 100
+
+	var wg sync.WaitGroup
+	deleted := make([]bool, n)
+	for i := 0; i < n; i++ {
+		i := i
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			deleted[i] = s.CompareAndDelete("a", 1)
+		}()
+	}
+	wg.Wait()
+
+	// exactly one caller deletes the entry
+	assert.Equal(t, 1, lo.Count(deleted, true))
+	_, ok := s.Load("a")
+	assert.False(t, ok)
+}
+
+func TestSyncMap_GetOrCompute(t *testing.T) {
+	s := &SyncMap[string, string]{}
+	res, ok := s.GetOrCompute("a", func() string { return "A" })
+	assert.Equal(t, "A", res)
+	assert.True(t, ok)
+	res, ok = s.GetOrCompute("a", func() string {
+		t.Fatal("fn should not be called")
+		return ""
+	})
+	assert.Equal(t, "A", res)
+	assert.False(t, ok)
+
+	var calls int32
+	var computed int32
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, ok := s.GetOrCompute("b", func() string {
+				atomic.AddInt32(&calls, 1)
+				return "B"
+			})
+			assert.Equal(t, "B", v)
+			if ok {
+				atomic.AddInt32(&computed, 1)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), calls)
+	assert.Equal(t, int32(1), computed)
+
+	// a value stored while fn is running wins
+	res, ok = s.GetOrCompute("c", func() string {
+		s.Store("c", "stored")
+		return "C"
+	})
+	assert.Equal(t, "stored", res)
+	assert.False(t, ok)
+}
+
 func TestSyncMap_LoadAndDelete(t *testing.T) {
 	s := &SyncMap[string, string]{}
 	res, ok := s.LoadAndDelete("a")
@@ -115,6 +242,44 @@ func TestSyncMap_Range(t *testing.T) {
 	assert.Equal(t, 1, vv)
 }
 
+func TestSyncMap_Range_Concurrent(t *testing.T) {
+	s := &SyncMap[int, int]{}
+	for i := 0; i < 100; i++ {
+		s.Store(i, i)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 100; i < 200; i++ {
+			s.Store(i, i)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for j := 0; j < 10; j++ {
+			n := 0
+			s.Range(func(k, v int) bool {
+				assert.Equal(t, k, v)
+				n++
+				return true
+			})
+			// entries stored before Range are always visited
+			assert.GreaterOrEqual(t, n, 100)
+			assert.GreaterOrEqual(t, s.Len(), 100)
+			assert.GreaterOrEqual(t, len(s.Keys()), 100)
+		}
+	}()
+	wg.Wait()
+
+	assert.Equal(t, 200, s.Len())
+	keys := s.Keys()
+	slices.Sort(keys)
+	assert.Equal(t, 0, keys[0])
+	assert.Equal(t, 199, keys[199])
+}
+
 func TestSyncMap_Unsync(t *testing.T) {
 	m := map[string]int{"a": 1, "b": 2}
 	assert.Equal(t, m, SyncMapFrom(m).Unsync())
@@ -153,6 +318,38 @@ func TestSyncMap_FindAll(t *testing.T) {
 	assert.Equal(t, []int(nil), res)
 }
 
+func TestSyncMap_FindAllSorted(t *testing.T) {
+	s := &SyncMap[string, int]{}
+	for i, k := range []string{"d", "b", "e", "a", "c"} {
+		s.Store(k, i)
+	}
+	less := func(a, b string) bool { return a < b }
+
+	// the order is stable across calls
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, []int{3, 1, 4, 0, 2}, s.FindAllSorted(less, nil))
+		assert.Equal(t, []int{1, 0, 2}, s.FindAllSorted(less, func(k string, v int) bool {
+			return k != "a" && k != "c"
+		}))
+	}
+	assert.Equal(t, []int(nil), s.FindAllSorted(less, func(k string, v int) bool { return false }))
+
+	res := s.FindAllSorted(nil, nil)
+	slices.Sort(res)
+	assert.Equal(t, []int{0, 1, 2, 3, 4}, res)
+}
+
+func TestSyncMap_KeysSorted(t *testing.T) {
+	s := &SyncMap[string, int]{}
+	s.Store("b", 1)
+	s.Store("c", 2)
+	s.Store("a", 3)
+
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, []string{"a", "b", "c"}, s.KeysSorted(func(a, b string) bool { return a < b }))
+	}
+}
+
 func TestSyncMap_CountAll(t *testing.T) {
 	s := &SyncMap[string, int]{}
 	s.Store("a", 1)
@@ -234,6 +431,40 @@ func TestSyncMap_Len(t *testing.T) {
 	assert.Equal(t, 2, s.Len())
 }
 
+func TestSyncMap_Len_Concurrent(t *testing.T) {
+	s := &SyncMap[int, int]{}
+	const n = 100
+
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		i := i
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			s.Store(i, i)
+		}()
+		go func() {
+			defer wg.Done()
+			// the same key may be stored many times
+			s.Store(i%10, i)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, n, s.Len())
+
+	for i := 0; i < n; i += 2 {
+		i := i
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.Delete(i)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, n/2, s.Len())
+	assert.Equal(t, n/4, s.CountAll(func(k, _ int) bool { return k%4 == 1 }))
+}
+
 func TestLockMap(t *testing.T) {
 	m := LockMap[string]{}
 	res := []string{}